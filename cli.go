@@ -0,0 +1,156 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/linyows/ghe-repo-transfer/mover"
+)
+
+var commands = []struct {
+	Name  string
+	Usage string
+	Run   func(ctx context.Context, o *mover.Options) error
+}{
+	{mover.CmdMigrate, "move SRC to DST (default)", runMigrate},
+	{mover.CmdExport, "save SRC to -export-file", runExport},
+	{mover.CmdImport, "create DST from -export-file", runImport},
+	{mover.CmdVerify, "compare the moved issues of DST with SRC", runVerify},
+	{mover.CmdUsers, "list the users of SRC and whether they exist in DST", runUsers},
+	{mover.CmdStatus, "show the state files", runStatus},
+	{mover.CmdFinalize, "archive SRC after a clean verify", runFinalize},
+}
+
+// Run runs the command of the args. The flags without a command run
+// migrate, as the mover had no commands before.
+func Run(ctx context.Context, args []string) error {
+	name := mover.CmdMigrate
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		name, args = args[0], args[1:]
+	}
+	if name == "help" {
+		usage(os.Stdout)
+		return nil
+	}
+	for _, c := range commands {
+		if c.Name != name {
+			continue
+		}
+		o, err := mover.LoadOptions(name, args)
+		if err != nil {
+			return err
+		}
+		return c.Run(ctx, o)
+	}
+	usage(os.Stderr)
+	return fmt.Errorf("unknown command: %s", name)
+}
+
+func usage(w io.Writer) {
+	fmt.Fprintf(w, "Usage: github-issues-mover <command> [flags]\n\nCommands:\n")
+	for _, c := range commands {
+		fmt.Fprintf(w, "  %-8s %s\n", c.Name, c.Usage)
+	}
+	fmt.Fprintf(w, "\nRun 'github-issues-mover <command> -h' for the flags of a command.\n")
+}
+
+func runMigrate(ctx context.Context, o *mover.Options) error {
+	if o.SrcOrg != "" {
+		return mover.RunOrg(ctx, o)
+	}
+	if o.Manifest != "" {
+		return mover.RunBatch(ctx, o)
+	}
+	t, err := mover.New(ctx, o, nil)
+	if err != nil {
+		return err
+	}
+	return t.Exec(ctx)
+}
+
+func runExport(ctx context.Context, o *mover.Options) error {
+	t, err := mover.New(ctx, o, nil)
+	if err != nil {
+		return err
+	}
+	if err := t.Fetch(ctx); err != nil {
+		return err
+	}
+	return t.Export(o.ExportPath)
+}
+
+func runImport(ctx context.Context, o *mover.Options) error {
+	e, err := mover.LoadExport(o.ExportPath)
+	if err != nil {
+		return err
+	}
+	e.SetSource(o)
+	t, err := mover.New(ctx, o, nil)
+	if err != nil {
+		return err
+	}
+	t.Import(e)
+	return t.Apply(ctx)
+}
+
+func runVerify(ctx context.Context, o *mover.Options) error {
+	t, err := mover.New(ctx, o, nil)
+	if err != nil {
+		return err
+	}
+	if err := t.Fetch(ctx); err != nil {
+		return err
+	}
+	return t.Verify(ctx)
+}
+
+func runFinalize(ctx context.Context, o *mover.Options) error {
+	t, err := mover.New(ctx, o, nil)
+	if err != nil {
+		return err
+	}
+	if err := t.Fetch(ctx); err != nil {
+		return err
+	}
+	return t.Finalize(ctx, o.Confirm)
+}
+
+func runUsers(ctx context.Context, o *mover.Options) error {
+	t, err := mover.New(ctx, o, nil)
+	if err != nil {
+		return err
+	}
+	if err := t.Fetch(ctx); err != nil {
+		return err
+	}
+	t.ShowUsers(ctx)
+	return nil
+}
+
+func runStatus(ctx context.Context, o *mover.Options) error {
+	st, err := mover.LoadState(o.StatePath)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("state file: %s\n", o.StatePath)
+	fmt.Printf("  issues: %d (%d backlinked)\n", len(st.Numbers), len(st.Backlinked))
+	fmt.Printf("  dummies: %d (%d removed)\n", len(st.Dummies), len(st.Removed))
+	fmt.Printf("  discussions: %d\n", len(st.Discussions))
+	fmt.Printf("  projects: %d\n", len(st.Projects))
+
+	if _, err := os.Stat(o.BatchStatePath); err != nil {
+		return nil
+	}
+	bs, err := mover.LoadBatchState(o.BatchStatePath)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("batch state file: %s\n", o.BatchStatePath)
+	for _, r := range bs.Results {
+		fmt.Printf("  %-8s %-40s %s\n", r.Status, r.Src+" -> "+r.Dst, r.Error)
+	}
+	return nil
+}
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRun(t *testing.T) {
+	dir, err := ioutil.TempDir("", "mover-cli")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	state := filepath.Join(dir, "state.json")
+	if err := ioutil.WriteFile(state, []byte(`{"numbers": {"1": 1}}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		args   []string
+		hasErr bool
+	}{
+		{[]string{"help"}, false},
+		{[]string{"status", "-state-file", state}, false},
+		{[]string{"unknown"}, true},
+		{[]string{"-src", "foo"}, true},
+		{[]string{"verify", "-src", "foo/src", "-dst", "foo/dst", "-backlink"}, true},
+		{[]string{"import", "-dst", "foo/dst", "-export-file", filepath.Join(dir, "missing.json")}, true},
+	}
+	for _, tt := range tests {
+		if err := Run(context.Background(), tt.args); (err != nil) != tt.hasErr {
+			t.Errorf("%v: %v", tt.args, err)
+		}
+	}
+}
@@ -3,8 +3,11 @@ module github.com/linyows/ghe-repo-transfer
 go 1.13
 
 require (
-	github.com/google/go-github/v32 v32.1.0 // indirect
+	github.com/google/go-github/v32 v32.1.0
 	github.com/shurcooL/githubv4 v0.0.0-20200928013246-d292edc3691b
 	github.com/shurcooL/graphql v0.0.0-20200928012149-18c5c3165e3a // indirect
+	github.com/yuin/goldmark v1.4.12
 	golang.org/x/oauth2 v0.0.0-20201109201403-9fd604954f58
+	gopkg.in/yaml.v2 v2.2.2
+	gopkg.in/yaml.v3 v3.0.1
 )
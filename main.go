@@ -2,17 +2,16 @@ package main
 
 import (
 	"context"
+	"flag"
 	"fmt"
+	"os"
 )
 
 func main() {
-	ctx := context.Background()
-	transfer, err := New(ctx)
-	if err != nil {
-		fmt.Printf("%#v\n", err)
-		return
-	}
-	if err := transfer.Exec(ctx); err != nil {
-		fmt.Printf("%#v\n", err)
+	if err := Run(context.Background(), os.Args[1:]); err != nil {
+		if err != flag.ErrHelp {
+			fmt.Fprintf(os.Stderr, "%s\n", err)
+		}
+		os.Exit(1)
 	}
 }
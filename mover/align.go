@@ -0,0 +1,204 @@
+package mover
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+)
+
+const (
+	// AlignStrict requires the source numbers to be contiguous and
+	// the destination to be empty, and fails on any mismatch.
+	AlignStrict = "strict"
+	// AlignFill fills the gaps of the source numbers with dummies.
+	AlignFill = "fill"
+	// AlignNone creates issues sequentially and records the number map.
+	AlignNone = "none"
+
+	defaultDummyTitle = "Dummy"
+	defaultDummyBody  = "This is a dummy to align the issue numbers for move."
+	defaultDummyLabel = "dummy"
+	dummyLabelColor   = "eeeeee"
+	dummyLockReason   = "resolved"
+)
+
+func validAlign(mode string) bool {
+	switch mode {
+	case AlignStrict, AlignFill, AlignNone:
+		return true
+	}
+	return false
+}
+
+// items returns the issues and pull requests ordered by number.
+func (t *Transfer) items() []*Issue {
+	var items []*Issue
+	for i := range t.Issues {
+		items = append(items, &t.Issues[i])
+	}
+	for i := range t.Pulls {
+		items = append(items, &t.Pulls[i])
+	}
+	sort.Slice(items, func(i, j int) bool {
+		return items[i].Number < items[j].Number
+	})
+	return items
+}
+
+// checkDst verifies that the existing DST issues up to last were created
+// by a previous run, so that the numbers can still be aligned.
+func (t *Transfer) checkDst(last int) error {
+	for n := 1; n <= last; n++ {
+		if !t.State.HasDst(n) {
+			return fmt.Errorf("dst already has issue #%d not created by this tool, numbers cannot be aligned", n)
+		}
+	}
+	return nil
+}
+
+func (t *Transfer) doIssuesAligned(ctx context.Context) error {
+	last, err := t.Destination.LastNumber(ctx)
+	if err != nil {
+		return err
+	}
+	if err := t.checkDst(last); err != nil {
+		return err
+	}
+
+	items := t.items()
+	if t.Align == AlignStrict {
+		for i, v := range items {
+			if v.Number != i+1 {
+				return fmt.Errorf("numbers cannot be aligned strictly: %s", t.findGap(ctx, i+1))
+			}
+		}
+	}
+
+	n := 1
+	for _, v := range items {
+		for ; n < v.Number; n++ {
+			if n <= last {
+				continue
+			}
+			if err := t.createDummy(ctx, n); err != nil {
+				return err
+			}
+		}
+		n = v.Number + 1
+		if v.Number <= last {
+			logf(t.Log, "skipped issue: #%d - already moved\n", v.Number)
+			continue
+		}
+		got, err := t.createIssue(ctx, v, t.Align == AlignStrict)
+		if err != nil {
+			return t.recordFailed(v.Number, got, err)
+		}
+		if got > 0 && got != v.Number {
+			if t.Align == AlignStrict {
+				return fmt.Errorf("src #%d was created as dst #%d", v.Number, got)
+			}
+			logf(t.Log, "number mismatch: src #%d was created as dst #%d\n", v.Number, got)
+		} else {
+			got = v.Number
+		}
+		if err := t.recordNumber(v.Number, got); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (t *Transfer) doIssuesSequential(ctx context.Context) error {
+	for _, v := range t.items() {
+		if n, ok := t.State.Numbers[v.Number]; ok {
+			logf(t.Log, "skipped issue: #%d - already moved to #%d\n", v.Number, n)
+			continue
+		}
+		got, err := t.createIssue(ctx, v, true)
+		if err != nil {
+			return t.recordFailed(v.Number, got, err)
+		}
+		if err := t.recordNumber(v.Number, got); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (t *Transfer) recordNumber(src, dst int) error {
+	t.State.Numbers[src] = dst
+	return t.State.Save(t.StatePath)
+}
+
+// recordFailed records the number of the issue created before err, so that
+// the next run does not take it for an issue not created by this tool.
+func (t *Transfer) recordFailed(src, dst int, err error) error {
+	if dst > 0 {
+		if err := t.recordNumber(src, dst); err != nil {
+			return err
+		}
+	}
+	return err
+}
+
+func (t *Transfer) createDummy(ctx context.Context, n int) error {
+	gap := t.findGap(ctx, n)
+	now := time.Now()
+	var got int
+	var err error
+	if t.IsImport {
+		got, err = t.Destination.ImportIssue(ctx, t.buildImportDummyIssueRequest(&now, gap), t.DummyLock)
+	} else {
+		got, err = t.Destination.CreateIssue(ctx, t.buildCreateDummyIssueRequest(&now, gap))
+	}
+	if err != nil {
+		if got > 0 {
+			t.State.Dummies = append(t.State.Dummies, got)
+			if err := t.State.Save(t.StatePath); err != nil {
+				return err
+			}
+		}
+		return err
+	}
+	logf(t.Log, "created dummy: #%d - %s\n", n, gap)
+	if got == 0 {
+		got = n
+	}
+	t.State.Dummies = append(t.State.Dummies, got)
+	if err := t.State.Save(t.StatePath); err != nil {
+		return err
+	}
+	if t.DummyLock {
+		if err := t.Destination.LockIssue(ctx, got, dummyLockReason); err != nil {
+			return err
+		}
+		logf(t.Log, "locked dummy: #%d\n", got)
+	}
+	return nil
+}
+
+// createIssue creates the issue, or a dummy when v is nil, and returns
+// the DST number. When the import api is used, the number is known only
+// after waiting for the import, otherwise 0 is returned.
+func (t *Transfer) createIssue(ctx context.Context, v *Issue, wait bool) (int, error) {
+	if t.IsImport {
+		return t.Destination.ImportIssue(ctx, t.buildImportIssueRequest(ctx, v), wait)
+	}
+	return t.Destination.CreateIssue(ctx, t.buildCreateIssueRequest(ctx, v))
+}
+
+func (t *Transfer) ensureDummyLabel(ctx context.Context) error {
+	if t.DummyLabel == "" {
+		return nil
+	}
+	created, err := t.Destination.EnsureLabel(ctx, Label{Name: t.DummyLabel, Color: dummyLabelColor})
+	if err != nil {
+		return err
+	}
+	if created {
+		logf(t.Log, "created label: %s\n", t.DummyLabel)
+	}
+	return nil
+}
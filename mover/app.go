@@ -0,0 +1,175 @@
+package mover
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strconv"
+	"time"
+
+	"golang.org/x/oauth2"
+)
+
+const (
+	// appJWTLifetime is within the 10 minutes GitHub allows.
+	appJWTLifetime = 9 * time.Minute
+	// appTokenRefresh is how long before the expiry an installation token
+	// is refreshed, so that a request does not start with a token about to expire.
+	appTokenRefresh = 5 * time.Minute
+)
+
+// App is the installation of a GitHub App to authenticate as.
+type App struct {
+	ID             int64
+	InstallationID int64
+	Key            *rsa.PrivateKey
+	// Log is where the refreshes are printed, the standard output when nil.
+	Log io.Writer
+}
+
+// ParseAppKey parses the PEM private key of a GitHub App.
+func ParseAppKey(buf []byte) (*rsa.PrivateKey, error) {
+	block, _ := pem.Decode(buf)
+	if block == nil {
+		return nil, fmt.Errorf("invalid app private key: no PEM block")
+	}
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("invalid app private key: %s", err)
+	}
+	rsaKey, ok := key.(*rsa.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("invalid app private key: not RSA")
+	}
+	return rsaKey, nil
+}
+
+// JWT returns the JSON Web Token of the app, signed with its key by RS256.
+func (a *App) JWT(now time.Time) (string, error) {
+	enc := base64.RawURLEncoding
+	header, err := json.Marshal(map[string]string{"alg": "RS256", "typ": "JWT"})
+	if err != nil {
+		return "", err
+	}
+	claims, err := json.Marshal(map[string]interface{}{
+		// iat is a minute ago for the clock drift.
+		"iat": now.Add(-time.Minute).Unix(),
+		"exp": now.Add(appJWTLifetime).Unix(),
+		"iss": strconv.FormatInt(a.ID, 10),
+	})
+	if err != nil {
+		return "", err
+	}
+	unsigned := enc.EncodeToString(header) + "." + enc.EncodeToString(claims)
+	hash := sha256.Sum256([]byte(unsigned))
+	sig, err := rsa.SignPKCS1v15(rand.Reader, a.Key, crypto.SHA256, hash[:])
+	if err != nil {
+		return "", err
+	}
+	return unsigned + "." + enc.EncodeToString(sig), nil
+}
+
+// TokenSource returns the installation tokens of the app on the endpoint,
+// refreshed before they expire.
+func (a *App) TokenSource(ctx context.Context, endpoint string) oauth2.TokenSource {
+	return oauth2.ReuseTokenSource(nil, &appTokenSource{ctx: ctx, app: a, endpoint: endpoint})
+}
+
+type appTokenSource struct {
+	ctx      context.Context
+	app      *App
+	endpoint string
+}
+
+// Token exchanges a new JWT for an installation token.
+func (s *appTokenSource) Token() (*oauth2.Token, error) {
+	jwt, err := s.app.JWT(time.Now())
+	if err != nil {
+		return nil, err
+	}
+	client, err := newRESTClient(s.ctx, s.endpoint, oauth2.StaticTokenSource(&oauth2.Token{AccessToken: jwt}))
+	if err != nil {
+		return nil, err
+	}
+	got, _, err := client.Apps.CreateInstallationToken(s.ctx, s.app.InstallationID, nil)
+	if err != nil {
+		return nil, err
+	}
+	logf(s.app.Log, "refreshed installation token: app %d, expires at %s\n", s.app.ID, got.GetExpiresAt().Format(time.RFC3339))
+	return &oauth2.Token{
+		AccessToken: got.GetToken(),
+		Expiry:      got.GetExpiresAt().Add(-appTokenRefresh),
+	}, nil
+}
+
+// loadApp returns the App of the options, or nil when the id is 0.
+func loadApp(id, installationID int, keyPath string) (*App, error) {
+	if id == 0 {
+		return nil, nil
+	}
+	buf, err := ioutil.ReadFile(keyPath)
+	if err != nil {
+		return nil, err
+	}
+	key, err := ParseAppKey(buf)
+	if err != nil {
+		return nil, err
+	}
+	return &App{ID: int64(id), InstallationID: int64(installationID), Key: key}, nil
+}
+
+func (o *Options) srcTokenSource(ctx context.Context) (oauth2.TokenSource, error) {
+	app, err := loadApp(o.SrcAppID, o.SrcAppInstallationID, o.SrcAppKey)
+	if err != nil {
+		return nil, err
+	}
+	if app != nil {
+		app.Log = o.Log
+	}
+	return tokenSource(ctx, o.SrcEndpoint, o.SrcToken, app), nil
+}
+
+func (o *Options) dstTokenSource(ctx context.Context) (oauth2.TokenSource, error) {
+	app, err := loadApp(o.DstAppID, o.DstAppInstallationID, o.DstAppKey)
+	if err != nil {
+		return nil, err
+	}
+	if app != nil {
+		app.Log = o.Log
+	}
+	return tokenSource(ctx, o.DstEndpoint, o.DstToken, app), nil
+}
+
+// tokenSource returns the installation tokens of the app, or the static token.
+func tokenSource(ctx context.Context, endpoint, token string, app *App) oauth2.TokenSource {
+	if app != nil {
+		return app.TokenSource(ctx, endpoint)
+	}
+	return oauth2.StaticTokenSource(&oauth2.Token{AccessToken: token})
+}
+
+// accessToken returns the current token of the source, or empty on error,
+// for the git commands, which do not take a TokenSource.
+func accessToken(w io.Writer, ts oauth2.TokenSource) string {
+	if ts == nil {
+		return ""
+	}
+	t, err := ts.Token()
+	if err != nil {
+		logf(w, "token error: %s\n", err)
+		return ""
+	}
+	return t.AccessToken
+}
@@ -0,0 +1,108 @@
+package mover
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func testAppKey(t *testing.T, dir string) (*rsa.PrivateKey, string) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, "app.pem")
+	buf := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+	if err := ioutil.WriteFile(path, buf, 0600); err != nil {
+		t.Fatal(err)
+	}
+	return key, path
+}
+
+func TestExecGitHubApp(t *testing.T) {
+	tests := []struct {
+		name      string
+		expiresIn time.Duration
+		refreshed bool
+	}{
+		{"reused", time.Hour, false},
+		// the tokens are refreshed every time they are used when they
+		// expire within the refresh time.
+		{"refreshed", appTokenRefresh, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFakeGitHub()
+			defer f.Close()
+			seedSrc(f)
+			dir := testDir(t)
+			defer os.RemoveAll(dir)
+			key, keyPath := testAppKey(t, dir)
+			f.AddInstallation(1, 10, &key.PublicKey, tt.expiresIn)
+			f.AddInstallation(2, 20, &key.PublicKey, tt.expiresIn)
+
+			o := testOptions(f, dir)
+			o.SrcToken, o.DstToken, o.DstAdminToken = "", "", ""
+			o.SrcAppID, o.SrcAppInstallationID, o.SrcAppKey = 1, 10, keyPath
+			o.DstAppID, o.DstAppInstallationID, o.DstAppKey = 2, 20, keyPath
+			if err := o.Validate(CmdMigrate); err != nil {
+				t.Fatal(err)
+			}
+
+			tr, err := New(context.Background(), o, testShared())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := tr.Exec(context.Background()); err != nil {
+				t.Fatal(err)
+			}
+			if got := f.Repo(testDst).Numbers(); len(got) != 5 {
+				t.Errorf("dst numbers: %v", got)
+			}
+			src, dst := f.Issued(10), f.Issued(20)
+			if tt.refreshed && (src < 2 || dst < 2) {
+				t.Errorf("issued tokens: src %d, dst %d, want refreshes", src, dst)
+			}
+			if !tt.refreshed && (src != 1 || dst != 1) {
+				t.Errorf("issued tokens: src %d, dst %d, want 1", src, dst)
+			}
+		})
+	}
+}
+
+func TestGitHubAppInvalid(t *testing.T) {
+	f := newFakeGitHub()
+	defer f.Close()
+	dir := testDir(t)
+	defer os.RemoveAll(dir)
+	key, keyPath := testAppKey(t, dir)
+	other, _ := rsa.GenerateKey(rand.Reader, 2048)
+	f.AddInstallation(1, 10, &other.PublicKey, time.Hour)
+
+	app := &App{ID: 1, InstallationID: 10, Key: key}
+	if _, err := app.TokenSource(context.Background(), f.URL).Token(); err == nil {
+		t.Error("token of the wrong key")
+	}
+
+	o := testOptions(f, dir)
+	o.SrcAppID = 1
+	if err := o.Validate(CmdMigrate); err == nil {
+		t.Error("app without the installation id and the key")
+	}
+	o.SrcAppInstallationID, o.SrcAppKey = 10, filepath.Join(dir, "replace.yml")
+	if _, err := New(context.Background(), o, testShared()); err == nil {
+		t.Error("app with an invalid key")
+	}
+	o.SrcAppKey = keyPath
+	o.SrcType = SrcGitLab
+	if err := o.Validate(CmdMigrate); err == nil {
+		t.Error("app for gitlab")
+	}
+}
@@ -0,0 +1,137 @@
+package mover
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+	"text/template"
+	"time"
+)
+
+const (
+	// the defaults are the header of the former versions.
+	defaultIssueHeader   = `<img src="{{.AvatarURL}}" width="25"> <b>{{.Author}}</b> commented{{if not .Imported}} ({{date .CreatedAt}}){{end}}:`
+	defaultCommentHeader = defaultIssueHeader
+	defaultTimeFormat    = time.RFC822
+	defaultTimeZone      = "UTC"
+)
+
+// Attribution is the data of the templates of the headers and the footer
+// of the moved issues, pull requests, comments and discussions.
+type Attribution struct {
+	// Author and AvatarURL are the SRC ones.
+	Author    string
+	AvatarURL string
+	// DstAuthor is the login after the user replacement, and DstAvatarURL
+	// is its avatar on the DST host.
+	DstAuthor    string
+	DstAvatarURL string
+	// CreatedAt is in the time zone of the templates.
+	CreatedAt time.Time
+	// URL is the SRC one, empty when the source does not tell.
+	URL string
+	// Number is the SRC number of the issue, pull request or discussion.
+	Number int
+	Src    string
+	// Imported is true when the import api keeps the original times, so
+	// that the headers do not need them.
+	Imported bool
+}
+
+// Templates are the text/template of the issue header, the comment header
+// and the footer. The header is put before the body with an empty line,
+// and the footer after it.
+type Templates struct {
+	IssueHeader   *template.Template
+	CommentHeader *template.Template
+	Footer        *template.Template
+	// Origin is the footer of the SRC url put after Footer of the issues,
+	// nil unless it is given.
+	Origin     *template.Template
+	Location   *time.Location
+	TimeFormat string
+	// Log is where the template errors are printed, the standard output when nil.
+	Log io.Writer
+}
+
+// NewTemplates parses the templates, which have the date function to
+// format a time by the layout of Go in the time zone, like Asia/Tokyo,
+// UTC by default.
+func NewTemplates(issueHeader, commentHeader, footer, zone, format string) (*Templates, error) {
+	loc := time.UTC
+	if zone != "" {
+		var err error
+		if loc, err = time.LoadLocation(zone); err != nil {
+			return nil, fmt.Errorf("invalid time zone: %s", zone)
+		}
+	}
+	if format == "" {
+		format = defaultTimeFormat
+	}
+	t := &Templates{Location: loc, TimeFormat: format}
+	funcs := template.FuncMap{
+		"date": func(v time.Time) string { return v.In(loc).Format(format) },
+	}
+	for _, v := range []struct {
+		name string
+		text string
+		tmpl **template.Template
+	}{
+		{"issue_header", issueHeader, &t.IssueHeader},
+		{"comment_header", commentHeader, &t.CommentHeader},
+		{"footer", footer, &t.Footer},
+	} {
+		tmpl, err := template.New(v.name).Funcs(funcs).Option("missingkey=error").Parse(v.text)
+		if err != nil {
+			return nil, err
+		}
+		// an error of the fields is found before any issue is moved.
+		if err := tmpl.Execute(&bytes.Buffer{}, Attribution{}); err != nil {
+			return nil, err
+		}
+		*v.tmpl = tmpl
+	}
+	return t, nil
+}
+
+// Issue returns the body of an issue with the header and the footers.
+func (t *Templates) Issue(a Attribution, body string) string {
+	s := t.wrap(t.IssueHeader, a, body)
+	if t.Origin == nil {
+		return s
+	}
+	if o := execute(t.Log, t.Origin, a); o != "" {
+		s += "\n\n" + o
+	}
+	return s
+}
+
+// Comment returns the body of a comment with the header and the footer.
+func (t *Templates) Comment(a Attribution, body string) string {
+	return t.wrap(t.CommentHeader, a, body)
+}
+
+func (t *Templates) wrap(header *template.Template, a Attribution, body string) string {
+	a.CreatedAt = a.CreatedAt.In(t.Location)
+	var parts []string
+	if h := execute(t.Log, header, a); h != "" {
+		parts = append(parts, h)
+	}
+	parts = append(parts, body)
+	if f := execute(t.Log, t.Footer, a); f != "" {
+		parts = append(parts, f)
+	}
+	return strings.Join(parts, "\n\n")
+}
+
+// execute returns the trimmed result of the template, or empty on error,
+// as the templates are checked by NewTemplates.
+func execute(w io.Writer, tmpl *template.Template, a Attribution) string {
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, a); err != nil {
+		logf(w, "template error: %s\n", err)
+		return ""
+	}
+	return strings.TrimSpace(buf.String())
+}
@@ -0,0 +1,106 @@
+package mover
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestTemplates(t *testing.T) {
+	a := Attribution{
+		Author:    "alice",
+		AvatarURL: "https://src.example.com/avatars/alice",
+		DstAuthor: "alice-dst",
+		CreatedAt: time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC),
+		URL:       "https://src.example.com/foo/bar/issues/1",
+		Number:    1,
+		Src:       "foo/bar",
+	}
+
+	d, err := NewTemplates(defaultIssueHeader, defaultCommentHeader, "", "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `<img src="https://src.example.com/avatars/alice" width="25"> <b>alice</b> commented (01 Jan 19 00:00 UTC):` + "\n\nbody"
+	if got := d.Issue(a, "body"); got != want {
+		t.Errorf("default: %q, want %q", got, want)
+	}
+	a.Imported = true
+	want = `<img src="https://src.example.com/avatars/alice" width="25"> <b>alice</b> commented:` + "\n\nbody"
+	if got := d.Comment(a, "body"); got != want {
+		t.Errorf("default imported: %q, want %q", got, want)
+	}
+
+	c, err := NewTemplates(
+		"@{{.DstAuthor}} wrote on {{date .CreatedAt}}",
+		"",
+		"_moved from [{{.Src}}#{{.Number}}]({{.URL}})_",
+		"Asia/Tokyo",
+		"2006-01-02 15:04",
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want = "@alice-dst wrote on 2019-01-01 09:00\n\nbody\n\n_moved from [foo/bar#1](https://src.example.com/foo/bar/issues/1)_"
+	if got := c.Issue(a, "body"); got != want {
+		t.Errorf("custom issue: %q, want %q", got, want)
+	}
+	want = "body\n\n_moved from [foo/bar#1](https://src.example.com/foo/bar/issues/1)_"
+	if got := c.Comment(a, "body"); got != want {
+		t.Errorf("custom comment: %q, want %q", got, want)
+	}
+
+	invalid := []struct {
+		name   string
+		header string
+		zone   string
+	}{
+		{"parse", "{{.Author", ""},
+		{"field", "{{.Login}}", ""},
+		{"zone", "", "Mars/Olympus"},
+	}
+	for _, tt := range invalid {
+		if _, err := NewTemplates(tt.header, "", "", tt.zone, ""); err == nil {
+			t.Errorf("%s: no error", tt.name)
+		}
+	}
+}
+
+func TestExecTemplates(t *testing.T) {
+	f := newFakeGitHub()
+	defer f.Close()
+	seedSrc(f)
+	f.Repo(testSrc).Issues[1].URL = "https://src.example.com/foo/bar/issues/1"
+	dir := testDir(t)
+	defer os.RemoveAll(dir)
+
+	o := testOptions(f, dir)
+	o.IsImport = false
+	o.IssueHeader = "{{.Author}} as {{.DstAuthor}}: {{.DstAvatarURL}}"
+	o.CommentHeader = "{{.Author}} ({{date .CreatedAt}})"
+	o.Footer = "{{with .URL}}from {{.}}{{end}}"
+	o.TimeFormat = "2006-01-02"
+	tr, err := New(context.Background(), o, testShared())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := tr.Exec(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	first := f.Repo(testDst).Issues[1]
+	want := "alice as alice-dst: " + f.URL + "/alice-dst.png\n\nsee https://dst.example.com/foo\n\nfrom https://src.example.com/foo/bar/issues/1"
+	if first.Body != want {
+		t.Errorf("#1 body: %q, want %q", first.Body, want)
+	}
+	want = "bob (2019-01-02)\n\na comment"
+	if len(first.Comments.Nodes) != 1 || first.Comments.Nodes[0].Body != want {
+		t.Errorf("#1 comments: %#v, want %q", first.Comments.Nodes, want)
+	}
+
+	o.Footer = "{{.Missing}}"
+	if _, err := New(context.Background(), o, testShared()); err == nil {
+		t.Error("invalid footer")
+	}
+}
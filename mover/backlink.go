@@ -0,0 +1,124 @@
+package mover
+
+import (
+	"bytes"
+	"context"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"github.com/google/go-github/v32/github"
+)
+
+const (
+	defaultMovedNotice  = "This issue was moved to {{.URL}}."
+	defaultOriginFooter = "{{with .URL}}Originally at {{.}}{{end}}"
+)
+
+// Backlink is the data of the template of the moved notice.
+type Backlink struct {
+	// URL and Number are the DST ones.
+	URL    string
+	Number int
+	Dst    string
+}
+
+// parseMovedNotice parses the template of the moved notice, checking
+// the fields before any issue is moved.
+func parseMovedNotice(text string) (*template.Template, error) {
+	tmpl, err := template.New("moved_notice").Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, err
+	}
+	if err := tmpl.Execute(&bytes.Buffer{}, Backlink{}); err != nil {
+		return nil, err
+	}
+	return tmpl, nil
+}
+
+// DoBacklinks comments on the moved SRC issues and pull requests with the
+// links to the DST ones, and closes and locks them if configured, so that
+// people go on in DST. The numbers commented and the ones done are recorded
+// in the state, so that a resumed run does not comment again.
+func (t *Transfer) DoBacklinks(ctx context.Context) error {
+	// the discussions moved as issues cannot be commented by the issues api.
+	discussions := map[int]bool{}
+	for _, d := range t.Discussions {
+		discussions[d.Number] = true
+	}
+	var numbers []int
+	for n := range t.State.Numbers {
+		if !t.State.IsBacklinked(n) && !discussions[n] {
+			numbers = append(numbers, n)
+		}
+	}
+	sort.Ints(numbers)
+	open := map[int]bool{}
+	for _, v := range t.items() {
+		open[v.Number] = !v.Closed
+	}
+
+	for _, n := range numbers {
+		dn := t.State.Numbers[n]
+		if !t.State.IsNoticed(n) {
+			var buf bytes.Buffer
+			b := Backlink{URL: t.dstIssueURL(dn), Number: dn, Dst: t.Destination.Repo()}
+			if err := t.MovedNotice.Execute(&buf, b); err != nil {
+				return err
+			}
+			body := buf.String()
+			if _, _, err := t.SRC.REST.Issues.CreateComment(ctx, t.SRC.Owner, t.SRC.Name, n, &github.IssueComment{Body: &body}); err != nil {
+				return err
+			}
+			t.State.Noticed = append(t.State.Noticed, n)
+			if err := t.State.Save(t.StatePath); err != nil {
+				return err
+			}
+		}
+		if t.CloseSrc {
+			// the SRC state is recorded before the close, for the verify.
+			if open[n] && !t.State.IsClosedSrc(n) {
+				t.State.ClosedSrc = append(t.State.ClosedSrc, n)
+				if err := t.State.Save(t.StatePath); err != nil {
+					return err
+				}
+			}
+			state := "closed"
+			if _, _, err := t.SRC.REST.Issues.Edit(ctx, t.SRC.Owner, t.SRC.Name, n, &github.IssueRequest{State: &state}); err != nil {
+				return err
+			}
+		}
+		if t.LockSrc {
+			if _, err := t.SRC.REST.Issues.Lock(ctx, t.SRC.Owner, t.SRC.Name, n, &github.LockIssueOptions{LockReason: "resolved"}); err != nil {
+				return err
+			}
+		}
+		logf(t.Log, "backlinked: #%d to %s#%d\n", n, t.Destination.Repo(), dn)
+
+		t.State.Backlinked = append(t.State.Backlinked, n)
+		if err := t.State.Save(t.StatePath); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (t *Transfer) dstIssueURL(n int) string {
+	if u := t.dstRepoURL(); u != "" {
+		return u + "/issues/" + strconv.Itoa(n)
+	}
+	return t.Destination.Repo() + "#" + strconv.Itoa(n)
+}
+
+// dstRepoURL returns the web url of DST, or empty when it is unknown.
+func (t *Transfer) dstRepoURL() string {
+	switch d := t.Destination.(type) {
+	case *DST:
+		return webURL(d.Endpoint) + "/" + d.Repo()
+	case *Gitea:
+		return strings.TrimSuffix(d.Endpoint, "/") + "/" + d.Repo()
+	}
+	return ""
+}
@@ -0,0 +1,167 @@
+package mover
+
+import (
+	"context"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestExecBacklink(t *testing.T) {
+	f := newFakeGitHub()
+	defer f.Close()
+	f.AddUser("alice-dst")
+	seedSrc(f)
+	f.Repo(testSrc).Issues[1].URL = "https://src.example.com/foo/bar/issues/1"
+	f.Repo(testSrc).Issues[1].Comments.Nodes[0].URL = "https://src.example.com/foo/bar/issues/1#issuecomment-1"
+	dir := testDir(t)
+	defer os.RemoveAll(dir)
+
+	o := testOptions(f, dir)
+	o.OriginFooter = true
+	o.Backlink = true
+	o.CloseSrc = true
+	o.LockSrc = true
+	tr, err := New(context.Background(), o, testShared())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := tr.Exec(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	dst := f.Repo(testDst)
+	if !strings.HasSuffix(dst.Issues[1].Body, "\n\nOriginally at https://src.example.com/foo/bar/issues/1") {
+		t.Errorf("#1 body: %q, want the origin footer", dst.Issues[1].Body)
+	}
+	if c := dst.Issues[1].Comments.Nodes[0].Body; strings.Contains(c, "Originally at") {
+		t.Errorf("#1 comment: %q, want no origin footer", c)
+	}
+	src := f.Repo(testSrc)
+	for _, n := range []int{1, 2, 4, 5} {
+		v := src.Issues[n]
+		want := "This issue was moved to " + f.URL + "/" + testDst + "/issues/" + strconv.Itoa(n) + "."
+		if len(v.Comments.Nodes) == 0 || v.Comments.Nodes[len(v.Comments.Nodes)-1].Body != want {
+			t.Errorf("src #%d comments: %#v, want %q", n, v.Comments.Nodes, want)
+		}
+		if !v.Closed || !v.Locked {
+			t.Errorf("src #%d: closed %t, locked %t", n, v.Closed, v.Locked)
+		}
+	}
+	if len(tr.State.Backlinked) != 4 {
+		t.Errorf("backlinked: %v", tr.State.Backlinked)
+	}
+
+	// a resumed run does not comment again.
+	tr, err = New(context.Background(), o, testShared())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := tr.Exec(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if got := len(src.Issues[2].Comments.Nodes); got != 1 {
+		t.Errorf("src #2 comments: %d, want 1", got)
+	}
+
+	// the notices and the closes by the back-link are not differences, but
+	// the state of the SRC issue closed in the meantime is.
+	if err := tr.Fetch(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if err := tr.Verify(context.Background()); err != nil {
+		t.Errorf("verify of the back-linked issues: %s", err)
+	}
+	dst.Issues[1].Closed = false
+	dst.Issues[1].State = "OPEN"
+	if err := tr.Verify(context.Background()); err == nil || !strings.Contains(err.Error(), "1 issues") {
+		t.Errorf("verify of the reopened issue: %v", err)
+	}
+}
+
+func TestExecBacklinkResumeAfterClose(t *testing.T) {
+	f := newFakeGitHub()
+	defer f.Close()
+	f.AddUser("alice-dst")
+	seedSrc(f)
+	dir := testDir(t)
+	defer os.RemoveAll(dir)
+
+	o := testOptions(f, dir)
+	o.Backlink = true
+	o.CloseSrc = true
+	f.Fail("PATCH", "/repos/"+testSrc+"/issues/2", 422, 1)
+	tr, err := New(context.Background(), o, testShared())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := tr.Exec(context.Background()); err == nil {
+		t.Fatal("no error of the close")
+	}
+
+	// the resumed run closes #2 without commenting again.
+	tr, err = New(context.Background(), o, testShared())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := tr.Exec(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	v := f.Repo(testSrc).Issues[2]
+	if got := len(v.Comments.Nodes); got != 1 || !v.Closed {
+		t.Errorf("src #2: %d comments, closed %t, want 1 notice and closed", got, v.Closed)
+	}
+}
+
+func TestExecBacklinkFailedImport(t *testing.T) {
+	f := newFakeGitHub()
+	defer f.Close()
+	f.ImportPolls = 1
+	f.FailImportOf = "second"
+	seedSrc(f)
+	dir := testDir(t)
+	defer os.RemoveAll(dir)
+
+	o := testOptions(f, dir)
+	o.Backlink = true
+	o.CloseSrc = true
+	tr, err := New(context.Background(), o, testShared())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := tr.Exec(context.Background()); err == nil || !strings.Contains(err.Error(), "issue imports failed: [2]") {
+		t.Fatalf("exec with the failed import: %v", err)
+	}
+
+	src := f.Repo(testSrc)
+	if v := src.Issues[2]; len(v.Comments.Nodes) != 0 || v.Closed {
+		t.Errorf("src #2 of the failed import: %d comments, closed %t", len(v.Comments.Nodes), v.Closed)
+	}
+	// #5 is imported as #4, after the dummy #3 imported as #2.
+	if n, ok := tr.State.Numbers[5]; !ok || n != 4 {
+		t.Errorf("number of #5: %v", tr.State.Numbers)
+	}
+	want := "This issue was moved to " + f.URL + "/" + testDst + "/issues/4."
+	if c := src.Issues[5].Comments.Nodes; len(c) != 1 || c[0].Body != want || !src.Issues[5].Closed {
+		t.Errorf("src #5 comments: %#v, want %q", c, want)
+	}
+}
+
+func TestBacklinkInvalid(t *testing.T) {
+	f := newFakeGitHub()
+	defer f.Close()
+	dir := testDir(t)
+	defer os.RemoveAll(dir)
+
+	o := testOptions(f, dir)
+	o.CloseSrc = true
+	if _, err := New(context.Background(), o, testShared()); err == nil {
+		t.Error("close-src without backlink")
+	}
+	o.Backlink = true
+	o.MovedNotice = "{{.Missing}}"
+	if _, err := New(context.Background(), o, testShared()); err == nil {
+		t.Error("invalid moved notice")
+	}
+}
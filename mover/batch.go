@@ -0,0 +1,211 @@
+package mover
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	defaultBatchStatePath = "mover-batch.json"
+
+	BatchDone   = "done"
+	BatchFailed = "failed"
+)
+
+// Manifest is a list of repositories to move in batch. The keys of the
+// defaults and of each repository are the ones of Options, and override
+// the flags in this order.
+type Manifest struct {
+	Defaults map[string]interface{}   `yaml:"defaults"`
+	Repos    []map[string]interface{} `yaml:"repos"`
+}
+
+type BatchResult struct {
+	Src        string    `json:"src"`
+	Dst        string    `json:"dst"`
+	Status     string    `json:"status"`
+	Error      string    `json:"error,omitempty"`
+	StartedAt  time.Time `json:"started_at"`
+	FinishedAt time.Time `json:"finished_at"`
+}
+
+// BatchState is the results of a batch, used to resume it.
+type BatchState struct {
+	Results []*BatchResult `json:"results"`
+}
+
+func LoadManifest(path string, base *Options) ([]*Options, error) {
+	buf, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	m := Manifest{}
+	if err := yaml.Unmarshal(buf, &m); err != nil {
+		return nil, err
+	}
+
+	defaults := *base
+	if err := applyOptions(&defaults, m.Defaults); err != nil {
+		return nil, err
+	}
+
+	var opts []*Options
+	for i, v := range m.Repos {
+		o := defaults
+		if err := applyOptions(&o, v); err != nil {
+			return nil, err
+		}
+		if validSrc(o.SrcType, o.Src) != nil || !validRepo(o.Dst) {
+			return nil, fmt.Errorf("invalid repository in manifest: #%d %s -> %s", i+1, o.Src, o.Dst)
+		}
+		if _, ok := v["state_file"]; !ok {
+			o.StatePath = repoStatePath(defaults.StatePath, o.Src)
+		}
+		opts = append(opts, &o)
+	}
+
+	return opts, nil
+}
+
+func applyOptions(o *Options, v map[string]interface{}) error {
+	if len(v) == 0 {
+		return nil
+	}
+	buf, err := yaml.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return yaml.UnmarshalStrict(buf, o)
+}
+
+func validRepo(repo string) bool {
+	s := strings.Split(repo, "/")
+	return len(s) == 2 && s[0] != "" && s[1] != ""
+}
+
+// repoStatePath returns the state file of a repository in a batch:
+// mover-state.json -> mover-state.foo-bar.json, or empty without the file.
+func repoStatePath(path, repo string) string {
+	if path == "" {
+		return ""
+	}
+	ext := filepath.Ext(path)
+	return strings.TrimSuffix(path, ext) + "." + strings.Replace(repo, "/", "-", -1) + ext
+}
+
+func LoadBatchState(path string) (*BatchState, error) {
+	s := &BatchState{}
+	buf, err := ioutil.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return s, nil
+		}
+		return nil, err
+	}
+	if err := json.Unmarshal(buf, s); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *BatchState) Save(path string) error {
+	buf, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, buf, 0644)
+}
+
+func (s *BatchState) Result(src, dst string) *BatchResult {
+	for _, v := range s.Results {
+		if v.Src == src && v.Dst == dst {
+			return v
+		}
+	}
+	r := &BatchResult{Src: src, Dst: dst}
+	s.Results = append(s.Results, r)
+	return r
+}
+
+// RunBatch moves the repositories of the manifest one by one, sharing the
+// user cache and the rate limiter. The repositories already done in the
+// batch state file are skipped, so that a failed batch can be resumed.
+func RunBatch(ctx context.Context, base *Options) error {
+	opts, err := LoadManifest(base.Manifest, base)
+	if err != nil {
+		return err
+	}
+	return runBatch(ctx, base, opts, NewShared())
+}
+
+func runBatch(ctx context.Context, base *Options, opts []*Options, shared *Shared) error {
+	if base.BatchStatePath == "" {
+		return fmt.Errorf("batch state file is required")
+	}
+	st, err := LoadBatchState(base.BatchStatePath)
+	if err != nil {
+		return err
+	}
+
+	for _, o := range opts {
+		r := st.Result(o.Src, o.Dst)
+		if r.Status == BatchDone {
+			logf(base.Log, "skipped repository: %s -> %s - already done\n", o.Src, o.Dst)
+			continue
+		}
+
+		logf(base.Log, "started repository: %s -> %s\n", o.Src, o.Dst)
+		r.StartedAt = time.Now()
+		r.Error = ""
+		err := runBatchRepo(ctx, o, shared)
+		r.FinishedAt = time.Now()
+		if err != nil {
+			r.Status = BatchFailed
+			r.Error = err.Error()
+			logf(base.Log, "failed repository: %s -> %s - %s\n", o.Src, o.Dst, err)
+		} else {
+			r.Status = BatchDone
+			logf(base.Log, "finished repository: %s -> %s\n", o.Src, o.Dst)
+		}
+		if err := st.Save(base.BatchStatePath); err != nil {
+			return err
+		}
+	}
+
+	return st.Report(base.Log, opts)
+}
+
+func runBatchRepo(ctx context.Context, o *Options, shared *Shared) error {
+	t, err := New(ctx, o, shared)
+	if err != nil {
+		return err
+	}
+	return t.Exec(ctx)
+}
+
+// Report prints the results of the repositories of the batch to w, and returns
+// an error when some of them are not done.
+func (s *BatchState) Report(w io.Writer, opts []*Options) error {
+	failed := 0
+	logf(w, "\n%-8s %-40s %s\n", "STATUS", "SRC -> DST", "ERROR")
+	for _, o := range opts {
+		r := s.Result(o.Src, o.Dst)
+		if r.Status != BatchDone {
+			failed++
+		}
+		logf(w, "%-8s %-40s %s\n", r.Status, r.Src+" -> "+r.Dst, r.Error)
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d repositories failed", failed, len(opts))
+	}
+	return nil
+}
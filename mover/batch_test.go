@@ -0,0 +1,130 @@
+package mover
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoadManifest(t *testing.T) {
+	dir := testDir(t)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "manifest.yml")
+	yml := `defaults:
+  align: none
+  dummy_label: moved
+repos:
+  - src: foo/a
+    dst: bar/a
+  - src: foo/b
+    dst: bar/b
+    align: fill
+    state_file: b.json
+`
+	if err := ioutil.WriteFile(path, []byte(yml), 0644); err != nil {
+		t.Fatal(err)
+	}
+	base := DefaultOptions()
+	base.StatePath = "mover-state.json"
+	base.DummyTitle = "flag"
+	opts, err := LoadManifest(path, base)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(opts) != 2 {
+		t.Fatalf("%d repositories", len(opts))
+	}
+	a, b := opts[0], opts[1]
+	if a.Src != "foo/a" || a.Dst != "bar/a" || a.Align != AlignNone || a.DummyLabel != "moved" || a.DummyTitle != "flag" {
+		t.Errorf("a: %s -> %s, align %s, dummy %s %s", a.Src, a.Dst, a.Align, a.DummyLabel, a.DummyTitle)
+	}
+	if a.StatePath != "mover-state.foo-a.json" {
+		t.Errorf("a state file: %s", a.StatePath)
+	}
+	if b.Align != AlignFill || b.StatePath != "b.json" {
+		t.Errorf("b: align %s, state file %s", b.Align, b.StatePath)
+	}
+
+	invalid := []string{
+		"repos:\n  - src: foo\n    dst: bar/a\n",
+		"repos:\n  - src: foo/a\n    dst: bar/a\n    unknown: 1\n",
+		"defaults:\n  align: [none]\n",
+	}
+	for _, v := range invalid {
+		if err := ioutil.WriteFile(path, []byte(v), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := LoadManifest(path, base); err == nil {
+			t.Errorf("no error: %q", v)
+		}
+	}
+}
+
+func TestRunBatch(t *testing.T) {
+	f := newFakeGitHub()
+	defer f.Close()
+	seedSrc(f)
+	f.Repo("foo/src2").AddIssue(testIssue("one", false))
+	dir := testDir(t)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "manifest.yml")
+	yml := "repos:\n  - src: foo/src\n    dst: foo/dst\n  - src: foo/src2\n    dst: foo/dst2\n"
+	if err := ioutil.WriteFile(path, []byte(yml), 0644); err != nil {
+		t.Fatal(err)
+	}
+	base := testOptions(f, dir)
+	base.Manifest = path
+	base.BatchStatePath = filepath.Join(dir, "batch.json")
+	opts, err := LoadManifest(path, base)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the first repository fails after its labels are created, and the
+	// second one is moved.
+	f.Fail("PUT", "/repos/"+testDst+"/issues/3/lock", http.StatusInternalServerError, 1)
+	if err := runBatch(context.Background(), base, opts, testShared()); err == nil || !strings.Contains(err.Error(), "1 of 2") {
+		t.Fatalf("batch with a failure: %v", err)
+	}
+	st, err := LoadBatchState(base.BatchStatePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r := st.Result("foo/src", "foo/dst"); r.Status != BatchFailed || r.Error == "" {
+		t.Errorf("foo/src: %s %q", r.Status, r.Error)
+	}
+	if r := st.Result("foo/src2", "foo/dst2"); r.Status != BatchDone {
+		t.Errorf("foo/src2: %s %q", r.Status, r.Error)
+	}
+	for _, name := range []string{"state.foo-src.json", "state.foo-src2.json"} {
+		if _, err := os.Stat(filepath.Join(dir, name)); err != nil {
+			t.Errorf("state file of the repository: %s", err)
+		}
+	}
+
+	// the resumed batch moves the failed one, and skips the one done.
+	imports := len(f.Repo("foo/dst2").Imports)
+	if err := runBatch(context.Background(), base, opts, testShared()); err != nil {
+		t.Fatal(err)
+	}
+	if got := f.Repo(testDst).Numbers(); len(got) != 5 {
+		t.Errorf("foo/dst numbers: %v", got)
+	}
+	if got := f.Repo(testDst).Labels; len(got) != 3 {
+		t.Errorf("foo/dst labels: %v, want bug, help wanted and dummy", got)
+	}
+	if got := len(f.Repo("foo/dst2").Imports); got != imports {
+		t.Errorf("foo/dst2 imported again: %d, want %d", got, imports)
+	}
+	st, err = LoadBatchState(base.BatchStatePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(st.Results) != 2 || st.Results[0].Status != BatchDone || st.Results[0].Error != "" {
+		t.Errorf("results: %+v %+v", st.Results[0], st.Results[1])
+	}
+}
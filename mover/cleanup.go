@@ -0,0 +1,89 @@
+package mover
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/shurcooL/githubv4"
+)
+
+const (
+	CleanupNone     = "none"
+	CleanupDelete   = "delete"
+	CleanupTransfer = "transfer"
+)
+
+func validCleanup(mode string) bool {
+	switch mode {
+	case CleanupNone, CleanupDelete, CleanupTransfer:
+		return true
+	}
+	return false
+}
+
+// CleanupDummies deletes the dummies recorded in the state, or transfers
+// them to the trash repository, once the numbers have been aligned.
+func (t *Transfer) CleanupDummies(ctx context.Context) error {
+	var repositoryID githubv4.ID
+	if t.Cleanup == CleanupTransfer {
+		s := strings.Split(t.TrashRepo, "/")
+		repo, _, err := t.DST.Client.Repositories.Get(ctx, s[0], s[1])
+		if err != nil {
+			return err
+		}
+		repositoryID = repo.GetNodeID()
+	}
+
+	for _, n := range t.State.Dummies {
+		if t.State.IsRemoved(n) {
+			continue
+		}
+		issue, resp, err := t.DST.Client.Issues.Get(ctx, t.DST.Owner, t.DST.Name, n)
+		if err != nil {
+			if resp != nil && resp.StatusCode == http.StatusNotFound {
+				return fmt.Errorf("dummy #%d is not found: its import may not have finished yet", n)
+			}
+			return err
+		}
+		if issue.GetTitle() != t.DummyTitle {
+			logf(t.Log, "skipped dummy cleanup: #%d - %s is not a dummy\n", n, issue.GetTitle())
+			continue
+		}
+
+		switch t.Cleanup {
+		case CleanupDelete:
+			var m struct {
+				DeleteIssue struct {
+					ClientMutationID string
+				} `graphql:"deleteIssue(input: $input)"`
+			}
+			input := githubv4.DeleteIssueInput{IssueID: issue.GetNodeID()}
+			if err := t.DST.adminGraphQL().Mutate(ctx, &m, input, nil); err != nil {
+				return err
+			}
+			logf(t.Log, "deleted dummy: #%d\n", n)
+		case CleanupTransfer:
+			var m struct {
+				TransferIssue struct {
+					Issue struct {
+						Number int
+					}
+				} `graphql:"transferIssue(input: $input)"`
+			}
+			input := githubv4.TransferIssueInput{IssueID: issue.GetNodeID(), RepositoryID: repositoryID}
+			if err := t.DST.GraphQL.Mutate(ctx, &m, input, nil); err != nil {
+				return err
+			}
+			logf(t.Log, "transferred dummy: #%d to %s#%d\n", n, t.TrashRepo, m.TransferIssue.Issue.Number)
+		}
+
+		t.State.Removed = append(t.State.Removed, n)
+		if err := t.State.Save(t.StatePath); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,422 @@
+package mover
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/shurcooL/githubv4"
+)
+
+const (
+	DiscussionsNone    = "none"
+	DiscussionsMigrate = "migrate"
+	DiscussionsIssues  = "issues"
+
+	defaultDiscussionCategory = "General"
+)
+
+func validDiscussions(mode string) bool {
+	switch mode {
+	case DiscussionsNone, DiscussionsMigrate, DiscussionsIssues:
+		return true
+	}
+	return false
+}
+
+type DiscussionCategory struct {
+	ID           string
+	Name         string
+	Slug         string
+	Description  string
+	IsAnswerable bool
+}
+
+type DiscussionAuthor struct {
+	Login     string
+	AvatarURL string `graphql:"avatarUrl(size: 100)"`
+}
+
+type DiscussionReply struct {
+	Author    DiscussionAuthor
+	Body      string
+	CreatedAt time.Time
+}
+
+type DiscussionReplies struct {
+	Nodes    []DiscussionReply
+	PageInfo struct {
+		EndCursor   githubv4.String
+		HasNextPage bool
+	}
+}
+
+type DiscussionComment struct {
+	ID        string
+	Author    DiscussionAuthor
+	Body      string
+	CreatedAt time.Time
+	IsAnswer  bool
+	Replies   DiscussionReplies `graphql:"replies(first: 100)"`
+}
+
+type DiscussionComments struct {
+	Nodes    []DiscussionComment
+	PageInfo struct {
+		EndCursor   githubv4.String
+		HasNextPage bool
+	}
+}
+
+type Discussion struct {
+	ID        string
+	Number    int
+	Title     string
+	Body      string
+	URL       string
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	Author    DiscussionAuthor
+	Category  struct {
+		Name string
+		Slug string
+	}
+	// Comments and their replies are of the first pages in the queries,
+	// and the rest are fetched by fetchDiscussionComments.
+	Comments DiscussionComments `graphql:"comments(first: 100)"`
+}
+
+type DiscussionCategoriesQuery struct {
+	Repository struct {
+		ID                   string
+		DiscussionCategories struct {
+			Nodes []DiscussionCategory
+		} `graphql:"discussionCategories(first: 100)"`
+	} `graphql:"repository(owner: $owner, name: $repo)"`
+}
+
+type DiscussionsQuery struct {
+	Repository struct {
+		Discussions struct {
+			Nodes    []Discussion
+			PageInfo struct {
+				EndCursor   githubv4.String
+				HasNextPage bool
+			}
+		} `graphql:"discussions(first: 25, after: $cursor, orderBy: {field: CREATED_AT, direction: ASC})"`
+	} `graphql:"repository(owner: $owner, name: $repo)"`
+}
+
+type DiscussionNumberQuery struct {
+	Repository struct {
+		Discussion Discussion `graphql:"discussion(number: $number)"`
+	} `graphql:"repository(owner: $owner, name: $repo)"`
+}
+
+type DiscussionCommentsQuery struct {
+	Repository struct {
+		Discussion struct {
+			Comments DiscussionComments `graphql:"comments(first: 100, after: $cursor)"`
+		} `graphql:"discussion(number: $number)"`
+	} `graphql:"repository(owner: $owner, name: $repo)"`
+}
+
+type DiscussionRepliesQuery struct {
+	Node struct {
+		DiscussionComment struct {
+			Replies DiscussionReplies `graphql:"replies(first: 100, after: $cursor)"`
+		} `graphql:"... on DiscussionComment"`
+	} `graphql:"node(id: $id)"`
+}
+
+// CreateDiscussionInput is an input type of CreateDiscussion.
+type CreateDiscussionInput struct {
+	RepositoryID githubv4.ID     `json:"repositoryId"`
+	CategoryID   githubv4.ID     `json:"categoryId"`
+	Title        githubv4.String `json:"title"`
+	Body         githubv4.String `json:"body"`
+}
+
+// AddDiscussionCommentInput is an input type of AddDiscussionComment.
+type AddDiscussionCommentInput struct {
+	DiscussionID githubv4.ID     `json:"discussionId"`
+	Body         githubv4.String `json:"body"`
+	ReplyToID    *githubv4.ID    `json:"replyToId,omitempty"`
+}
+
+// MarkDiscussionCommentAsAnswerInput is an input type of MarkDiscussionCommentAsAnswer.
+type MarkDiscussionCommentAsAnswerInput struct {
+	ID githubv4.ID `json:"id"`
+}
+
+func (t *Transfer) FetchDiscussions(ctx context.Context) error {
+	var discussions []Discussion
+	var dq DiscussionsQuery
+	dv := map[string]interface{}{
+		"owner":  githubv4.String(t.SRC.Owner),
+		"repo":   githubv4.String(t.SRC.Name),
+		"cursor": (*githubv4.String)(nil),
+	}
+	for {
+		err := t.SRC.Client.Query(ctx, &dq, dv)
+		if err != nil {
+			return err
+		}
+		discussions = append(discussions, dq.Repository.Discussions.Nodes...)
+		if !dq.Repository.Discussions.PageInfo.HasNextPage {
+			break
+		}
+		dv["cursor"] = githubv4.NewString(dq.Repository.Discussions.PageInfo.EndCursor)
+	}
+	for i := range discussions {
+		if err := fetchDiscussionComments(ctx, t.SRC.Client, t.SRC.Owner, t.SRC.Name, &discussions[i]); err != nil {
+			return err
+		}
+	}
+
+	t.Discussions = discussions
+
+	return nil
+}
+
+// fetchDiscussionComments fetches the comments and the replies of the
+// discussion after their first pages.
+func fetchDiscussionComments(ctx context.Context, client *githubv4.Client, owner, name string, d *Discussion) error {
+	cv := map[string]interface{}{
+		"owner":  githubv4.String(owner),
+		"repo":   githubv4.String(name),
+		"number": githubv4.Int(d.Number),
+		"cursor": githubv4.NewString(d.Comments.PageInfo.EndCursor),
+	}
+	for d.Comments.PageInfo.HasNextPage {
+		var cq DiscussionCommentsQuery
+		if err := client.Query(ctx, &cq, cv); err != nil {
+			return err
+		}
+		comments := cq.Repository.Discussion.Comments
+		d.Comments.Nodes = append(d.Comments.Nodes, comments.Nodes...)
+		d.Comments.PageInfo = comments.PageInfo
+		cv["cursor"] = githubv4.NewString(comments.PageInfo.EndCursor)
+	}
+
+	for i := range d.Comments.Nodes {
+		c := &d.Comments.Nodes[i]
+		rv := map[string]interface{}{
+			"id":     githubv4.ID(c.ID),
+			"cursor": githubv4.NewString(c.Replies.PageInfo.EndCursor),
+		}
+		for c.Replies.PageInfo.HasNextPage {
+			var rq DiscussionRepliesQuery
+			if err := client.Query(ctx, &rq, rv); err != nil {
+				return err
+			}
+			replies := rq.Node.DiscussionComment.Replies
+			c.Replies.Nodes = append(c.Replies.Nodes, replies.Nodes...)
+			c.Replies.PageInfo = replies.PageInfo
+			rv["cursor"] = githubv4.NewString(replies.PageInfo.EndCursor)
+		}
+	}
+	return nil
+}
+
+// discussionIssues converts the discussions into closed issues, so that
+// they are moved with the issues for instances that lack discussions.
+func (t *Transfer) discussionIssues() []Issue {
+	var issues []Issue
+	for _, d := range t.Discussions {
+		var v Issue
+		v.Number = d.Number
+		v.Title = d.Title
+		v.Body = d.Body
+		v.CreatedAt = d.CreatedAt
+		v.UpdatedAt = d.UpdatedAt
+		v.ClosedAt = d.UpdatedAt
+		v.State = "CLOSED"
+		v.Closed = true
+		v.Author.Login = d.Author.Login
+		v.Author.AvatarURL = d.Author.AvatarURL
+		v.Comments.Nodes = make([]IssueComment, 0, len(d.Comments.Nodes))
+		for _, c := range d.Comments.Nodes {
+			v.Comments.Nodes = append(v.Comments.Nodes, discussionIssueComment(c.Author, c.Body, c.CreatedAt))
+			for _, r := range c.Replies.Nodes {
+				v.Comments.Nodes = append(v.Comments.Nodes, discussionIssueComment(r.Author, "> replied to the comment above\n\n"+r.Body, r.CreatedAt))
+			}
+		}
+		v.Comments.TotalCount = githubv4.Int(len(v.Comments.Nodes))
+		if t.Filter.Match(&v) {
+			issues = append(issues, v)
+		}
+	}
+	return issues
+}
+
+func discussionIssueComment(a DiscussionAuthor, body string, createdAt time.Time) IssueComment {
+	var c IssueComment
+	c.Author.Login = a.Login
+	c.Author.AvatarURL = a.AvatarURL
+	c.Body = body
+	c.CreatedAt = createdAt
+	return c
+}
+
+func (t *Transfer) DoDiscussions(ctx context.Context) error {
+	v := map[string]interface{}{
+		"owner": githubv4.String(t.DST.Owner),
+		"repo":  githubv4.String(t.DST.Name),
+	}
+	var cq DiscussionCategoriesQuery
+	if err := t.DST.GraphQL.Query(ctx, &cq, v); err != nil {
+		return err
+	}
+	categories := cq.Repository.DiscussionCategories.Nodes
+
+	for i := range t.Discussions {
+		d := &t.Discussions[i]
+		n, created := t.State.Discussions[d.Number]
+		if created && t.State.IsCommentedDiscussion(d.Number) {
+			logf(t.Log, "skipped discussion: #%d - already moved to #%d\n", d.Number, n)
+			continue
+		}
+
+		category := findDiscussionCategory(categories, d.Category.Slug, d.Category.Name)
+		if category == nil {
+			// categories cannot be created by the api.
+			logf(t.Log, "discussion category not found: %s, using %s\n", d.Category.Name, t.DiscussionCategory)
+			category = findDiscussionCategory(categories, "", t.DiscussionCategory)
+		}
+		if category == nil {
+			return fmt.Errorf("discussion category not found: %s", t.DiscussionCategory)
+		}
+
+		var dst *Discussion
+		if created {
+			// the run stopped in the comments, which are added from the
+			// first one missing.
+			var err error
+			if dst, err = t.dstDiscussion(ctx, n); err != nil {
+				return err
+			}
+			logf(t.Log, "resumed discussion: #%d - %s\n", n, d.Title)
+		} else {
+			var m struct {
+				CreateDiscussion struct {
+					Discussion struct {
+						ID     string
+						Number int
+					}
+				} `graphql:"createDiscussion(input: $input)"`
+			}
+			input := CreateDiscussionInput{
+				RepositoryID: cq.Repository.ID,
+				CategoryID:   category.ID,
+				Title:        githubv4.String(t.replaceText(ScopeTitle, d.Title)),
+				Body:         githubv4.String(t.Templates.Issue(t.attribution(d.Author.Login, d.Author.AvatarURL, d.URL, d.CreatedAt, d.Number, false), t.replaceBody(d.Body))),
+			}
+			if err := t.DST.GraphQL.Mutate(ctx, &m, input, nil); err != nil {
+				return err
+			}
+			dst = &Discussion{ID: m.CreateDiscussion.Discussion.ID, Number: m.CreateDiscussion.Discussion.Number}
+			logf(t.Log, "created discussion: #%d - %s\n", dst.Number, d.Title)
+
+			t.State.Discussions[d.Number] = dst.Number
+			if err := t.State.Save(t.StatePath); err != nil {
+				return err
+			}
+		}
+
+		if err := t.addDiscussionComments(ctx, d, dst, category.IsAnswerable); err != nil {
+			return err
+		}
+		t.State.CommentedDiscussions = append(t.State.CommentedDiscussions, d.Number)
+		if err := t.State.Save(t.StatePath); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// dstDiscussion returns the DST discussion with all of its comments and replies.
+func (t *Transfer) dstDiscussion(ctx context.Context, n int) (*Discussion, error) {
+	var q DiscussionNumberQuery
+	v := map[string]interface{}{
+		"owner":  githubv4.String(t.DST.Owner),
+		"repo":   githubv4.String(t.DST.Name),
+		"number": githubv4.Int(n),
+	}
+	if err := t.DST.GraphQL.Query(ctx, &q, v); err != nil {
+		return nil, err
+	}
+	d := q.Repository.Discussion
+	if err := fetchDiscussionComments(ctx, t.DST.GraphQL, t.DST.Owner, t.DST.Name, &d); err != nil {
+		return nil, err
+	}
+	return &d, nil
+}
+
+// addDiscussionComments adds the comments and replies of the SRC discussion
+// d to dst, skipping the ones dst already has, in order.
+func (t *Transfer) addDiscussionComments(ctx context.Context, d, dst *Discussion, answerable bool) error {
+	for i, c := range d.Comments.Nodes {
+		var commentID githubv4.ID
+		var replies []DiscussionReply
+		answered := false
+		if i < len(dst.Comments.Nodes) {
+			commentID = githubv4.ID(dst.Comments.Nodes[i].ID)
+			replies = dst.Comments.Nodes[i].Replies.Nodes
+			answered = dst.Comments.Nodes[i].IsAnswer
+		} else {
+			var err error
+			if commentID, err = t.addDiscussionComment(ctx, dst.ID, d.Number, nil, c.Author, c.Body, c.CreatedAt); err != nil {
+				return err
+			}
+		}
+		for j, r := range c.Replies.Nodes {
+			if j < len(replies) {
+				continue
+			}
+			if _, err := t.addDiscussionComment(ctx, dst.ID, d.Number, &commentID, r.Author, r.Body, r.CreatedAt); err != nil {
+				return err
+			}
+		}
+		if c.IsAnswer && answerable && !answered {
+			var am struct {
+				MarkDiscussionCommentAsAnswer struct {
+					ClientMutationID string
+				} `graphql:"markDiscussionCommentAsAnswer(input: $input)"`
+			}
+			if err := t.DST.GraphQL.Mutate(ctx, &am, MarkDiscussionCommentAsAnswerInput{ID: commentID}, nil); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (t *Transfer) addDiscussionComment(ctx context.Context, discussionID string, number int, replyToID *githubv4.ID, a DiscussionAuthor, body string, createdAt time.Time) (githubv4.ID, error) {
+	var m struct {
+		AddDiscussionComment struct {
+			Comment struct {
+				ID string
+			}
+		} `graphql:"addDiscussionComment(input: $input)"`
+	}
+	input := AddDiscussionCommentInput{
+		DiscussionID: discussionID,
+		Body:         githubv4.String(t.Templates.Comment(t.attribution(a.Login, a.AvatarURL, "", createdAt, number, false), t.replaceText(ScopeComments, body))),
+		ReplyToID:    replyToID,
+	}
+	if err := t.DST.GraphQL.Mutate(ctx, &m, input, nil); err != nil {
+		return nil, err
+	}
+	return m.AddDiscussionComment.Comment.ID, nil
+}
+
+func findDiscussionCategory(categories []DiscussionCategory, slug, name string) *DiscussionCategory {
+	for i, v := range categories {
+		if (slug != "" && v.Slug == slug) || v.Name == name {
+			return &categories[i]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,150 @@
+package mover
+
+import (
+	"context"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+// seedDiscussions makes testSrc have a Q&A discussion #1 with three
+// comments, the first one with three replies and the second one the
+// answer, and an idea #2 of the category missing in testDst.
+func seedDiscussions(f *fakeGitHub) {
+	src := f.Repo(testSrc)
+	src.DiscussionCategories = []DiscussionCategory{
+		{ID: "SC_1", Name: "Q&A", Slug: "q-a", IsAnswerable: true},
+		{ID: "SC_2", Name: "Ideas", Slug: "ideas"},
+	}
+	f.Repo(testDst).DiscussionCategories = []DiscussionCategory{
+		{ID: "DC_1", Name: "General", Slug: "general"},
+		{ID: "DC_2", Name: "Q&A", Slug: "q-a", IsAnswerable: true},
+	}
+	comment := func(body string) DiscussionComment {
+		c := DiscussionComment{Body: body, CreatedAt: time.Date(2021, 1, 2, 0, 0, 0, 0, time.UTC)}
+		c.Author.Login = "bob"
+		return c
+	}
+
+	q := &Discussion{Title: "question", Body: "how?", CreatedAt: time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)}
+	q.Author.Login = "alice"
+	q.Category.Name = "Q&A"
+	q.Category.Slug = "q-a"
+	first := comment("first comment")
+	for _, body := range []string{"first reply", "second reply", "third reply"} {
+		first.Replies.Nodes = append(first.Replies.Nodes, DiscussionReply{Body: body})
+	}
+	answer := comment("second comment")
+	answer.IsAnswer = true
+	q.Comments.Nodes = []DiscussionComment{first, answer, comment("third comment")}
+	src.AddDiscussion(testSrc, q)
+
+	idea := &Discussion{Title: "idea", Body: "why not?"}
+	idea.Category.Name = "Ideas"
+	idea.Category.Slug = "ideas"
+	src.AddDiscussion(testSrc, idea)
+}
+
+func TestExecDiscussions(t *testing.T) {
+	f := newFakeGitHub()
+	defer f.Close()
+	// the comments and the replies are of several pages.
+	f.PageSize = 2
+	seedDiscussions(f)
+	dir := testDir(t)
+	defer os.RemoveAll(dir)
+
+	o := testOptions(f, dir)
+	o.SkipLabels = true
+	o.SkipMilestones = true
+	o.Discussions = DiscussionsMigrate
+	// the run stops at the second reply of the question.
+	f.FailGraphQL("second reply", 1)
+	tr, err := New(context.Background(), o, testShared())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := tr.Exec(context.Background()); err == nil {
+		t.Fatal("no error on the failed reply")
+	}
+	if n, ok := tr.State.Discussions[1]; !ok || n != 1 {
+		t.Errorf("the created discussion is not recorded: %v", tr.State.Discussions)
+	}
+
+	tr, err = New(context.Background(), o, testShared())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := tr.Exec(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	dst := f.Repo(testDst).Discussions
+	if len(dst) != 2 {
+		t.Fatalf("%d discussions", len(dst))
+	}
+	q := dst[0]
+	if q.Title != "question" || q.Category.Slug != "q-a" || !strings.Contains(q.Body, "how?") {
+		t.Errorf("question: %q %s %q", q.Title, q.Category.Slug, q.Body)
+	}
+	comments := q.Comments.Nodes
+	if len(comments) != 3 {
+		t.Fatalf("%d comments of the question", len(comments))
+	}
+	for i, want := range []string{"first comment", "second comment", "third comment"} {
+		if !strings.Contains(comments[i].Body, want) {
+			t.Errorf("comment %d: %q, want %q", i, comments[i].Body, want)
+		}
+	}
+	replies := comments[0].Replies.Nodes
+	if len(replies) != 3 {
+		t.Fatalf("%d replies of the first comment", len(replies))
+	}
+	for i, want := range []string{"first reply", "second reply", "third reply"} {
+		if !strings.Contains(replies[i].Body, want) {
+			t.Errorf("reply %d: %q, want %q", i, replies[i].Body, want)
+		}
+	}
+	if comments[0].IsAnswer || !comments[1].IsAnswer {
+		t.Errorf("answer: %t %t", comments[0].IsAnswer, comments[1].IsAnswer)
+	}
+	if dst[1].Title != "idea" || dst[1].Category.Slug != "general" {
+		t.Errorf("idea: %q in %s", dst[1].Title, dst[1].Category.Slug)
+	}
+	if !tr.State.IsCommentedDiscussion(1) || !tr.State.IsCommentedDiscussion(2) {
+		t.Errorf("commented discussions: %v", tr.State.CommentedDiscussions)
+	}
+}
+
+func TestDiscussionIssues(t *testing.T) {
+	f := newFakeGitHub()
+	defer f.Close()
+	f.PageSize = 2
+	seedDiscussions(f)
+	dir := testDir(t)
+	defer os.RemoveAll(dir)
+
+	o := testOptions(f, dir)
+	o.Discussions = DiscussionsIssues
+	tr, err := New(context.Background(), o, testShared())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := tr.FetchDiscussions(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	issues := tr.discussionIssues()
+	if len(issues) != 2 || !issues[0].Closed {
+		t.Fatalf("issues: %d", len(issues))
+	}
+	// the comments and their replies of all the pages are in order.
+	var bodies []string
+	for _, c := range issues[0].Comments.Nodes {
+		bodies = append(bodies, c.Body[strings.LastIndex(c.Body, "\n")+1:])
+	}
+	want := "first comment,first reply,second reply,third reply,second comment,third comment"
+	if got := strings.Join(bodies, ","); got != want {
+		t.Errorf("comments: %s, want %s", got, want)
+	}
+}
@@ -0,0 +1,502 @@
+package mover
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+const (
+	testSrc = "foo/src"
+	testDst = "foo/dst"
+)
+
+func init() {
+	importWaitInterval = time.Millisecond
+}
+
+// testOptions returns the options moving testSrc to testDst of the fake,
+// with the files in dir.
+func testOptions(f *fakeGitHub, dir string) *Options {
+	o := DefaultOptions()
+	o.Src = testSrc
+	o.Dst = testDst
+	o.SrcEndpoint = f.URL
+	o.DstEndpoint = f.URL
+	o.SrcToken = "src-token"
+	o.DstToken = "dst-token"
+	o.DstAdminToken = "dst-admin-token"
+	o.StatePath = filepath.Join(dir, "state.json")
+	o.ReplacePath = filepath.Join(dir, "replace.yml")
+	return o
+}
+
+func testShared() *Shared {
+	s := NewShared()
+	s.Limiter = NewRateLimiter(http.DefaultTransport, 0)
+	return s
+}
+
+func testDir(t *testing.T) string {
+	dir, err := ioutil.TempDir("", "mover-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	replace := "user:\n  - wrong: alice\n    right: alice-dst\nbody:\n  - wrong: src.example.com\n    right: dst.example.com\n"
+	if err := ioutil.WriteFile(filepath.Join(dir, "replace.yml"), []byte(replace), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
+
+func testIssue(title string, closed bool) *fakeIssue {
+	v := &fakeIssue{}
+	v.Title = title
+	v.Body = "see https://src.example.com/foo"
+	v.Closed = closed
+	v.CreatedAt = time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC)
+	v.Author.Login = "alice"
+	v.Author.AvatarURL = "https://src.example.com/avatars/alice"
+	return v
+}
+
+// seedSrc makes testSrc have labels, a milestone, issues #1, #2 and #5,
+// a deleted #3 and a pull request #4.
+func seedSrc(f *fakeGitHub) {
+	src := f.Repo(testSrc)
+	src.Labels = []Label{{Name: "bug", Color: "ff0000"}, {Name: "help wanted", Color: "00ff00"}}
+	src.Milestones = []Milestone{{Number: 1, Title: "v1", State: "OPEN"}}
+
+	i1 := testIssue("first", true)
+	i1.Labels.Nodes = append(i1.Labels.Nodes, struct{ Name string }{"bug"})
+	i1.Assignees.Nodes = append(i1.Assignees.Nodes, struct{ Login string }{"alice"})
+	i1.Assignees.TotalCount = 1
+	i1.Milestone.Number = 1
+	c := IssueComment{Body: "a comment", CreatedAt: time.Date(2019, 1, 2, 0, 0, 0, 0, time.UTC)}
+	c.Author.Login = "bob"
+	i1.Comments.Nodes = append(i1.Comments.Nodes, c)
+	src.AddIssue(i1)
+	src.AddIssue(testIssue("second", false))
+	deleted := testIssue("deleted", false)
+	deleted.Deleted = true
+	src.AddIssue(deleted)
+	pull := testIssue("pull", false)
+	pull.Pull = true
+	src.AddIssue(pull)
+	src.AddIssue(testIssue("fifth", false))
+}
+
+func TestExecImportFill(t *testing.T) {
+	f := newFakeGitHub()
+	defer f.Close()
+	f.ImportPolls = 2
+	f.AddUser("alice-dst")
+	seedSrc(f)
+	dir := testDir(t)
+	defer os.RemoveAll(dir)
+
+	tr, err := New(context.Background(), testOptions(f, dir), testShared())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := tr.Exec(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	dst := f.Repo(testDst)
+	if len(dst.Labels) != 3 {
+		t.Errorf("labels: %#v, want bug, help wanted and dummy", dst.Labels)
+	}
+	if len(dst.Milestones) != 1 || dst.Milestones[0].Title != "v1" {
+		t.Errorf("milestones: %#v", dst.Milestones)
+	}
+	titles := []string{"first", "second", defaultDummyTitle, "pull", "fifth"}
+	for i, want := range titles {
+		v, ok := dst.Issues[i+1]
+		if !ok {
+			t.Fatalf("#%d is missing", i+1)
+		}
+		if v.Title != want {
+			t.Errorf("#%d title: %q, want %q", i+1, v.Title, want)
+		}
+	}
+
+	first := dst.Issues[1]
+	if !first.Closed {
+		t.Error("#1 is not closed")
+	}
+	if len(first.Comments.Nodes) != 1 || !strings.Contains(first.Comments.Nodes[0].Body, "a comment") {
+		t.Errorf("#1 comments: %#v", first.Comments.Nodes)
+	}
+	if !strings.Contains(first.Body, "https://dst.example.com/foo") {
+		t.Errorf("#1 body is not replaced: %q", first.Body)
+	}
+	if len(first.Assignees.Nodes) != 1 || first.Assignees.Nodes[0].Login != "alice-dst" {
+		t.Errorf("#1 assignees: %#v", first.Assignees.Nodes)
+	}
+
+	dummy := dst.Issues[3]
+	if !dummy.Closed || !dummy.Locked || dummy.LockReason != dummyLockReason {
+		t.Errorf("dummy: closed %t, locked %t %q", dummy.Closed, dummy.Locked, dummy.LockReason)
+	}
+	if !strings.Contains(dummy.Body, "#3 was deleted.") {
+		t.Errorf("dummy body: %q", dummy.Body)
+	}
+
+	st, err := LoadState(filepath.Join(dir, "state.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(st.Numbers) != 4 || st.Numbers[5] != 5 {
+		t.Errorf("state numbers: %v", st.Numbers)
+	}
+	if len(st.Dummies) != 1 || st.Dummies[0] != 3 {
+		t.Errorf("state dummies: %v", st.Dummies)
+	}
+}
+
+func TestExecResume(t *testing.T) {
+	f := newFakeGitHub()
+	defer f.Close()
+	seedSrc(f)
+	dir := testDir(t)
+	defer os.RemoveAll(dir)
+
+	o := testOptions(f, dir)
+
+	// the run stops at the lock of the dummy #3, after #1, #2 and #3 are created.
+	f.Fail("PUT", "/repos/"+testDst+"/issues/3/lock", http.StatusInternalServerError, 1)
+	tr, err := New(context.Background(), o, testShared())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := tr.Exec(context.Background()); err == nil {
+		t.Fatal("no error on the failed lock")
+	}
+	if got := f.Repo(testDst).Numbers(); len(got) != 3 {
+		t.Fatalf("dst numbers after the failure: %v", got)
+	}
+
+	tr, err = New(context.Background(), o, testShared())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := tr.Exec(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	dst := f.Repo(testDst)
+	if got := dst.Numbers(); len(got) != 5 {
+		t.Errorf("dst numbers after the resume: %v", got)
+	}
+	if len(dst.Imports) != 5 {
+		t.Errorf("%d imports, want 5 without the ones already done", len(dst.Imports))
+	}
+	if dst.Issues[4].Title != "pull" || dst.Issues[5].Title != "fifth" {
+		t.Errorf("#4 %q, #5 %q", dst.Issues[4].Title, dst.Issues[5].Title)
+	}
+	// the labels and the milestones of the first run are kept.
+	if len(dst.Labels) != 3 || len(dst.Milestones) != 1 {
+		t.Errorf("labels %v, milestones %v", dst.Labels, dst.Milestones)
+	}
+}
+
+// TestExecResumeAfterDummy resumes a run in which the dummy #3 was created
+// after the imported #4 of the older creation time.
+func TestExecResumeAfterDummy(t *testing.T) {
+	f := newFakeGitHub()
+	defer f.Close()
+	seedSrc(f)
+	src := f.Repo(testSrc)
+	fifth := src.Issues[5]
+	delete(src.Issues, 5)
+	dir := testDir(t)
+	defer os.RemoveAll(dir)
+
+	o := testOptions(f, dir)
+	tr, err := New(context.Background(), o, testShared())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := tr.Exec(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	src.Issues[5] = fifth
+	tr, err = New(context.Background(), o, testShared())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := tr.Exec(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	dst := f.Repo(testDst)
+	if got := dst.Numbers(); len(got) != 5 {
+		t.Errorf("dst numbers after the resume: %v", got)
+	}
+	if dst.Issues[4].Title != "pull" || dst.Issues[5].Title != "fifth" {
+		t.Errorf("#4 %q, #5 %q", dst.Issues[4].Title, dst.Issues[5].Title)
+	}
+}
+
+// TestExecResumeCreated resumes a run in which #1 was created but its close
+// failed, without taking #1 for an issue not created by this tool.
+func TestExecResumeCreated(t *testing.T) {
+	f := newFakeGitHub()
+	defer f.Close()
+	seedSrc(f)
+	dir := testDir(t)
+	defer os.RemoveAll(dir)
+
+	o := testOptions(f, dir)
+	o.IsImport = false
+	f.Fail("PATCH", "/repos/"+testDst+"/issues/1", http.StatusInternalServerError, 1)
+	tr, err := New(context.Background(), o, testShared())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := tr.Exec(context.Background()); err == nil {
+		t.Fatal("no error on the failed close")
+	}
+	if n, ok := tr.State.Numbers[1]; !ok || n != 1 {
+		t.Errorf("#1 not recorded: %v", tr.State.Numbers)
+	}
+
+	tr, err = New(context.Background(), o, testShared())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := tr.Exec(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if got := f.Repo(testDst).Numbers(); len(got) != 5 {
+		t.Errorf("dst numbers after the resume: %v", got)
+	}
+}
+
+func TestStateOfOtherRepos(t *testing.T) {
+	f := newFakeGitHub()
+	defer f.Close()
+	seedSrc(f)
+	dir := testDir(t)
+	defer os.RemoveAll(dir)
+
+	o := testOptions(f, dir)
+	o.SkipLabels = true
+	o.SkipMilestones = true
+	tr, err := New(context.Background(), o, testShared())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := tr.Exec(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	o.Dst = "foo/other"
+	if _, err := New(context.Background(), o, testShared()); err == nil || !strings.Contains(err.Error(), "foo/src to foo/dst") {
+		t.Errorf("state of another dst: %v", err)
+	}
+}
+
+func TestExecImportFailure(t *testing.T) {
+	f := newFakeGitHub()
+	defer f.Close()
+	seedSrc(f)
+	dir := testDir(t)
+	defer os.RemoveAll(dir)
+
+	o := testOptions(f, dir)
+	o.SkipLabels = true
+	o.SkipMilestones = true
+
+	f.Fail("POST", "/repos/"+testDst+"/import/issues", http.StatusInternalServerError, 1)
+	tr, err := New(context.Background(), o, testShared())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := tr.Exec(context.Background()); err == nil {
+		t.Fatal("no error on the failed import request")
+	}
+
+	// strict alignment waits for the imports.
+	f.FailImports = true
+	o.Align = AlignStrict
+	o.Numbers = "1-2"
+	tr, err = New(context.Background(), o, testShared())
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = tr.Exec(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "issue import failed") {
+		t.Fatalf("error on the failed import: %v", err)
+	}
+}
+
+func TestExecCreateSequential(t *testing.T) {
+	f := newFakeGitHub()
+	defer f.Close()
+	f.PageSize = 2
+	seedSrc(f)
+	dir := testDir(t)
+	defer os.RemoveAll(dir)
+
+	o := testOptions(f, dir)
+	o.IsImport = false
+	o.Align = AlignNone
+	o.State = filterStateOpen
+
+	tr, err := New(context.Background(), o, testShared())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := tr.Exec(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	dst := f.Repo(testDst)
+	if got := dst.Numbers(); len(got) != 3 {
+		t.Fatalf("dst numbers: %v, want the open #2, #4 and #5", got)
+	}
+	st := tr.State
+	if st.Numbers[2] != 1 || st.Numbers[4] != 2 || st.Numbers[5] != 3 {
+		t.Errorf("state numbers: %v", st.Numbers)
+	}
+	if len(st.Dummies) != 0 {
+		t.Errorf("dummies: %v", st.Dummies)
+	}
+	for _, n := range dst.Numbers() {
+		if v := dst.Issues[n]; v.Closed {
+			t.Errorf("#%d is closed", n)
+		}
+	}
+}
+
+func TestExecCleanupDelete(t *testing.T) {
+	f := newFakeGitHub()
+	defer f.Close()
+	seedSrc(f)
+	dir := testDir(t)
+	defer os.RemoveAll(dir)
+
+	o := testOptions(f, dir)
+	o.Cleanup = CleanupDelete
+
+	tr, err := New(context.Background(), o, testShared())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := tr.Exec(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := f.Repo(testDst).Issues[3]; ok {
+		t.Error("dummy #3 is not deleted")
+	}
+	if len(tr.State.Removed) != 1 || tr.State.Removed[0] != 3 {
+		t.Errorf("removed: %v", tr.State.Removed)
+	}
+}
+
+func TestExecCleanupAfterImports(t *testing.T) {
+	f := newFakeGitHub()
+	defer f.Close()
+	f.ImportPolls = 2
+	seedSrc(f)
+	dir := testDir(t)
+	defer os.RemoveAll(dir)
+
+	// the dummy is not waited for without the lock, and is found only
+	// after its import.
+	o := testOptions(f, dir)
+	o.DummyLock = false
+	o.Cleanup = CleanupDelete
+	tr, err := New(context.Background(), o, testShared())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := tr.Exec(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := f.Repo(testDst).Issues[3]; ok {
+		t.Error("dummy #3 is not deleted")
+	}
+	if len(tr.State.Removed) != 1 || tr.State.Removed[0] != 3 {
+		t.Errorf("removed: %v", tr.State.Removed)
+	}
+}
+
+func TestRateLimiterRetryAfter(t *testing.T) {
+	f := newFakeGitHub()
+	defer f.Close()
+	seedSrc(f)
+	dir := testDir(t)
+	defer os.RemoveAll(dir)
+
+	f.RetryAfter("POST", "/repos/"+testDst+"/labels", 1, 1)
+	tr, err := New(context.Background(), testOptions(f, dir), testShared())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := tr.FetchLabels(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	start := time.Now()
+	if err := tr.DoLabels(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if d := time.Since(start); d < time.Second {
+		t.Errorf("the labels were created in %s, before Retry-After", d)
+	}
+	// the refused label is created by the retry.
+	if got := f.Repo(testDst).Labels; len(got) < 2 || got[0].Name != "bug" || got[1].Name != "help wanted" {
+		t.Errorf("labels: %v", got)
+	}
+}
+
+func TestNewInvalidRepo(t *testing.T) {
+	f := newFakeGitHub()
+	defer f.Close()
+	dir := testDir(t)
+	defer os.RemoveAll(dir)
+
+	o := testOptions(f, dir)
+	o.Src = "foo"
+	if _, err := New(context.Background(), o, testShared()); err == nil {
+		t.Error("no error on the source without owner")
+	}
+}
+
+func TestNewWithLog(t *testing.T) {
+	f := newFakeGitHub()
+	defer f.Close()
+	seedSrc(f)
+	dir := testDir(t)
+	defer os.RemoveAll(dir)
+
+	// the state file is not defaulted to the working directory.
+	o := testOptions(f, dir)
+	o.StatePath = ""
+	if _, err := New(context.Background(), o, testShared()); err == nil {
+		t.Error("no error without the state file")
+	}
+
+	var buf bytes.Buffer
+	o = testOptions(f, dir)
+	o.Log = &buf
+	tr, err := New(context.Background(), o, testShared())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := tr.Exec(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	for _, v := range []string{"created label: bug", "requested issue import: importID 1 - first", "waiting for issue imports"} {
+		if !strings.Contains(buf.String(), v) {
+			t.Errorf("log: %q, want %q", buf.String(), v)
+		}
+	}
+}
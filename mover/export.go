@@ -0,0 +1,96 @@
+package mover
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+)
+
+const (
+	defaultExportPath = "mover-export.json"
+)
+
+// Export is what is fetched from SRC, so that DST can be created from it
+// later, or by another host that cannot reach SRC.
+type Export struct {
+	Src         string       `json:"src"`
+	Type        string       `json:"type,omitempty"`
+	Endpoint    string       `json:"endpoint"`
+	Labels      []Label      `json:"labels"`
+	Milestones  []Milestone  `json:"milestones"`
+	Issues      []Issue      `json:"issues"`
+	Pulls       []Issue      `json:"pulls"`
+	Discussions []Discussion `json:"discussions,omitempty"`
+}
+
+func LoadExport(path string) (*Export, error) {
+	buf, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	e := &Export{}
+	if err := json.Unmarshal(buf, e); err != nil {
+		return nil, fmt.Errorf("export %s: %s", path, err)
+	}
+	return e, nil
+}
+
+func (t *Transfer) Export(path string) error {
+	e := &Export{
+		Src:         t.Source.Repo(),
+		Labels:      t.Labels,
+		Milestones:  t.Milestones,
+		Issues:      t.Issues,
+		Pulls:       t.Pulls,
+		Discussions: t.Discussions,
+	}
+	switch s := t.Source.(type) {
+	case *SRC:
+		e.Type, e.Endpoint = SrcGitHub, s.Endpoint
+	case *GitLab:
+		e.Type, e.Endpoint = SrcGitLab, s.Endpoint
+	case *Jira:
+		e.Type, e.Endpoint = SrcJira, s.Endpoint
+	}
+	buf, err := json.MarshalIndent(e, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := ioutil.WriteFile(path, buf, 0644); err != nil {
+		return err
+	}
+	logf(t.Log, "exported: %d labels, %d milestones, %d issues, %d pulls, %d discussions to %s\n",
+		len(t.Labels), len(t.Milestones), len(t.Issues), len(t.Pulls), len(t.Discussions), path)
+	return nil
+}
+
+// SetSource sets the source of the export to the options without -src. The
+// type and the endpoint are set only when they are recorded, since the old
+// exports have neither the type nor the endpoint of the other sources.
+func (e *Export) SetSource(o *Options) {
+	if o.Src != "" {
+		return
+	}
+	o.Src = e.Src
+	if e.Type != "" {
+		o.SrcType = e.Type
+	}
+	if e.Endpoint != "" {
+		o.SrcEndpoint = e.Endpoint
+	}
+}
+
+// Import sets what is exported as if it were fetched.
+func (t *Transfer) Import(e *Export) {
+	t.Labels = e.Labels
+	t.Milestones = e.Milestones
+	t.Issues = e.Issues
+	t.Pulls = e.Pulls
+	t.Discussions = e.Discussions
+	if t.SkipLabels {
+		t.Labels = nil
+	}
+	if t.SkipMilestones {
+		t.Milestones = nil
+	}
+}
@@ -0,0 +1,102 @@
+package mover
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestExportImport(t *testing.T) {
+	f := newFakeGitHub()
+	defer f.Close()
+	f.AddUser("alice-dst")
+	seedSrc(f)
+	dir := testDir(t)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "export.json")
+
+	tr, err := New(context.Background(), testOptions(f, dir), testShared())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := tr.Fetch(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if err := tr.Export(path); err != nil {
+		t.Fatal(err)
+	}
+
+	e, err := LoadExport(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	o := testOptions(f, dir)
+	o.Src, o.SrcEndpoint = "", defaultEndpoint
+	e.SetSource(o)
+	if o.Src != testSrc || o.SrcType != SrcGitHub || o.SrcEndpoint != f.URL {
+		t.Errorf("source of the export: %s %s %s", o.Src, o.SrcType, o.SrcEndpoint)
+	}
+	tr, err = New(context.Background(), o, testShared())
+	if err != nil {
+		t.Fatal(err)
+	}
+	tr.Import(e)
+	if err := tr.Apply(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	dst := f.Repo(testDst)
+	if got := dst.Numbers(); len(got) != 5 || dst.Issues[1].Title != "first" || dst.Issues[5].Title != "fifth" {
+		t.Errorf("imported numbers: %v", got)
+	}
+}
+
+func TestExportSource(t *testing.T) {
+	s := newGitLabStub(t)
+	defer s.Close()
+	f := newFakeGitHub()
+	defer f.Close()
+	dir := testDir(t)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "export.json")
+
+	o := testOptions(f, dir)
+	o.SrcType = SrcGitLab
+	o.Src = testGitLabProject
+	o.SrcEndpoint = s.URL
+	o.SrcToken = "gitlab-token"
+	tr, err := New(context.Background(), o, testShared())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := tr.Fetch(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if err := tr.Export(path); err != nil {
+		t.Fatal(err)
+	}
+
+	e, err := LoadExport(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	o = DefaultOptions()
+	e.SetSource(o)
+	if o.Src != testGitLabProject || o.SrcType != SrcGitLab || o.SrcEndpoint != s.URL {
+		t.Errorf("source of the gitlab export: %s %s %s", o.Src, o.SrcType, o.SrcEndpoint)
+	}
+
+	// the options of -src are not overridden, and the old export without
+	// the type and the endpoint leaves them.
+	o = DefaultOptions()
+	o.Src = "foo/src"
+	e.SetSource(o)
+	if o.Src != "foo/src" || o.SrcType != SrcGitHub || o.SrcEndpoint != defaultEndpoint {
+		t.Errorf("source of -src: %s %s %s", o.Src, o.SrcType, o.SrcEndpoint)
+	}
+	o = DefaultOptions()
+	(&Export{Src: "foo/old"}).SetSource(o)
+	if o.Src != "foo/old" || o.SrcType != SrcGitHub || o.SrcEndpoint != defaultEndpoint {
+		t.Errorf("source of the old export: %s %s %s", o.Src, o.SrcType, o.SrcEndpoint)
+	}
+}
@@ -0,0 +1,551 @@
+package mover
+
+import (
+	"encoding/json"
+	"fmt"
+	"hash/crc32"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// fakeProject is a classic project of a repository, an organization or a
+// user, whose owner is like "repos/foo/bar", "orgs/foo" or "users/foo".
+type fakeProject struct {
+	ID      int64
+	Owner   string
+	Name    string
+	Body    string
+	Columns []*fakeColumn
+}
+
+type fakeColumn struct {
+	ID    int64
+	Name  string
+	Cards []*fakeCard
+}
+
+// fakeCard is a note, or of the issue of ContentURL in SRC or of ContentID in DST.
+type fakeCard struct {
+	ID          int64
+	Note        string
+	ContentURL  string
+	ContentID   int64
+	ContentType string
+}
+
+// fakeProjectV2 is a project of the owner, linked to the repository Repo.
+type fakeProjectV2 struct {
+	ID               string
+	Owner            string
+	Repo             string
+	Title            string
+	ShortDescription string
+	Readme           string
+	Fields           []*fakeProjectV2Field
+	Items            []*fakeProjectV2Item
+}
+
+type fakeProjectV2Field struct {
+	ID       string
+	Name     string
+	DataType string
+	Options  []ProjectV2SingleSelectFieldOption
+}
+
+// fakeProjectV2Item is an ISSUE or PULL_REQUEST of Repo and Number, or a
+// DRAFT_ISSUE of Title and Body.
+type fakeProjectV2Item struct {
+	ID     string
+	Type   string
+	Repo   string
+	Number int
+	Title  string
+	Body   string
+	Values []fakeProjectV2Value
+}
+
+// fakeProjectV2Value is the value of the field of the type: TEXT, NUMBER,
+// DATE or SINGLE_SELECT, whose Option is the name of the option.
+type fakeProjectV2Value struct {
+	Field  string
+	Type   string
+	Text   string
+	Number float64
+	Date   string
+	Option string
+}
+
+// issueID is the REST id of the issue, unique in the fake.
+func issueID(repo string, n int) int64 {
+	return int64(crc32.ChecksumIEEE([]byte(repo)))*100000 + int64(n)
+}
+
+func (f *fakeGitHub) nextID() int64 {
+	f.ids++
+	return f.ids
+}
+
+// AddOrg makes the login an organization.
+func (f *fakeGitHub) AddOrg(login string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.orgs[login] = true
+}
+
+// AddProject adds the classic project with the columns.
+func (f *fakeGitHub) AddProject(owner, name string, columns ...string) *fakeProject {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	p := &fakeProject{ID: f.nextID(), Owner: owner, Name: name}
+	for _, c := range columns {
+		p.Columns = append(p.Columns, &fakeColumn{ID: f.nextID(), Name: c})
+	}
+	f.projects = append(f.projects, p)
+	return p
+}
+
+// AddCard adds the card to the bottom of the column.
+func (f *fakeGitHub) AddCard(c *fakeColumn, card *fakeCard) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	card.ID = f.nextID()
+	c.Cards = append(c.Cards, card)
+}
+
+// Projects returns the classic projects of the owner.
+func (f *fakeGitHub) Projects(owner string) []*fakeProject {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var projects []*fakeProject
+	for _, p := range f.projects {
+		if p.Owner == owner {
+			projects = append(projects, p)
+		}
+	}
+	return projects
+}
+
+// AddProjectV2 adds the project with the built-in Title and Status fields.
+func (f *fakeGitHub) AddProjectV2(owner, repo, title string) *fakeProjectV2 {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.addProjectV2(owner, repo, title)
+}
+
+func (f *fakeGitHub) addProjectV2(owner, repo, title string) *fakeProjectV2 {
+	p := &fakeProjectV2{ID: fmt.Sprintf("PVT_%d", f.nextID()), Owner: owner, Repo: repo, Title: title}
+	p.Fields = append(p.Fields, &fakeProjectV2Field{ID: fmt.Sprintf("PVTF_%d", f.nextID()), Name: "Title", DataType: "TITLE"})
+	status := &fakeProjectV2Field{ID: fmt.Sprintf("PVTF_%d", f.nextID()), Name: "Status", DataType: "SINGLE_SELECT"}
+	f.setOptions(status, []string{"Todo", "In Progress", "Done"})
+	p.Fields = append(p.Fields, status)
+	f.projectsV2 = append(f.projectsV2, p)
+	return p
+}
+
+// AddField adds the field with the options of a single select one.
+func (f *fakeGitHub) AddField(p *fakeProjectV2, name, dataType string, options ...string) *fakeProjectV2Field {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	field := &fakeProjectV2Field{ID: fmt.Sprintf("PVTF_%d", f.nextID()), Name: name, DataType: dataType}
+	f.setOptions(field, options)
+	p.Fields = append(p.Fields, field)
+	return field
+}
+
+func (f *fakeGitHub) setOptions(field *fakeProjectV2Field, names []string) {
+	field.Options = nil
+	for _, name := range names {
+		field.Options = append(field.Options, ProjectV2SingleSelectFieldOption{ID: fmt.Sprintf("PVTSSF_%d", f.nextID()), Name: name, Color: "BLUE"})
+	}
+}
+
+// AddItem adds the item to the project.
+func (f *fakeGitHub) AddItem(p *fakeProjectV2, item *fakeProjectV2Item) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	item.ID = fmt.Sprintf("PVTI_%d", f.nextID())
+	p.Items = append(p.Items, item)
+}
+
+// ProjectsV2 returns the projects linked to the repository.
+func (f *fakeGitHub) ProjectsV2(repo string) []*fakeProjectV2 {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var projects []*fakeProjectV2
+	for _, p := range f.projectsV2 {
+		if p.Repo == repo {
+			projects = append(projects, p)
+		}
+	}
+	return projects
+}
+
+// Value returns the value of the field of the item.
+func (i *fakeProjectV2Item) Value(field string) (fakeProjectV2Value, bool) {
+	for _, v := range i.Values {
+		if v.Field == field {
+			return v, true
+		}
+	}
+	return fakeProjectV2Value{}, false
+}
+
+var (
+	reRepoProjects  = regexp.MustCompile(`^/repos/([^/]+/[^/]+)/projects$`)
+	reOrgProjects   = regexp.MustCompile(`^/orgs/([^/]+)/projects$`)
+	reUserProjects  = regexp.MustCompile(`^/user/projects$`)
+	reProjectColumn = regexp.MustCompile(`^/projects/(\d+)/columns$`)
+	reColumnCards   = regexp.MustCompile(`^/projects/columns/(\d+)/cards$`)
+)
+
+// serveProjects serves the REST endpoints of the classic projects, and
+// reports whether the request is of them.
+func (f *fakeGitHub) serveProjects(w http.ResponseWriter, r *http.Request, path string) bool {
+	var owner string
+	switch {
+	case reRepoProjects.MatchString(path):
+		owner = "repos/" + reRepoProjects.FindStringSubmatch(path)[1]
+	case reOrgProjects.MatchString(path):
+		login := reOrgProjects.FindStringSubmatch(path)[1]
+		if !f.orgs[login] {
+			f.error(w, http.StatusNotFound, "Not Found")
+			return true
+		}
+		owner = "orgs/" + login
+	case reUserProjects.MatchString(path) && r.Method == "POST":
+		owner = "users/" + f.authLogin(r)
+	}
+	if owner != "" {
+		switch r.Method {
+		case "GET":
+			projects := []interface{}{}
+			for _, p := range f.projects {
+				if p.Owner == owner {
+					projects = append(projects, map[string]interface{}{"id": p.ID, "name": p.Name, "body": p.Body})
+				}
+			}
+			f.json(w, http.StatusOK, projects)
+		case "POST":
+			var v struct{ Name, Body string }
+			json.NewDecoder(r.Body).Decode(&v)
+			p := &fakeProject{ID: f.nextID(), Owner: owner, Name: v.Name, Body: v.Body}
+			f.projects = append(f.projects, p)
+			f.json(w, http.StatusCreated, map[string]interface{}{"id": p.ID, "name": p.Name, "body": p.Body})
+		default:
+			return false
+		}
+		return true
+	}
+
+	if m := reProjectColumn.FindStringSubmatch(path); m != nil {
+		id, _ := strconv.ParseInt(m[1], 10, 64)
+		var p *fakeProject
+		for _, v := range f.projects {
+			if v.ID == id {
+				p = v
+			}
+		}
+		if p == nil {
+			f.error(w, http.StatusNotFound, "Not Found")
+			return true
+		}
+		switch r.Method {
+		case "GET":
+			columns := []interface{}{}
+			for _, c := range p.Columns {
+				columns = append(columns, map[string]interface{}{"id": c.ID, "name": c.Name})
+			}
+			f.json(w, http.StatusOK, columns)
+		case "POST":
+			var v struct{ Name string }
+			json.NewDecoder(r.Body).Decode(&v)
+			c := &fakeColumn{ID: f.nextID(), Name: v.Name}
+			p.Columns = append(p.Columns, c)
+			f.json(w, http.StatusCreated, map[string]interface{}{"id": c.ID, "name": c.Name})
+		default:
+			return false
+		}
+		return true
+	}
+
+	if m := reColumnCards.FindStringSubmatch(path); m != nil {
+		id, _ := strconv.ParseInt(m[1], 10, 64)
+		var c *fakeColumn
+		for _, p := range f.projects {
+			for _, v := range p.Columns {
+				if v.ID == id {
+					c = v
+				}
+			}
+		}
+		if c == nil {
+			f.error(w, http.StatusNotFound, "Not Found")
+			return true
+		}
+		switch r.Method {
+		case "GET":
+			cards := []interface{}{}
+			for _, v := range c.Cards {
+				cards = append(cards, map[string]interface{}{"id": v.ID, "note": v.Note, "content_url": v.ContentURL})
+			}
+			f.json(w, http.StatusOK, cards)
+		case "POST":
+			var v struct {
+				Note        string
+				ContentID   int64  `json:"content_id"`
+				ContentType string `json:"content_type"`
+			}
+			json.NewDecoder(r.Body).Decode(&v)
+			card := &fakeCard{ID: f.nextID(), Note: v.Note, ContentID: v.ContentID, ContentType: v.ContentType}
+			// a new card is put on the top of the column.
+			c.Cards = append([]*fakeCard{card}, c.Cards...)
+			f.json(w, http.StatusCreated, map[string]interface{}{"id": card.ID, "note": card.Note})
+		default:
+			return false
+		}
+		return true
+	}
+
+	return false
+}
+
+// serveProjectsV2 serves the GraphQL queries and mutations of the projects,
+// and reports whether the query is of them.
+func (f *fakeGitHub) serveProjectsV2(w http.ResponseWriter, q string, vars, input map[string]interface{}) bool {
+	owner := fmt.Sprint(vars["owner"])
+	name := fmt.Sprintf("%v/%v", vars["owner"], vars["repo"])
+	var data map[string]interface{}
+	switch {
+	case strings.Contains(q, "repositoryOwner(login:"):
+		data = map[string]interface{}{
+			"repositoryOwner": map[string]interface{}{"id": "O_" + owner},
+			"repository":      map[string]interface{}{"id": "R_" + name},
+		}
+	case strings.Contains(q, "organization(login:") && strings.Contains(q, "projectsV2("):
+		if !f.orgs[owner] {
+			f.json(w, http.StatusOK, map[string]interface{}{"errors": []interface{}{map[string]interface{}{"message": "Could not resolve to an Organization with the login of '" + owner + "'."}}})
+			return true
+		}
+		var nodes []interface{}
+		for _, p := range f.projectsV2 {
+			if p.Owner == owner {
+				nodes = append(nodes, gqlProjectV2(p))
+			}
+		}
+		data = map[string]interface{}{"organization": map[string]interface{}{"projectsV2": f.connection(nodes, vars["cursor"])}}
+	case strings.Contains(q, "repository(owner:") && strings.Contains(q, "projectsV2("):
+		var nodes []interface{}
+		for _, p := range f.projectsV2 {
+			if p.Repo == name {
+				nodes = append(nodes, gqlProjectV2(p))
+			}
+		}
+		data = map[string]interface{}{"repository": map[string]interface{}{"projectsV2": f.connection(nodes, vars["cursor"])}}
+	case strings.Contains(q, "node(id: $id)") && strings.Contains(q, "items(first: 100"):
+		p := f.projectV2(fmt.Sprint(vars["id"]))
+		if p == nil {
+			data = map[string]interface{}{"node": nil}
+			break
+		}
+		var nodes []interface{}
+		for _, v := range p.Items {
+			nodes = append(nodes, gqlProjectV2Item(v))
+		}
+		data = map[string]interface{}{"node": map[string]interface{}{"items": f.connection(nodes, vars["cursor"])}}
+	case strings.Contains(q, "node(id: $id)") && strings.Contains(q, "fields(first: 50)"):
+		p := f.projectV2(fmt.Sprint(vars["id"]))
+		if p == nil {
+			data = map[string]interface{}{"node": nil}
+			break
+		}
+		data = map[string]interface{}{"node": map[string]interface{}{"fields": gqlProjectV2Fields(p)}}
+	case strings.Contains(q, "createProjectV2("):
+		owner := strings.TrimPrefix(fmt.Sprint(input["ownerId"]), "O_")
+		p := f.addProjectV2(owner, strings.TrimPrefix(fmt.Sprint(input["repositoryId"]), "R_"), fmt.Sprint(input["title"]))
+		data = map[string]interface{}{"createProjectV2": map[string]interface{}{"projectV2": map[string]interface{}{"id": p.ID, "title": p.Title}}}
+	case strings.Contains(q, "updateProjectV2("):
+		p := f.projectV2(fmt.Sprint(input["projectId"]))
+		if p == nil {
+			return false
+		}
+		if v, ok := input["shortDescription"].(string); ok {
+			p.ShortDescription = v
+		}
+		if v, ok := input["readme"].(string); ok {
+			p.Readme = v
+		}
+		data = map[string]interface{}{"updateProjectV2": map[string]interface{}{"clientMutationId": ""}}
+	case strings.Contains(q, "createProjectV2Field("):
+		p := f.projectV2(fmt.Sprint(input["projectId"]))
+		if p == nil {
+			return false
+		}
+		field := &fakeProjectV2Field{ID: fmt.Sprintf("PVTF_%d", f.nextID()), Name: fmt.Sprint(input["name"]), DataType: fmt.Sprint(input["dataType"])}
+		f.setOptions(field, optionNames(input["singleSelectOptions"]))
+		p.Fields = append(p.Fields, field)
+		data = map[string]interface{}{"createProjectV2Field": map[string]interface{}{"clientMutationId": ""}}
+	case strings.Contains(q, "updateProjectV2Field("):
+		_, field := f.projectV2Field(fmt.Sprint(input["fieldId"]))
+		if field == nil {
+			return false
+		}
+		f.setOptions(field, optionNames(input["singleSelectOptions"]))
+		data = map[string]interface{}{"updateProjectV2Field": map[string]interface{}{"clientMutationId": ""}}
+	case strings.Contains(q, "addProjectV2ItemById("):
+		p := f.projectV2(fmt.Sprint(input["projectId"]))
+		id := fmt.Sprint(input["contentId"])
+		repo, n, ok := f.parseNodeID(id)
+		if p == nil || !ok {
+			return false
+		}
+		// the item of the content already added is returned.
+		var item *fakeProjectV2Item
+		for _, v := range p.Items {
+			if v.Type != "DRAFT_ISSUE" && v.Repo == id[2:strings.LastIndex(id, "_")] && v.Number == n {
+				item = v
+			}
+		}
+		if item == nil {
+			item = &fakeProjectV2Item{ID: fmt.Sprintf("PVTI_%d", f.nextID()), Type: "ISSUE", Repo: id[2:strings.LastIndex(id, "_")], Number: n}
+			if repo.Issues[n].Pull {
+				item.Type = "PULL_REQUEST"
+			}
+			p.Items = append(p.Items, item)
+		}
+		data = map[string]interface{}{"addProjectV2ItemById": map[string]interface{}{"item": map[string]interface{}{"id": item.ID}}}
+	case strings.Contains(q, "addProjectV2DraftIssue("):
+		p := f.projectV2(fmt.Sprint(input["projectId"]))
+		if p == nil {
+			return false
+		}
+		item := &fakeProjectV2Item{ID: fmt.Sprintf("PVTI_%d", f.nextID()), Type: "DRAFT_ISSUE", Title: fmt.Sprint(input["title"])}
+		if v, ok := input["body"].(string); ok {
+			item.Body = v
+		}
+		p.Items = append(p.Items, item)
+		data = map[string]interface{}{"addProjectV2DraftIssue": map[string]interface{}{"projectItem": map[string]interface{}{"id": item.ID}}}
+	case strings.Contains(q, "updateProjectV2ItemFieldValue("):
+		p, field := f.projectV2Field(fmt.Sprint(input["fieldId"]))
+		if p == nil {
+			return false
+		}
+		var item *fakeProjectV2Item
+		for _, v := range p.Items {
+			if v.ID == input["itemId"] {
+				item = v
+			}
+		}
+		if item == nil {
+			return false
+		}
+		value, _ := input["value"].(map[string]interface{})
+		v := fakeProjectV2Value{Field: field.Name, Type: field.DataType}
+		switch field.DataType {
+		case "TEXT":
+			v.Text, _ = value["text"].(string)
+		case "NUMBER":
+			v.Number, _ = value["number"].(float64)
+		case "DATE":
+			v.Date, _ = value["date"].(string)
+		case "SINGLE_SELECT":
+			for _, o := range field.Options {
+				if o.ID == value["singleSelectOptionId"] {
+					v.Option = o.Name
+				}
+			}
+		}
+		var values []fakeProjectV2Value
+		for _, old := range item.Values {
+			if old.Field != field.Name {
+				values = append(values, old)
+			}
+		}
+		item.Values = append(values, v)
+		data = map[string]interface{}{"updateProjectV2ItemFieldValue": map[string]interface{}{"clientMutationId": ""}}
+	default:
+		return false
+	}
+	f.json(w, http.StatusOK, map[string]interface{}{"data": data})
+	return true
+}
+
+func (f *fakeGitHub) projectV2(id string) *fakeProjectV2 {
+	for _, p := range f.projectsV2 {
+		if p.ID == id {
+			return p
+		}
+	}
+	return nil
+}
+
+func (f *fakeGitHub) projectV2Field(id string) (*fakeProjectV2, *fakeProjectV2Field) {
+	for _, p := range f.projectsV2 {
+		for _, v := range p.Fields {
+			if v.ID == id {
+				return p, v
+			}
+		}
+	}
+	return nil, nil
+}
+
+func optionNames(v interface{}) []string {
+	var names []string
+	options, _ := v.([]interface{})
+	for _, o := range options {
+		if m, ok := o.(map[string]interface{}); ok {
+			names = append(names, fmt.Sprint(m["name"]))
+		}
+	}
+	return names
+}
+
+func gqlProjectV2(p *fakeProjectV2) map[string]interface{} {
+	return map[string]interface{}{
+		"id":               p.ID,
+		"title":            p.Title,
+		"shortDescription": p.ShortDescription,
+		"readme":           p.Readme,
+		"fields":           gqlProjectV2Fields(p),
+	}
+}
+
+func gqlProjectV2Fields(p *fakeProjectV2) map[string]interface{} {
+	var nodes []interface{}
+	for _, v := range p.Fields {
+		node := map[string]interface{}{"id": v.ID, "name": v.Name, "dataType": v.DataType}
+		if v.DataType == "SINGLE_SELECT" {
+			var options []interface{}
+			for _, o := range v.Options {
+				options = append(options, map[string]interface{}{"id": o.ID, "name": o.Name, "color": o.Color, "description": o.Description})
+			}
+			node["options"] = options
+		}
+		nodes = append(nodes, node)
+	}
+	return map[string]interface{}{"nodes": nodes}
+}
+
+func gqlProjectV2Item(i *fakeProjectV2Item) map[string]interface{} {
+	content := map[string]interface{}{"title": i.Title, "body": i.Body}
+	if i.Type != "DRAFT_ISSUE" {
+		content = map[string]interface{}{"number": i.Number, "repository": map[string]interface{}{"nameWithOwner": i.Repo}}
+	}
+	var values []interface{}
+	for _, v := range i.Values {
+		field := map[string]interface{}{"name": v.Field}
+		switch v.Type {
+		case "TEXT", "TITLE":
+			values = append(values, map[string]interface{}{"__typename": "ProjectV2ItemFieldTextValue", "text": v.Text, "field": field})
+		case "NUMBER":
+			values = append(values, map[string]interface{}{"__typename": "ProjectV2ItemFieldNumberValue", "number": v.Number, "field": field})
+		case "DATE":
+			values = append(values, map[string]interface{}{"__typename": "ProjectV2ItemFieldDateValue", "date": v.Date, "field": field})
+		case "SINGLE_SELECT":
+			values = append(values, map[string]interface{}{"__typename": "ProjectV2ItemFieldSingleSelectValue", "name": v.Option, "field": field})
+		}
+	}
+	return map[string]interface{}{"id": i.ID, "type": i.Type, "content": content, "fieldValues": map[string]interface{}{"nodes": values}}
+}
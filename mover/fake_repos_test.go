@@ -0,0 +1,144 @@
+package mover
+
+import (
+	"encoding/json"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+var (
+	reOrgRepos = regexp.MustCompile(`^/orgs/([^/]+)/repos$`)
+	reTopics   = regexp.MustCompile(`^/repos/([^/]+/[^/]+)/topics$`)
+	reBranch   = regexp.MustCompile(`^/repos/([^/]+/[^/]+)/branches/([^/]+)$`)
+)
+
+func restRepo(name string, v *fakeRepo) map[string]interface{} {
+	visibility := v.Visibility
+	if visibility == "" {
+		visibility = "public"
+	}
+	return map[string]interface{}{
+		"name":           strings.Split(name, "/")[1],
+		"full_name":      name,
+		"node_id":        "R_" + name,
+		"description":    v.Description,
+		"homepage":       v.Homepage,
+		"private":        visibility != "public",
+		"visibility":     visibility,
+		"has_issues":     !v.IssuesDisabled,
+		"topics":         v.Topics,
+		"default_branch": v.DefaultBranch,
+		"archived":       v.Archived,
+		"fork":           v.Fork,
+	}
+}
+
+// serveRepos serves the endpoints creating repositories and the ones of
+// the missing repositories, and reports whether the request is served.
+func (f *fakeGitHub) serveRepos(w http.ResponseWriter, r *http.Request, path string) bool {
+	var owner string
+	switch {
+	case r.Method == "POST" && reOrgRepos.MatchString(path):
+		owner = reOrgRepos.FindStringSubmatch(path)[1]
+		if !f.orgs[owner] {
+			f.error(w, http.StatusNotFound, "Not Found")
+			return true
+		}
+	case r.Method == "POST" && path == "/user/repos":
+		owner = f.authLogin(r)
+	}
+	if owner != "" {
+		var v struct {
+			Name        string
+			Description string
+			Homepage    string
+			Visibility  string
+			HasIssues   *bool `json:"has_issues"`
+		}
+		json.NewDecoder(r.Body).Decode(&v)
+		name := owner + "/" + v.Name
+		repo := f.repo(name)
+		if !repo.Missing {
+			f.error(w, http.StatusUnprocessableEntity, "name already exists on this account")
+			return true
+		}
+		repo.Missing = false
+		repo.Owner = owner
+		repo.Description = v.Description
+		repo.Homepage = v.Homepage
+		repo.Visibility = v.Visibility
+		repo.IssuesDisabled = v.HasIssues != nil && !*v.HasIssues
+		repo.Labels = append(repo.Labels, f.DefaultLabels...)
+		f.json(w, http.StatusCreated, restRepo(name, repo))
+		return true
+	}
+
+	if m := reOrgRepos.FindStringSubmatch(path); m != nil && r.Method == "GET" {
+		var names []string
+		for name, v := range f.repos {
+			if strings.HasPrefix(name, m[1]+"/") && !v.Missing {
+				names = append(names, name)
+			}
+		}
+		sort.Strings(names)
+		repos := []interface{}{}
+		for _, name := range names {
+			repos = append(repos, restRepo(name, f.repos[name]))
+		}
+		f.json(w, http.StatusOK, repos)
+		return true
+	}
+	if m := reRepo.FindStringSubmatch(path); m != nil && f.repo(m[1]).Missing {
+		f.error(w, http.StatusNotFound, "Not Found")
+		return true
+	}
+	if m := reTopics.FindStringSubmatch(path); m != nil && r.Method == "PUT" {
+		var v struct {
+			Names []string
+		}
+		json.NewDecoder(r.Body).Decode(&v)
+		f.repo(m[1]).Topics = v.Names
+		f.json(w, http.StatusOK, map[string]interface{}{"names": v.Names})
+		return true
+	}
+	if m := reLabels.FindStringSubmatch(path); m != nil && r.Method == "GET" {
+		labels := []interface{}{}
+		for _, l := range f.repo(m[1]).Labels {
+			labels = append(labels, map[string]interface{}{"name": l.Name, "color": l.Color})
+		}
+		f.json(w, http.StatusOK, labels)
+		return true
+	}
+	if m := reLabel.FindStringSubmatch(path); m != nil && r.Method == "DELETE" {
+		repo := f.repo(m[1])
+		for i, l := range repo.Labels {
+			if strings.EqualFold(l.Name, m[2]) {
+				repo.Labels = append(repo.Labels[:i], repo.Labels[i+1:]...)
+				w.WriteHeader(http.StatusNoContent)
+				return true
+			}
+		}
+		f.error(w, http.StatusNotFound, "Not Found")
+		return true
+	}
+	if m := reBranch.FindStringSubmatch(path); m != nil && r.Method == "GET" {
+		if !hasString(f.repo(m[1]).Branches, m[2]) {
+			f.error(w, http.StatusNotFound, "Branch not found")
+			return true
+		}
+		f.json(w, http.StatusOK, map[string]interface{}{"name": m[2]})
+		return true
+	}
+	return false
+}
+
+func hasString(values []string, s string) bool {
+	for _, v := range values {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
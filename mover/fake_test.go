@@ -0,0 +1,1076 @@
+package mover
+
+import (
+	"crypto"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// fakeGitHub is an in-memory GitHub Enterprise serving the GraphQL queries
+// and the REST endpoints used by the mover. Its endpoint is its URL.
+type fakeGitHub struct {
+	*httptest.Server
+
+	// PageSize is the number of nodes and items of a page.
+	PageSize int
+	// ImportPolls is the number of pending statuses of an import before it is imported.
+	ImportPolls int
+	// FailImports makes the imports end in the failed status, and
+	// FailImportOf the ones of the title, which get no number.
+	FailImports  bool
+	FailImportOf string
+	// AuthLogin is the login of the tokens of no user by AddToken.
+	AuthLogin string
+	// DefaultLabels are the labels of the created repositories.
+	DefaultLabels []Label
+
+	mu       sync.Mutex
+	repos    map[string]*fakeRepo
+	users    map[string]bool
+	imports  map[int]*fakeImport
+	failures []*fakeFailure
+	requests []string
+	apps     map[int64]*fakeApp
+	tokens   map[string]string
+	orgs     map[string]bool
+
+	projects   []*fakeProject
+	projectsV2 []*fakeProjectV2
+	ids        int64
+}
+
+// fakeApp is an installation of a GitHub App.
+type fakeApp struct {
+	id        int64
+	key       *rsa.PublicKey
+	expiresIn time.Duration
+	issued    int
+}
+
+type fakeRepo struct {
+	Labels     []Label
+	Milestones []Milestone
+	Issues     map[int]*fakeIssue
+	Imports    []*IssueImportRequest
+	// Description, IssuesDisabled and Archived are set by the repository edits.
+	Description    string
+	IssuesDisabled bool
+	Archived       bool
+	Fork           bool
+	// Missing makes the repository not found until it is created, and
+	// Owner is the login it is created for.
+	Missing       bool
+	Owner         string
+	Visibility    string
+	Homepage      string
+	Topics        []string
+	DefaultBranch string
+	Branches      []string
+	// DiscussionCategories and Discussions are of the discussions api,
+	// whose numbers are shared with the issues.
+	DiscussionCategories []DiscussionCategory
+	Discussions          []*Discussion
+	next                 int
+}
+
+type fakeIssue struct {
+	Issue
+	Pull    bool
+	Deleted bool
+	// TransferredTo is the issue the REST api redirects to, like
+	// "foo/bar/issues/1", and MovedTo is the repository the issue is
+	// returned of without the redirect.
+	TransferredTo string
+	MovedTo       string
+	// Discussion is whether the number is of a discussion.
+	Discussion bool
+	Locked     bool
+	LockReason string
+	// Importing makes the issue not found until the status of its import
+	// turns imported.
+	Importing bool
+}
+
+// gone reports whether the number is no longer of an issue or a pull request.
+func (v *fakeIssue) gone() bool {
+	return v.Deleted || v.TransferredTo != "" || v.MovedTo != "" || v.Discussion
+}
+
+type fakeImport struct {
+	repo   string
+	number int
+	polls  int
+	failed bool
+}
+
+// fakeFailure is a response replacing the ones of a request for times.
+type fakeFailure struct {
+	method     string
+	path       string
+	status     int
+	retryAfter int
+	times      int
+}
+
+func newFakeGitHub() *fakeGitHub {
+	f := &fakeGitHub{
+		PageSize: 100,
+		repos:    map[string]*fakeRepo{},
+		users:    map[string]bool{},
+		imports:  map[int]*fakeImport{},
+		orgs:     map[string]bool{},
+	}
+	f.Server = httptest.NewServer(http.HandlerFunc(f.serve))
+	return f
+}
+
+// Repo returns the repository of the name like owner/name, creating it if missing.
+func (f *fakeGitHub) Repo(name string) *fakeRepo {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.repo(name)
+}
+
+func (f *fakeGitHub) repo(name string) *fakeRepo {
+	r, ok := f.repos[name]
+	if !ok {
+		r = &fakeRepo{Issues: map[int]*fakeIssue{}}
+		f.repos[name] = r
+	}
+	return r
+}
+
+// authLogin returns the login of the authenticated user of the request.
+func (f *fakeGitHub) authLogin(r *http.Request) string {
+	if login := f.login(r); login != "" {
+		return login
+	}
+	return f.AuthLogin
+}
+
+func (f *fakeGitHub) AddUser(login string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.users[login] = true
+}
+
+// AddInstallation adds the installation of the app of the key, whose
+// tokens expire in expiresIn. Only the tokens added by AddToken and of the
+// installations are accepted once one of them is added.
+func (f *fakeGitHub) AddInstallation(appID, installationID int64, key *rsa.PublicKey, expiresIn time.Duration) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.apps == nil {
+		f.apps = map[int64]*fakeApp{}
+	}
+	if f.tokens == nil {
+		f.tokens = map[string]string{}
+	}
+	f.apps[installationID] = &fakeApp{id: appID, key: key, expiresIn: expiresIn}
+}
+
+// AddToken adds the token of the login, who authors what is created with it.
+func (f *fakeGitHub) AddToken(token, login string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.tokens == nil {
+		f.tokens = map[string]string{}
+	}
+	f.tokens[token] = login
+}
+
+// login returns the login of the token of the request, or empty.
+func (f *fakeGitHub) login(r *http.Request) string {
+	return f.tokens[strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")]
+}
+
+// Issued returns the number of the tokens issued for the installation.
+func (f *fakeGitHub) Issued(installationID int64) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.apps[installationID].issued
+}
+
+// Fail makes the next times requests of the method and path respond with the status.
+func (f *fakeGitHub) Fail(method, path string, status, times int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.failures = append(f.failures, &fakeFailure{method: method, path: path, status: status, times: times})
+}
+
+// RetryAfter makes the next times requests of the method and path refused
+// with the seconds to wait before the next request, like the secondary rate limits.
+func (f *fakeGitHub) RetryAfter(method, path string, seconds, times int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.failures = append(f.failures, &fakeFailure{method: method, path: path, retryAfter: seconds, times: times})
+}
+
+// FailGraphQL makes the next times GraphQL requests containing match in
+// the query or the variables fail with an error.
+func (f *fakeGitHub) FailGraphQL(match string, times int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.failures = append(f.failures, &fakeFailure{method: "GRAPHQL", path: match, times: times})
+}
+
+// AddDiscussion adds the discussion to the repository with the next number,
+// giving the ids to it and its comments.
+func (r *fakeRepo) AddDiscussion(name string, d *Discussion) *Discussion {
+	r.next++
+	d.Number = r.next
+	d.ID = fmt.Sprintf("D_%s_%d", name, d.Number)
+	for i := range d.Comments.Nodes {
+		d.Comments.Nodes[i].ID = fmt.Sprintf("%s_%d", d.ID, i+1)
+	}
+	r.Discussions = append(r.Discussions, d)
+	return d
+}
+
+// Requests returns the requests served so far, like "POST /repos/foo/bar/labels".
+func (f *fakeGitHub) Requests() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]string(nil), f.requests...)
+}
+
+// AddIssue adds the issue or pull request to the repository with the next number.
+func (r *fakeRepo) AddIssue(v *fakeIssue) *fakeIssue {
+	if v.Number == 0 {
+		v.Number = r.next + 1
+	}
+	if v.Number > r.next {
+		r.next = v.Number
+	}
+	if v.Closed {
+		v.State = "CLOSED"
+	} else {
+		v.State = "OPEN"
+	}
+	r.Issues[v.Number] = v
+	return v
+}
+
+// Numbers returns the numbers of the issues and pull requests not deleted.
+func (r *fakeRepo) Numbers() []int {
+	var ns []int
+	for n, v := range r.Issues {
+		if !v.gone() {
+			ns = append(ns, n)
+		}
+	}
+	sort.Ints(ns)
+	return ns
+}
+
+func (f *fakeGitHub) serve(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.requests = append(f.requests, r.Method+" "+strings.TrimPrefix(r.URL.Path, "/api/v3"))
+	if r.Header.Get("Authorization") == "" {
+		f.error(w, http.StatusUnauthorized, "Requires authentication")
+		return
+	}
+	if m := reAppToken.FindStringSubmatch(strings.TrimPrefix(r.URL.Path, "/api/v3")); m != nil && r.Method == "POST" {
+		id, _ := strconv.ParseInt(m[1], 10, 64)
+		f.serveAppToken(w, r, id)
+		return
+	}
+	if f.tokens != nil && f.login(r) == "" {
+		f.error(w, http.StatusUnauthorized, "Bad credentials")
+		return
+	}
+	for _, v := range f.failures {
+		if v.times == 0 || v.method != r.Method || v.path != strings.TrimPrefix(r.URL.Path, "/api/v3") {
+			continue
+		}
+		v.times--
+		if v.retryAfter > 0 {
+			w.Header().Set("Retry-After", strconv.Itoa(v.retryAfter))
+			f.error(w, http.StatusForbidden, "You have exceeded a secondary rate limit.")
+			return
+		}
+		f.error(w, v.status, http.StatusText(v.status))
+		return
+	}
+
+	if r.URL.Path == "/api/graphql" {
+		f.serveGraphQL(w, r)
+		return
+	}
+	f.serveREST(w, r)
+}
+
+// serveAppToken issues an installation token for the JWT of the app.
+func (f *fakeGitHub) serveAppToken(w http.ResponseWriter, r *http.Request, installationID int64) {
+	app, ok := f.apps[installationID]
+	if !ok {
+		f.error(w, http.StatusNotFound, "Not Found")
+		return
+	}
+	s := strings.Split(strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer "), ".")
+	if len(s) != 3 {
+		f.error(w, http.StatusUnauthorized, "A JSON web token could not be decoded")
+		return
+	}
+	sig, _ := base64.RawURLEncoding.DecodeString(s[2])
+	hash := sha256.Sum256([]byte(s[0] + "." + s[1]))
+	if err := rsa.VerifyPKCS1v15(app.key, crypto.SHA256, hash[:], sig); err != nil {
+		f.error(w, http.StatusUnauthorized, "A JSON web token could not be decoded")
+		return
+	}
+	var claims struct {
+		Iss string
+		Exp int64
+	}
+	buf, _ := base64.RawURLEncoding.DecodeString(s[1])
+	json.Unmarshal(buf, &claims)
+	if claims.Iss != strconv.FormatInt(app.id, 10) || claims.Exp < time.Now().Unix() {
+		f.error(w, http.StatusUnauthorized, "'Expiration time' claim ('exp') is too far in the future")
+		return
+	}
+	app.issued++
+	token := fmt.Sprintf("ghs_%d_%d", installationID, app.issued)
+	f.tokens[token] = "app[bot]"
+	f.json(w, http.StatusCreated, map[string]interface{}{
+		"token":      token,
+		"expires_at": time.Now().Add(app.expiresIn).UTC().Format(time.RFC3339),
+	})
+}
+
+func (f *fakeGitHub) error(w http.ResponseWriter, status int, message string) {
+	f.json(w, status, map[string]interface{}{"message": message})
+}
+
+func (f *fakeGitHub) json(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// page returns the range of the page of the cursor, and the next cursor.
+func (f *fakeGitHub) page(cursor interface{}, total int) (int, int, string) {
+	from := 0
+	if s, ok := cursor.(string); ok {
+		from, _ = strconv.Atoi(s)
+	}
+	to := from + f.PageSize
+	if to >= total {
+		return from, total, ""
+	}
+	return from, to, strconv.Itoa(to)
+}
+
+func (f *fakeGitHub) connection(nodes []interface{}, cursor interface{}) map[string]interface{} {
+	from, to, next := f.page(cursor, len(nodes))
+	return map[string]interface{}{
+		"nodes": append([]interface{}{}, nodes[from:to]...),
+		"pageInfo": map[string]interface{}{
+			"endCursor":   next,
+			"hasNextPage": next != "",
+		},
+	}
+}
+
+func (f *fakeGitHub) serveGraphQL(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Query     string
+		Variables map[string]interface{}
+	}
+	buf, err := ioutil.ReadAll(r.Body)
+	if err == nil {
+		err = json.Unmarshal(buf, &req)
+	}
+	if err != nil {
+		f.error(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	for _, v := range f.failures {
+		if v.times > 0 && v.method == "GRAPHQL" && strings.Contains(string(buf), v.path) {
+			v.times--
+			f.json(w, http.StatusOK, map[string]interface{}{"errors": []interface{}{map[string]interface{}{"message": "failed by the test"}}})
+			return
+		}
+	}
+	name := fmt.Sprintf("%v/%v", req.Variables["owner"], req.Variables["repo"])
+	repo := f.repo(name)
+	cursor := req.Variables["cursor"]
+
+	var data map[string]interface{}
+	q := req.Query
+	input, _ := req.Variables["input"].(map[string]interface{})
+	if strings.HasPrefix(q, "mutation") && !strings.Contains(q, "deleteIssue(") && strings.Contains(r.Header.Get("Authorization"), "dst-admin-token") {
+		f.json(w, http.StatusOK, map[string]interface{}{"errors": []interface{}{map[string]interface{}{"message": "the admin token is only for the deletes"}}})
+		return
+	}
+	if f.serveProjectsV2(w, q, req.Variables, input) {
+		return
+	}
+	switch {
+	case strings.Contains(q, "discussionCategories("):
+		var nodes []interface{}
+		for _, v := range repo.DiscussionCategories {
+			nodes = append(nodes, map[string]interface{}{
+				"id": v.ID, "name": v.Name, "slug": v.Slug, "description": v.Description, "isAnswerable": v.IsAnswerable,
+			})
+		}
+		data = map[string]interface{}{"repository": map[string]interface{}{
+			"id": "R_" + name, "discussionCategories": map[string]interface{}{"nodes": nodes},
+		}}
+	case strings.Contains(q, "discussions(first:"):
+		var nodes []interface{}
+		for _, v := range repo.Discussions {
+			nodes = append(nodes, f.gqlDiscussion(v))
+		}
+		data = map[string]interface{}{"repository": map[string]interface{}{"discussions": f.connection(nodes, cursor)}}
+	case strings.Contains(q, "node(id: $id)") && strings.Contains(q, "replies("):
+		_, c := f.discussionComment(fmt.Sprint(req.Variables["id"]))
+		if c == nil {
+			data = map[string]interface{}{"node": nil}
+			break
+		}
+		var replies []interface{}
+		for _, v := range c.Replies.Nodes {
+			replies = append(replies, gqlDiscussionReply(v))
+		}
+		data = map[string]interface{}{"node": map[string]interface{}{"replies": f.connection(replies, cursor)}}
+	case strings.Contains(q, "discussion(number:") && strings.Contains(q, "comments(first:"):
+		n := int(req.Variables["number"].(float64))
+		var node map[string]interface{}
+		for _, v := range repo.Discussions {
+			if v.Number == n {
+				node = f.gqlDiscussion(v)
+				if strings.Contains(q, "comments(first: 100, after: $cursor)") {
+					var comments []interface{}
+					for _, c := range v.Comments.Nodes {
+						comments = append(comments, f.gqlDiscussionComment(c))
+					}
+					node = map[string]interface{}{"comments": f.connection(comments, cursor)}
+				}
+			}
+		}
+		data = map[string]interface{}{"repository": map[string]interface{}{"discussion": node}}
+	case strings.Contains(q, "createDiscussion("):
+		name := strings.TrimPrefix(fmt.Sprint(input["repositoryId"]), "R_")
+		d := &Discussion{Title: fmt.Sprint(input["title"]), Body: fmt.Sprint(input["body"]), CreatedAt: time.Now()}
+		for _, v := range f.repo(name).DiscussionCategories {
+			if v.ID == input["categoryId"] {
+				d.Category.Name = v.Name
+				d.Category.Slug = v.Slug
+			}
+		}
+		f.repo(name).AddDiscussion(name, d)
+		data = map[string]interface{}{"createDiscussion": map[string]interface{}{
+			"discussion": map[string]interface{}{"id": d.ID, "number": d.Number},
+		}}
+	case strings.Contains(q, "addDiscussionComment("):
+		d := f.discussion(fmt.Sprint(input["discussionId"]))
+		if d == nil {
+			f.json(w, http.StatusOK, map[string]interface{}{"errors": []interface{}{map[string]interface{}{"message": "not found"}}})
+			return
+		}
+		body := fmt.Sprint(input["body"])
+		var id string
+		if replyTo, ok := input["replyToId"]; ok {
+			_, c := f.discussionComment(fmt.Sprint(replyTo))
+			if c == nil {
+				f.json(w, http.StatusOK, map[string]interface{}{"errors": []interface{}{map[string]interface{}{"message": "not found"}}})
+				return
+			}
+			c.Replies.Nodes = append(c.Replies.Nodes, DiscussionReply{Body: body, CreatedAt: time.Now()})
+			id = fmt.Sprintf("%s_%d", c.ID, len(c.Replies.Nodes))
+		} else {
+			id = fmt.Sprintf("%s_%d", d.ID, len(d.Comments.Nodes)+1)
+			d.Comments.Nodes = append(d.Comments.Nodes, DiscussionComment{ID: id, Body: body, CreatedAt: time.Now()})
+		}
+		data = map[string]interface{}{"addDiscussionComment": map[string]interface{}{"comment": map[string]interface{}{"id": id}}}
+	case strings.Contains(q, "markDiscussionCommentAsAnswer("):
+		_, c := f.discussionComment(fmt.Sprint(input["id"]))
+		if c == nil {
+			f.json(w, http.StatusOK, map[string]interface{}{"errors": []interface{}{map[string]interface{}{"message": "not found"}}})
+			return
+		}
+		c.IsAnswer = true
+		data = map[string]interface{}{"markDiscussionCommentAsAnswer": map[string]interface{}{"clientMutationId": ""}}
+	case strings.Contains(q, "issueOrPullRequest("):
+		var node interface{}
+		n := int(req.Variables["number"].(float64))
+		if v, ok := repo.Issues[n]; ok && !v.gone() {
+			typename := "Issue"
+			if v.Pull {
+				typename = "PullRequest"
+			}
+			node = map[string]interface{}{"__typename": typename}
+		}
+		data = map[string]interface{}{"repository": map[string]interface{}{"issueOrPullRequest": node}}
+	case strings.Contains(q, "discussion(number:"):
+		var node interface{}
+		n := int(req.Variables["number"].(float64))
+		if v, ok := repo.Issues[n]; ok && v.Discussion {
+			node = map[string]interface{}{"url": fmt.Sprintf("%s/%s/discussions/%d", f.URL, name, n)}
+		}
+		data = map[string]interface{}{"repository": map[string]interface{}{"discussion": node}}
+	case strings.Contains(q, "pullRequests("), strings.Contains(q, "issues(first:"):
+		pull := strings.Contains(q, "pullRequests(")
+		var nodes []interface{}
+		for _, n := range repo.Numbers() {
+			if v := repo.Issues[n]; v.Pull == pull {
+				nodes = append(nodes, gqlIssue(v))
+			}
+		}
+		key := "issues"
+		if pull {
+			key = "pullRequests"
+		}
+		data = map[string]interface{}{"repository": map[string]interface{}{key: f.connection(nodes, cursor)}}
+	case strings.Contains(q, "milestones("):
+		var nodes []interface{}
+		for _, v := range repo.Milestones {
+			nodes = append(nodes, map[string]interface{}{
+				"number": v.Number, "title": v.Title, "description": v.Description,
+				"state": v.State, "closed": v.Closed, "dueOn": v.DueOn,
+			})
+		}
+		data = map[string]interface{}{"repository": map[string]interface{}{"milestones": f.connection(nodes, cursor)}}
+	case strings.Contains(q, "labels(first: 100, after: $cursor)"):
+		var nodes []interface{}
+		for _, v := range repo.Labels {
+			nodes = append(nodes, map[string]interface{}{"name": v.Name, "color": v.Color, "description": v.Description})
+		}
+		data = map[string]interface{}{"repository": map[string]interface{}{"labels": f.connection(nodes, cursor)}}
+	case strings.Contains(q, "deleteIssue("):
+		if !strings.Contains(r.Header.Get("Authorization"), "dst-admin-token") {
+			f.json(w, http.StatusOK, map[string]interface{}{"errors": []interface{}{map[string]interface{}{"message": "must be an admin"}}})
+			return
+		}
+		repo, n, ok := f.parseNodeID(fmt.Sprint(input["issueId"]))
+		if !ok {
+			f.json(w, http.StatusOK, map[string]interface{}{"errors": []interface{}{map[string]interface{}{"message": "not found"}}})
+			return
+		}
+		delete(repo.Issues, n)
+		data = map[string]interface{}{"deleteIssue": map[string]interface{}{"clientMutationId": ""}}
+	default:
+		f.json(w, http.StatusOK, map[string]interface{}{"errors": []interface{}{map[string]interface{}{"message": "unsupported query: " + q}}})
+		return
+	}
+	f.json(w, http.StatusOK, map[string]interface{}{"data": data})
+}
+
+func gqlIssue(v *fakeIssue) map[string]interface{} {
+	var labels, assignees, comments []interface{}
+	for _, l := range v.Labels.Nodes {
+		labels = append(labels, map[string]interface{}{"name": l.Name})
+	}
+	for _, a := range v.Assignees.Nodes {
+		assignees = append(assignees, map[string]interface{}{"login": a.Login})
+	}
+	// the comments are of the first page, as comments(first: 100).
+	for i, c := range v.Comments.Nodes {
+		if i == 100 {
+			break
+		}
+		comments = append(comments, map[string]interface{}{
+			"author":    map[string]interface{}{"login": c.Author.Login, "avatarUrl": c.Author.AvatarURL},
+			"body":      c.Body,
+			"createdAt": c.CreatedAt,
+			"url":       c.URL,
+		})
+	}
+	return map[string]interface{}{
+		"title":     v.Title,
+		"body":      v.Body,
+		"createdAt": v.CreatedAt,
+		"updatedAt": v.UpdatedAt,
+		"closedAt":  v.ClosedAt,
+		"state":     v.State,
+		"number":    v.Number,
+		"closed":    v.Closed,
+		"url":       v.URL,
+		"milestone": map[string]interface{}{"number": v.Milestone.Number},
+		"author":    map[string]interface{}{"login": v.Author.Login, "avatarUrl": v.Author.AvatarURL},
+		"assignees": map[string]interface{}{"nodes": assignees, "totalCount": len(assignees)},
+		"labels":    map[string]interface{}{"nodes": labels, "totalCount": len(labels)},
+		"comments":  map[string]interface{}{"nodes": comments, "totalCount": len(v.Comments.Nodes)},
+	}
+}
+
+func (f *fakeGitHub) gqlDiscussion(d *Discussion) map[string]interface{} {
+	var comments []interface{}
+	for _, c := range d.Comments.Nodes {
+		comments = append(comments, f.gqlDiscussionComment(c))
+	}
+	return map[string]interface{}{
+		"id":        d.ID,
+		"number":    d.Number,
+		"title":     d.Title,
+		"body":      d.Body,
+		"url":       d.URL,
+		"createdAt": d.CreatedAt,
+		"updatedAt": d.UpdatedAt,
+		"author":    map[string]interface{}{"login": d.Author.Login, "avatarUrl": d.Author.AvatarURL},
+		"category":  map[string]interface{}{"name": d.Category.Name, "slug": d.Category.Slug},
+		"comments":  f.connection(comments, nil),
+	}
+}
+
+func (f *fakeGitHub) gqlDiscussionComment(c DiscussionComment) map[string]interface{} {
+	var replies []interface{}
+	for _, r := range c.Replies.Nodes {
+		replies = append(replies, gqlDiscussionReply(r))
+	}
+	return map[string]interface{}{
+		"id":        c.ID,
+		"author":    map[string]interface{}{"login": c.Author.Login, "avatarUrl": c.Author.AvatarURL},
+		"body":      c.Body,
+		"createdAt": c.CreatedAt,
+		"isAnswer":  c.IsAnswer,
+		"replies":   f.connection(replies, nil),
+	}
+}
+
+func gqlDiscussionReply(r DiscussionReply) map[string]interface{} {
+	return map[string]interface{}{
+		"author":    map[string]interface{}{"login": r.Author.Login, "avatarUrl": r.Author.AvatarURL},
+		"body":      r.Body,
+		"createdAt": r.CreatedAt,
+	}
+}
+
+func (f *fakeGitHub) discussion(id string) *Discussion {
+	for _, repo := range f.repos {
+		for _, d := range repo.Discussions {
+			if d.ID == id {
+				return d
+			}
+		}
+	}
+	return nil
+}
+
+// discussionComment returns the comment of the id and its discussion.
+func (f *fakeGitHub) discussionComment(id string) (*Discussion, *DiscussionComment) {
+	for _, repo := range f.repos {
+		for _, d := range repo.Discussions {
+			for i := range d.Comments.Nodes {
+				if d.Comments.Nodes[i].ID == id {
+					return d, &d.Comments.Nodes[i]
+				}
+			}
+		}
+	}
+	return nil, nil
+}
+
+func nodeID(repo string, n int) string {
+	return fmt.Sprintf("I_%s_%d", repo, n)
+}
+
+func (f *fakeGitHub) parseNodeID(id string) (*fakeRepo, int, bool) {
+	i := strings.LastIndex(id, "_")
+	if !strings.HasPrefix(id, "I_") || i < 0 {
+		return nil, 0, false
+	}
+	repo, ok := f.repos[id[2:i]]
+	if !ok {
+		return nil, 0, false
+	}
+	n, err := strconv.Atoi(id[i+1:])
+	if err != nil {
+		return nil, 0, false
+	}
+	_, ok = repo.Issues[n]
+	return repo, n, ok
+}
+
+var (
+	reRepo         = regexp.MustCompile(`^/repos/([^/]+/[^/]+)$`)
+	reLabels       = regexp.MustCompile(`^/repos/([^/]+/[^/]+)/labels$`)
+	reLabel        = regexp.MustCompile(`^/repos/([^/]+/[^/]+)/labels/([^/]+)$`)
+	reMilestones   = regexp.MustCompile(`^/repos/([^/]+/[^/]+)/milestones$`)
+	reIssues       = regexp.MustCompile(`^/repos/([^/]+/[^/]+)/issues$`)
+	reIssue        = regexp.MustCompile(`^/repos/([^/]+/[^/]+)/issues/(\d+)$`)
+	reIssueComment = regexp.MustCompile(`^/repos/([^/]+/[^/]+)/issues/(\d+)/comments$`)
+	reIssueLock    = regexp.MustCompile(`^/repos/([^/]+/[^/]+)/issues/(\d+)/lock$`)
+	reImports      = regexp.MustCompile(`^/repos/([^/]+/[^/]+)/import/issues$`)
+	reImport       = regexp.MustCompile(`^/repos/([^/]+/[^/]+)/import/issues/(\d+)$`)
+	reUser         = regexp.MustCompile(`^/users/([^/]+)$`)
+	reAppToken     = regexp.MustCompile(`^/app/installations/(\d+)/access_tokens$`)
+)
+
+func (f *fakeGitHub) serveREST(w http.ResponseWriter, r *http.Request) {
+	path := strings.TrimPrefix(r.URL.Path, "/api/v3")
+	route := func(re *regexp.Regexp, method string) []string {
+		if r.Method != method {
+			return nil
+		}
+		return re.FindStringSubmatch(path)
+	}
+
+	if f.serveRepos(w, r, path) {
+		return
+	}
+	if m := route(reRepo, "GET"); m != nil {
+		f.json(w, http.StatusOK, restRepo(m[1], f.repo(m[1])))
+		return
+	}
+	if m := route(reRepo, "PATCH"); m != nil {
+		var v struct {
+			Description   *string
+			HasIssues     *bool `json:"has_issues"`
+			Archived      *bool
+			DefaultBranch *string `json:"default_branch"`
+		}
+		json.NewDecoder(r.Body).Decode(&v)
+		repo := f.repo(m[1])
+		if v.DefaultBranch != nil {
+			if !hasString(repo.Branches, *v.DefaultBranch) {
+				f.error(w, http.StatusUnprocessableEntity, "Validation Failed")
+				return
+			}
+			repo.DefaultBranch = *v.DefaultBranch
+		}
+		if repo.Archived {
+			f.error(w, http.StatusForbidden, "Repository was archived so is read-only.")
+			return
+		}
+		if v.Description != nil {
+			repo.Description = *v.Description
+		}
+		if v.HasIssues != nil {
+			repo.IssuesDisabled = !*v.HasIssues
+		}
+		if v.Archived != nil {
+			repo.Archived = *v.Archived
+		}
+		f.json(w, http.StatusOK, map[string]interface{}{"name": strings.Split(m[1], "/")[1], "full_name": m[1]})
+		return
+	}
+	if m := route(reLabels, "POST"); m != nil {
+		var v Label
+		json.NewDecoder(r.Body).Decode(&v)
+		repo := f.repo(m[1])
+		for _, l := range repo.Labels {
+			if strings.EqualFold(l.Name, v.Name) {
+				f.error(w, http.StatusUnprocessableEntity, "Validation Failed")
+				return
+			}
+		}
+		repo.Labels = append(repo.Labels, v)
+		f.json(w, http.StatusCreated, map[string]interface{}{"name": v.Name, "color": v.Color, "description": v.Description})
+		return
+	}
+	if m := route(reLabel, "GET"); m != nil {
+		for _, l := range f.repo(m[1]).Labels {
+			if strings.EqualFold(l.Name, m[2]) {
+				f.json(w, http.StatusOK, map[string]interface{}{"name": l.Name, "color": l.Color})
+				return
+			}
+		}
+		f.error(w, http.StatusNotFound, "Not Found")
+		return
+	}
+	if m := route(reMilestones, "POST"); m != nil {
+		var v struct {
+			Title       string
+			State       string
+			Description string
+			DueOn       time.Time `json:"due_on"`
+		}
+		json.NewDecoder(r.Body).Decode(&v)
+		repo := f.repo(m[1])
+		ms := Milestone{Number: len(repo.Milestones) + 1, Title: v.Title, State: strings.ToUpper(v.State), Description: v.Description, DueOn: v.DueOn}
+		repo.Milestones = append(repo.Milestones, ms)
+		f.json(w, http.StatusCreated, map[string]interface{}{"number": ms.Number, "title": ms.Title})
+		return
+	}
+	if m := route(reIssues, "GET"); m != nil {
+		f.listIssues(w, r, m[1])
+		return
+	}
+	if m := route(reIssues, "POST"); m != nil {
+		var v fakeIssueRequest
+		json.NewDecoder(r.Body).Decode(&v)
+		repo := f.repo(m[1])
+		issue := &fakeIssue{}
+		issue.Title = v.Title
+		issue.Body = v.Body
+		issue.CreatedAt = time.Now()
+		issue.Author.Login = f.login(r)
+		issue.Milestone.Number = v.Milestone
+		for _, l := range v.Labels {
+			issue.Labels.Nodes = append(issue.Labels.Nodes, struct{ Name string }{l})
+		}
+		if v.Assignee != "" {
+			issue.Assignees.Nodes = append(issue.Assignees.Nodes, struct{ Login string }{v.Assignee})
+		}
+		repo.AddIssue(issue)
+		f.json(w, http.StatusCreated, restIssue(m[1], issue))
+		return
+	}
+	if m := route(reIssue, "GET"); m != nil {
+		n, _ := strconv.Atoi(m[2])
+		v, ok := f.repo(m[1]).Issues[n]
+		switch {
+		case !ok, v.Discussion, v.Importing:
+			f.error(w, http.StatusNotFound, "Not Found")
+		case v.Deleted:
+			f.error(w, http.StatusGone, "This issue was deleted")
+		case v.TransferredTo != "":
+			w.Header().Set("Location", f.URL+"/api/v3/repos/"+v.TransferredTo)
+			f.error(w, http.StatusMovedPermanently, "Moved Permanently")
+		case v.MovedTo != "":
+			f.json(w, http.StatusOK, restIssue(v.MovedTo, v))
+		default:
+			f.json(w, http.StatusOK, restIssue(m[1], v))
+		}
+		return
+	}
+	if m := route(reIssue, "PATCH"); m != nil {
+		n, _ := strconv.Atoi(m[2])
+		v, ok := f.repo(m[1]).Issues[n]
+		if !ok {
+			f.error(w, http.StatusNotFound, "Not Found")
+			return
+		}
+		var req fakeIssueRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		if req.State != "" {
+			v.Closed = req.State == "closed"
+			v.State = strings.ToUpper(req.State)
+		}
+		f.json(w, http.StatusOK, restIssue(m[1], v))
+		return
+	}
+	if m := route(reIssueComment, "POST"); m != nil {
+		n, _ := strconv.Atoi(m[2])
+		v, ok := f.repo(m[1]).Issues[n]
+		if !ok {
+			f.error(w, http.StatusNotFound, "Not Found")
+			return
+		}
+		var c IssueComment
+		json.NewDecoder(r.Body).Decode(&c)
+		c.CreatedAt = time.Now()
+		c.Author.Login = f.login(r)
+		v.Comments.Nodes = append(v.Comments.Nodes, c)
+		f.json(w, http.StatusCreated, map[string]interface{}{"body": c.Body})
+		return
+	}
+	if m := route(reIssueLock, "PUT"); m != nil {
+		n, _ := strconv.Atoi(m[2])
+		v, ok := f.repo(m[1]).Issues[n]
+		if !ok {
+			f.error(w, http.StatusNotFound, "Not Found")
+			return
+		}
+		var req struct {
+			LockReason string `json:"lock_reason"`
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+		v.Locked = true
+		v.LockReason = req.LockReason
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	if m := route(reImports, "POST"); m != nil {
+		f.importIssue(w, r, m[1])
+		return
+	}
+	if m := route(reImport, "GET"); m != nil {
+		id, _ := strconv.Atoi(m[2])
+		f.importStatus(w, m[1], id)
+		return
+	}
+	if m := route(reUser, "GET"); m != nil {
+		switch {
+		case f.orgs[m[1]]:
+			f.json(w, http.StatusOK, map[string]interface{}{"login": m[1], "type": "Organization"})
+		case f.users[m[1]]:
+			f.json(w, http.StatusOK, map[string]interface{}{"login": m[1], "type": "User"})
+		default:
+			f.error(w, http.StatusNotFound, "Not Found")
+		}
+		return
+	}
+	if r.Method == "GET" && path == "/user" {
+		f.json(w, http.StatusOK, map[string]interface{}{"login": f.authLogin(r), "type": "User"})
+		return
+	}
+	if f.serveProjects(w, r, path) {
+		return
+	}
+
+	f.error(w, http.StatusNotFound, "Not Found")
+}
+
+// fakeIssueRequest is the body of the requests creating and editing issues.
+type fakeIssueRequest struct {
+	Title     string
+	Body      string
+	Labels    []string
+	Assignee  string
+	State     string
+	Milestone int
+}
+
+func restIssue(repo string, v *fakeIssue) map[string]interface{} {
+	var labels []interface{}
+	for _, l := range v.Labels.Nodes {
+		labels = append(labels, map[string]interface{}{"name": l.Name})
+	}
+	i := map[string]interface{}{
+		"id":             issueID(repo, v.Number),
+		"number":         v.Number,
+		"node_id":        nodeID(repo, v.Number),
+		"title":          v.Title,
+		"body":           v.Body,
+		"state":          strings.ToLower(v.State),
+		"locked":         v.Locked,
+		"comments":       len(v.Comments.Nodes),
+		"labels":         labels,
+		"repository_url": "/api/v3/repos/" + repo,
+		"html_url":       "/" + repo + "/issues/" + strconv.Itoa(v.Number),
+	}
+	if v.Pull {
+		i["pull_request"] = map[string]interface{}{"url": "/api/v3/repos/" + repo + "/pulls/" + strconv.Itoa(v.Number)}
+	}
+	return i
+}
+
+func (f *fakeGitHub) listIssues(w http.ResponseWriter, r *http.Request, name string) {
+	repo := f.repo(name)
+	ns := repo.Numbers()
+	// the imported issues keep the creation times, so the order by them
+	// is not the one by the numbers.
+	if r.URL.Query().Get("sort") == "created" {
+		sort.SliceStable(ns, func(i, j int) bool {
+			return repo.Issues[ns[i]].CreatedAt.Before(repo.Issues[ns[j]].CreatedAt)
+		})
+	}
+	if r.URL.Query().Get("direction") != "asc" {
+		for i, j := 0, len(ns)-1; i < j; i, j = i+1, j-1 {
+			ns[i], ns[j] = ns[j], ns[i]
+		}
+	}
+	perPage := f.PageSize
+	if n, err := strconv.Atoi(r.URL.Query().Get("per_page")); err == nil && n < perPage {
+		perPage = n
+	}
+	page := 1
+	if n, err := strconv.Atoi(r.URL.Query().Get("page")); err == nil && n > 0 {
+		page = n
+	}
+	from := (page - 1) * perPage
+	to := from + perPage
+	if from > len(ns) {
+		from = len(ns)
+	}
+	if to >= len(ns) {
+		to = len(ns)
+	} else {
+		q := r.URL.Query()
+		q.Set("page", strconv.Itoa(page+1))
+		w.Header().Set("Link", fmt.Sprintf(`<%s%s?%s>; rel="next"`, f.URL, r.URL.Path, q.Encode()))
+	}
+	var issues []interface{}
+	for _, n := range ns[from:to] {
+		issues = append(issues, restIssue(name, repo.Issues[n]))
+	}
+	f.json(w, http.StatusOK, issues)
+}
+
+// importIssue creates the issue at once, so that the numbers follow the
+// order of the requests, and its status turns imported after the polls.
+func (f *fakeGitHub) importIssue(w http.ResponseWriter, r *http.Request, name string) {
+	var req IssueImportRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		f.error(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	repo := f.repo(name)
+	repo.Imports = append(repo.Imports, &req)
+	if f.FailImportOf != "" && req.IssueImport.Title == f.FailImportOf {
+		id := len(f.imports) + 1
+		f.imports[id] = &fakeImport{repo: name, failed: true}
+		f.json(w, http.StatusAccepted, map[string]interface{}{
+			"id":     id,
+			"status": "pending",
+			"url":    fmt.Sprintf("%s/api/v3/repos/%s/import/issues/%d", f.URL, name, id),
+		})
+		return
+	}
+
+	issue := &fakeIssue{}
+	issue.Title = req.IssueImport.Title
+	issue.Body = req.IssueImport.Body
+	issue.Author.Login = f.login(r)
+	if req.IssueImport.CreatedAt != nil {
+		issue.CreatedAt = *req.IssueImport.CreatedAt
+	}
+	if req.IssueImport.Closed != nil {
+		issue.Closed = *req.IssueImport.Closed
+	}
+	if req.IssueImport.Milestone != nil {
+		issue.Milestone.Number = *req.IssueImport.Milestone
+	}
+	if req.IssueImport.Assignee != nil {
+		issue.Assignees.Nodes = append(issue.Assignees.Nodes, struct{ Login string }{*req.IssueImport.Assignee})
+	}
+	for _, l := range req.IssueImport.Labels {
+		issue.Labels.Nodes = append(issue.Labels.Nodes, struct{ Name string }{l})
+	}
+	for _, c := range req.Comments {
+		ic := IssueComment{Body: c.Body}
+		if c.CreatedAt != nil {
+			ic.CreatedAt = *c.CreatedAt
+		}
+		issue.Comments.Nodes = append(issue.Comments.Nodes, ic)
+	}
+	issue.Importing = f.ImportPolls > 0
+	repo.AddIssue(issue)
+
+	id := len(f.imports) + 1
+	f.imports[id] = &fakeImport{repo: name, number: issue.Number}
+	f.json(w, http.StatusAccepted, map[string]interface{}{
+		"id":     id,
+		"status": "pending",
+		"url":    fmt.Sprintf("%s/api/v3/repos/%s/import/issues/%d", f.URL, name, id),
+	})
+}
+
+func (f *fakeGitHub) importStatus(w http.ResponseWriter, name string, id int) {
+	v, ok := f.imports[id]
+	if !ok || v.repo != name {
+		f.error(w, http.StatusNotFound, "Not Found")
+		return
+	}
+	res := map[string]interface{}{"id": id, "status": "pending"}
+	switch {
+	case f.FailImports, v.failed:
+		res["status"] = "failed"
+		res["errors"] = []interface{}{map[string]interface{}{
+			"location": "/issue/title", "resource": "Issue", "field": "title", "value": "", "code": "missing_field",
+		}}
+	case v.polls >= f.ImportPolls:
+		f.repo(name).Issues[v.number].Importing = false
+		res["status"] = "imported"
+		res["issue_url"] = fmt.Sprintf("%s/api/v3/repos/%s/issues/%d", f.URL, name, v.number)
+	default:
+		v.polls++
+	}
+	f.json(w, http.StatusOK, res)
+}
@@ -0,0 +1,223 @@
+package mover
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/shurcooL/githubv4"
+)
+
+const (
+	filterDateLayout = "2006-01-02"
+	filterStateAll   = "all"
+	filterStateOpen  = "open"
+	filterStateClose = "closed"
+	milestoneAny     = "*"
+	milestoneNone    = "none"
+)
+
+// Filter narrows down the issues and pull requests to move.
+// Conditions supported by the GraphQL API are applied at query time,
+// and every condition is checked again on the client side.
+//
+// Numbers excluded by a filter are treated like any other gap in the
+// source numbering: DoIssues fills them with dummies so that the numbers
+// of the moved issues stay aligned with the source.
+type Filter struct {
+	State     string
+	Labels    []string
+	Milestone string
+	Author    string
+	Since     time.Time
+	Until     time.Time
+	From      int
+	To        int
+}
+
+func NewFilter(state, labels, milestone, author, since, until, numbers string) (*Filter, error) {
+	f := &Filter{
+		State:     strings.ToLower(state),
+		Milestone: milestone,
+		Author:    author,
+	}
+
+	switch f.State {
+	case "", filterStateAll:
+		f.State = filterStateAll
+	case filterStateOpen, filterStateClose:
+	default:
+		return nil, fmt.Errorf("invalid state filter: %s", state)
+	}
+
+	for _, v := range strings.Split(labels, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			f.Labels = append(f.Labels, v)
+		}
+	}
+
+	if milestone != "" && milestone != milestoneAny && milestone != milestoneNone {
+		if _, err := strconv.Atoi(milestone); err != nil {
+			return nil, fmt.Errorf("invalid milestone filter: %s", milestone)
+		}
+	}
+
+	var err error
+	if since != "" {
+		if f.Since, err = time.Parse(filterDateLayout, since); err != nil {
+			return nil, fmt.Errorf("invalid since filter: %s", since)
+		}
+	}
+	if until != "" {
+		if f.Until, err = time.Parse(filterDateLayout, until); err != nil {
+			return nil, fmt.Errorf("invalid until filter: %s", until)
+		}
+	}
+
+	if numbers != "" {
+		r := strings.SplitN(numbers, "-", 2)
+		if f.From, err = strconv.Atoi(r[0]); err != nil {
+			return nil, fmt.Errorf("invalid numbers filter: %s", numbers)
+		}
+		f.To = f.From
+		if len(r) == 2 {
+			if r[1] == "" {
+				f.To = 0
+			} else if f.To, err = strconv.Atoi(r[1]); err != nil {
+				return nil, fmt.Errorf("invalid numbers filter: %s", numbers)
+			}
+		}
+		if f.To > 0 && f.To < f.From {
+			return nil, fmt.Errorf("invalid numbers filter: %s", numbers)
+		}
+	}
+
+	return f, nil
+}
+
+// IsEmpty reports whether the filter lets every issue through.
+func (f *Filter) IsEmpty() bool {
+	return f == nil || (f.State == filterStateAll && len(f.Labels) == 0 && f.Milestone == "" &&
+		f.Author == "" && f.Since.IsZero() && f.Until.IsZero() && f.From == 0 && f.To == 0)
+}
+
+func (f *Filter) issueFilters() *githubv4.IssueFilters {
+	if f.IsEmpty() {
+		return nil
+	}
+
+	in := &githubv4.IssueFilters{}
+	switch f.State {
+	case filterStateOpen:
+		in.States = &[]githubv4.IssueState{githubv4.IssueStateOpen}
+	case filterStateClose:
+		in.States = &[]githubv4.IssueState{githubv4.IssueStateClosed}
+	}
+	if len(f.Labels) > 0 {
+		in.Labels = f.labels()
+	}
+	if f.Milestone != "" && f.Milestone != milestoneNone {
+		in.Milestone = githubv4.NewString(githubv4.String(f.Milestone))
+	}
+	if f.Author != "" {
+		in.CreatedBy = githubv4.NewString(githubv4.String(f.Author))
+	}
+	if !f.Since.IsZero() {
+		// since means "updated since", which every issue created since also is.
+		in.Since = githubv4.NewDateTime(githubv4.DateTime{Time: f.Since})
+	}
+
+	return in
+}
+
+func (f *Filter) pullRequestStates() *[]githubv4.PullRequestState {
+	if f == nil {
+		return nil
+	}
+	switch f.State {
+	case filterStateOpen:
+		return &[]githubv4.PullRequestState{githubv4.PullRequestStateOpen}
+	case filterStateClose:
+		return &[]githubv4.PullRequestState{githubv4.PullRequestStateClosed, githubv4.PullRequestStateMerged}
+	}
+	return nil
+}
+
+func (f *Filter) labels() *[]githubv4.String {
+	if f == nil || len(f.Labels) == 0 {
+		return nil
+	}
+	var labels []githubv4.String
+	for _, v := range f.Labels {
+		labels = append(labels, githubv4.String(v))
+	}
+	return &labels
+}
+
+// Match reports whether the issue satisfies every condition of the filter.
+func (f *Filter) Match(v *Issue) bool {
+	if f.IsEmpty() {
+		return true
+	}
+
+	switch f.State {
+	case filterStateOpen:
+		if v.Closed {
+			return false
+		}
+	case filterStateClose:
+		if !v.Closed {
+			return false
+		}
+	}
+
+	// the labels are matched case-insensitively, as GitHub does.
+	if len(f.Labels) > 0 {
+		found := false
+		for _, l := range v.Labels.Nodes {
+			for _, ll := range f.Labels {
+				if strings.EqualFold(l.Name, ll) {
+					found = true
+				}
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+
+	switch f.Milestone {
+	case "":
+	case milestoneAny:
+		if v.Milestone.Number == 0 {
+			return false
+		}
+	case milestoneNone:
+		if v.Milestone.Number != 0 {
+			return false
+		}
+	default:
+		if strconv.Itoa(v.Milestone.Number) != f.Milestone {
+			return false
+		}
+	}
+
+	if f.Author != "" && v.Author.Login != f.Author {
+		return false
+	}
+	if !f.Since.IsZero() && v.CreatedAt.Before(f.Since) {
+		return false
+	}
+	if !f.Until.IsZero() && !v.CreatedAt.Before(f.Until) {
+		return false
+	}
+	if f.From > 0 && v.Number < f.From {
+		return false
+	}
+	if f.To > 0 && v.Number > f.To {
+		return false
+	}
+
+	return true
+}
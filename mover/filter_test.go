@@ -0,0 +1,84 @@
+package mover
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFilterMatch(t *testing.T) {
+	issue := func(number int, closed bool, label string, milestone int, author string, created string) *Issue {
+		v := &Issue{Number: number, Closed: closed}
+		if label != "" {
+			v.Labels.Nodes = append(v.Labels.Nodes, struct{ Name string }{label})
+		}
+		v.Milestone.Number = milestone
+		v.Author.Login = author
+		v.CreatedAt, _ = time.Parse(filterDateLayout, created)
+		return v
+	}
+	open := issue(10, false, "Bug", 0, "alice", "2019-01-01")
+	closed := issue(20, true, "help wanted", 2, "bob", "2019-06-30")
+
+	tests := []struct {
+		state, labels, milestone, author, since, until, numbers string
+		open, closed                                            bool
+	}{
+		{"", "", "", "", "", "", "", true, true},
+		{"all", "", "", "", "", "", "", true, true},
+		{"open", "", "", "", "", "", "", true, false},
+		{"Closed", "", "", "", "", "", "", false, true},
+		{"", "bug", "", "", "", "", "", true, false},
+		{"", "BUG, Help Wanted", "", "", "", "", "", true, true},
+		{"", "question", "", "", "", "", "", false, false},
+		{"", "", "*", "", "", "", "", false, true},
+		{"", "", "none", "", "", "", "", true, false},
+		{"", "", "2", "", "", "", "", false, true},
+		{"", "", "1", "", "", "", "", false, false},
+		{"", "", "", "bob", "", "", "", false, true},
+		{"", "", "", "", "2019-06-30", "", "", false, true},
+		{"", "", "", "", "", "2019-06-30", "", true, false},
+		{"", "", "", "", "2019-01-01", "2019-07-01", "", true, true},
+		{"", "", "", "", "", "", "10", true, false},
+		{"", "", "", "", "", "", "11-20", false, true},
+		{"", "", "", "", "", "", "15-", false, true},
+		{"", "", "", "", "", "", "1-10", true, false},
+		{"open", "bug", "none", "alice", "2019-01-01", "2019-01-02", "1-10", true, false},
+	}
+	for _, tt := range tests {
+		f, err := NewFilter(tt.state, tt.labels, tt.milestone, tt.author, tt.since, tt.until, tt.numbers)
+		if err != nil {
+			t.Errorf("%+v: %s", tt, err)
+			continue
+		}
+		if got := f.Match(open); got != tt.open {
+			t.Errorf("%+v: open issue %t", tt, got)
+		}
+		if got := f.Match(closed); got != tt.closed {
+			t.Errorf("%+v: closed issue %t", tt, got)
+		}
+	}
+}
+
+func TestNewFilterInvalid(t *testing.T) {
+	tests := []struct {
+		state, milestone, since, until, numbers string
+	}{
+		{state: "merged"},
+		{milestone: "v1"},
+		{since: "2019/01/01"},
+		{until: "yesterday"},
+		{numbers: "a-b"},
+		{numbers: "10-x"},
+		{numbers: "20-10"},
+	}
+	for _, tt := range tests {
+		if _, err := NewFilter(tt.state, "", tt.milestone, "", tt.since, tt.until, tt.numbers); err == nil {
+			t.Errorf("no error: %+v", tt)
+		}
+	}
+
+	f, err := NewFilter("", "", "", "", "", "", "")
+	if err != nil || !f.IsEmpty() || f.issueFilters() != nil {
+		t.Errorf("empty filter: %+v, %v", f, err)
+	}
+}
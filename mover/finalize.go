@@ -0,0 +1,70 @@
+package mover
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"text/template"
+
+	"github.com/google/go-github/v32/github"
+)
+
+const defaultMovedDescription = "Moved to {{.URL}}"
+
+// parseMovedDescription parses the template of the SRC description set
+// by the finalize, which has the Backlink of the DST repository.
+func parseMovedDescription(text string) (*template.Template, error) {
+	tmpl, err := template.New("moved_description").Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, err
+	}
+	if err := tmpl.Execute(&bytes.Buffer{}, Backlink{}); err != nil {
+		return nil, err
+	}
+	return tmpl, nil
+}
+
+// Finalize verifies DST with the fetched SRC, and only when nothing differs,
+// disables the issues of SRC, points its description at DST and archives it.
+// confirm has to be the name of SRC, as the archive is hard to undo.
+func (t *Transfer) Finalize(ctx context.Context, confirm string) error {
+	if t.SRC == nil || t.DST == nil {
+		return fmt.Errorf("finalize is only for GitHub repositories")
+	}
+	if confirm != t.SRC.Repo() {
+		return fmt.Errorf("finalize needs -confirm=%s", t.SRC.Repo())
+	}
+	if err := t.Verify(ctx); err != nil {
+		return fmt.Errorf("finalize needs a clean verify: %s", err)
+	}
+
+	edit := &github.Repository{}
+	if t.DisableIssues {
+		edit.HasIssues = github.Bool(false)
+	}
+	if t.MovedDescription != nil {
+		var buf bytes.Buffer
+		b := Backlink{URL: webURL(t.DST.Endpoint) + "/" + t.DST.Repo(), Dst: t.DST.Repo()}
+		if err := t.MovedDescription.Execute(&buf, b); err != nil {
+			return err
+		}
+		edit.Description = github.String(buf.String())
+	}
+	if edit.HasIssues != nil || edit.Description != nil {
+		if _, _, err := t.SRC.REST.Repositories.Edit(ctx, t.SRC.Owner, t.SRC.Name, edit); err != nil {
+			return err
+		}
+		logf(t.Log, "updated repository: %s\n", t.SRC.Repo())
+	}
+
+	// an archived repository cannot be edited, so it is the last.
+	if t.ArchiveSrc {
+		archive := &github.Repository{Archived: github.Bool(true)}
+		if _, _, err := t.SRC.REST.Repositories.Edit(ctx, t.SRC.Owner, t.SRC.Name, archive); err != nil {
+			return err
+		}
+		logf(t.Log, "archived repository: %s\n", t.SRC.Repo())
+	}
+
+	return nil
+}
@@ -0,0 +1,72 @@
+package mover
+
+import (
+	"context"
+	"os"
+	"testing"
+)
+
+func TestFinalize(t *testing.T) {
+	f := newFakeGitHub()
+	defer f.Close()
+	f.AddUser("alice-dst")
+	seedSrc(f)
+	dir := testDir(t)
+	defer os.RemoveAll(dir)
+
+	o := testOptions(f, dir)
+	o.Backlink = true
+	o.CloseSrc = true
+	tr, err := New(context.Background(), o, testShared())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := tr.Exec(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	finalize := func(confirm string) error {
+		tr, err := New(context.Background(), o, testShared())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := tr.Fetch(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+		return tr.Finalize(context.Background(), confirm)
+	}
+	src := f.Repo(testSrc)
+
+	if err := finalize("foo/other"); err == nil {
+		t.Error("finalize without the confirmation")
+	}
+	title := f.Repo(testDst).Issues[2].Title
+	f.Repo(testDst).Issues[2].Title = "changed"
+	if err := finalize(testSrc); err == nil {
+		t.Error("finalize with a diff")
+	}
+	if src.Archived || src.IssuesDisabled {
+		t.Errorf("src is finalized with a diff: %#v", src)
+	}
+
+	// the back-linked issues are verified without the moved notices.
+	f.Repo(testDst).Issues[2].Title = title
+	if err := finalize(testSrc); err != nil {
+		t.Fatal(err)
+	}
+	if !src.Archived || !src.IssuesDisabled {
+		t.Errorf("src archived %t, issues disabled %t", src.Archived, src.IssuesDisabled)
+	}
+	if want := "Moved to " + f.URL + "/" + testDst; src.Description != want {
+		t.Errorf("src description: %q, want %q", src.Description, want)
+	}
+
+	o.Confirm = "foo/other"
+	if err := o.Validate(CmdFinalize); err == nil {
+		t.Error("validate without the confirmation")
+	}
+	o.Confirm = testSrc
+	if err := o.Validate(CmdFinalize); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,112 @@
+package mover
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/shurcooL/githubv4"
+)
+
+const (
+	GapFiltered    = "filtered"
+	GapDeleted     = "deleted"
+	GapTransferred = "transferred"
+	GapDiscussion  = "discussion"
+	GapUnknown     = "unknown"
+)
+
+// Gap is a source number that is neither a moved issue nor a moved pull request.
+type Gap struct {
+	Number  int
+	Reason  string
+	MovedTo string
+}
+
+func (g *Gap) String() string {
+	switch g.Reason {
+	case GapFiltered:
+		return fmt.Sprintf("#%d was excluded by the filter.", g.Number)
+	case GapDeleted:
+		return fmt.Sprintf("#%d was deleted.", g.Number)
+	case GapTransferred:
+		if g.MovedTo != "" {
+			return fmt.Sprintf("#%d was transferred to %s.", g.Number, g.MovedTo)
+		}
+		return fmt.Sprintf("#%d was transferred to another repository.", g.Number)
+	case GapDiscussion:
+		if g.MovedTo != "" {
+			return fmt.Sprintf("#%d is a discussion: %s", g.Number, g.MovedTo)
+		}
+		return fmt.Sprintf("#%d is a discussion.", g.Number)
+	}
+	return fmt.Sprintf("#%d is neither an issue nor a pull request.", g.Number)
+}
+
+type IssueOrPullRequestQuery struct {
+	Repository struct {
+		IssueOrPullRequest *struct {
+			Typename string `graphql:"__typename"`
+		} `graphql:"issueOrPullRequest(number: $number)"`
+	} `graphql:"repository(owner: $owner, name: $repo)"`
+}
+
+type DiscussionQuery struct {
+	Repository struct {
+		Discussion *struct {
+			URL string
+		} `graphql:"discussion(number: $number)"`
+	} `graphql:"repository(owner: $owner, name: $repo)"`
+}
+
+// findGap finds out why the source number n has no issue to move.
+// Errors are not fatal here: the number ends up as an unknown gap.
+func (t *Transfer) findGap(ctx context.Context, n int) *Gap {
+	g := &Gap{Number: n, Reason: GapUnknown}
+	if t.SRC == nil {
+		return g
+	}
+	v := map[string]interface{}{
+		"owner":  githubv4.String(t.SRC.Owner),
+		"repo":   githubv4.String(t.SRC.Name),
+		"number": githubv4.Int(n),
+	}
+
+	var iq IssueOrPullRequestQuery
+	if err := t.SRC.Client.Query(ctx, &iq, v); err == nil && iq.Repository.IssueOrPullRequest != nil {
+		g.Reason = GapFiltered
+		return g
+	}
+
+	// discussions are not in the schema of older GitHub Enterprise.
+	var dq DiscussionQuery
+	if err := t.SRC.Client.Query(ctx, &dq, v); err == nil && dq.Repository.Discussion != nil {
+		g.Reason = GapDiscussion
+		g.MovedTo = dq.Repository.Discussion.URL
+		return g
+	}
+
+	issue, resp, err := t.SRC.REST.Issues.Get(ctx, t.SRC.Owner, t.SRC.Name, n)
+	if err != nil {
+		if resp == nil {
+			return g
+		}
+		switch resp.StatusCode {
+		case http.StatusGone:
+			g.Reason = GapDeleted
+		case http.StatusMovedPermanently:
+			g.Reason = GapTransferred
+			g.MovedTo = resp.Header.Get("Location")
+		}
+		return g
+	}
+
+	// the redirect of a transferred issue is followed to its new repository.
+	if !strings.HasSuffix(strings.ToLower(issue.GetRepositoryURL()), strings.ToLower("/repos/"+t.SRC.Owner+"/"+t.SRC.Name)) {
+		g.Reason = GapTransferred
+		g.MovedTo = issue.GetHTMLURL()
+	}
+
+	return g
+}
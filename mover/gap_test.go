@@ -0,0 +1,56 @@
+package mover
+
+import (
+	"context"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestFindGap(t *testing.T) {
+	f := newFakeGitHub()
+	defer f.Close()
+	other := f.Repo("foo/other")
+	other.AddIssue(testIssue("transferred", false))
+	other.AddIssue(testIssue("moved", false))
+
+	src := f.Repo(testSrc)
+	src.AddIssue(testIssue("first", false))
+	deleted := testIssue("deleted", false)
+	deleted.Deleted = true
+	src.AddIssue(deleted)
+	transferred := testIssue("transferred", false)
+	transferred.TransferredTo = "foo/other/issues/1"
+	src.AddIssue(transferred)
+	moved := testIssue("moved", false)
+	moved.MovedTo = "foo/other"
+	src.AddIssue(moved)
+	discussion := testIssue("discussion", false)
+	discussion.Discussion = true
+	src.AddIssue(discussion)
+	dir := testDir(t)
+	defer os.RemoveAll(dir)
+
+	tr, err := New(context.Background(), testOptions(f, dir), testShared())
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		n      int
+		reason string
+		want   string
+	}{
+		{1, GapFiltered, "#1 was excluded by the filter."},
+		{2, GapDeleted, "#2 was deleted."},
+		{3, GapTransferred, "#3 was transferred to /foo/other/issues/1."},
+		{4, GapTransferred, "#4 was transferred to /foo/other/issues/4."},
+		{5, GapDiscussion, "#5 is a discussion: " + f.URL + "/" + testSrc + "/discussions/5"},
+		{6, GapUnknown, "#6 is neither"},
+	}
+	for _, tt := range tests {
+		g := tr.findGap(context.Background(), tt.n)
+		if g.Reason != tt.reason || !strings.HasPrefix(g.String(), tt.want) {
+			t.Errorf("#%d: %s %q, want %s %q", tt.n, g.Reason, g.String(), tt.reason, tt.want)
+		}
+	}
+}
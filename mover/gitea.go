@@ -0,0 +1,369 @@
+package mover
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	giteaPerPage    = 50
+	giteaLabelColor = "ededed"
+)
+
+// Gitea is the Destination of a Gitea or Forgejo repository. Gitea has no
+// import api, so the issues are created, and then commented and closed
+// with the original times as their update times. Gitea always creates
+// issues at the current time, so New moves to Gitea in the create mode,
+// which keeps the original times in the bodies.
+type Gitea struct {
+	Owner    string
+	Name     string
+	Endpoint string
+	Token    string
+	Client   *http.Client
+	Users    *UserCache
+	// Log is where the progress is printed, the standard output when nil.
+	Log io.Writer
+
+	labels     map[string]int64
+	milestones map[int]int64
+}
+
+type giteaIssue struct {
+	Number int64  `json:"number"`
+	Title  string `json:"title"`
+}
+
+type giteaIssueOption struct {
+	Title     string   `json:"title,omitempty"`
+	Body      string   `json:"body,omitempty"`
+	Assignees []string `json:"assignees,omitempty"`
+	Labels    []int64  `json:"labels,omitempty"`
+	Milestone int64    `json:"milestone,omitempty"`
+}
+
+type giteaEditOption struct {
+	State   string     `json:"state,omitempty"`
+	Updated *time.Time `json:"updated_at,omitempty"`
+}
+
+type giteaCommentOption struct {
+	Body    string     `json:"body"`
+	Updated *time.Time `json:"updated_at,omitempty"`
+}
+
+func (g *Gitea) Repo() string {
+	return g.Owner + "/" + g.Name
+}
+
+func (g *Gitea) CreateLabel(ctx context.Context, v Label) error {
+	input := map[string]string{
+		"name":        v.Name,
+		"color":       "#" + strings.TrimPrefix(v.Color, "#"),
+		"description": v.Description,
+	}
+	if err := g.do(ctx, "POST", g.repoPath("labels"), input, nil); err != nil {
+		return err
+	}
+	g.labels = nil
+	return nil
+}
+
+// CreateMilestone creates the milestone, and returns its position among
+// the milestones as the number of milestoneID.
+func (g *Gitea) CreateMilestone(ctx context.Context, v Milestone) (int, error) {
+	input := map[string]interface{}{
+		"title":       v.Title,
+		"description": v.Description,
+		"state":       strings.ToLower(v.State),
+	}
+	if !v.DueOn.IsZero() {
+		input["due_on"] = v.DueOn
+	}
+	var got struct {
+		ID int64 `json:"id"`
+	}
+	if err := g.do(ctx, "POST", g.repoPath("milestones"), input, &got); err != nil {
+		return 0, err
+	}
+	g.milestones = nil
+	if err := g.loadMilestones(ctx); err != nil {
+		return 0, err
+	}
+	for n, id := range g.milestones {
+		if id == got.ID {
+			return n, nil
+		}
+	}
+	return 0, fmt.Errorf("gitea milestone not found: %s", v.Title)
+}
+
+// ImportIssue creates the issue of the import request, since Gitea has no
+// import api. The number is always returned.
+func (g *Gitea) ImportIssue(ctx context.Context, input *IssueImportRequest, wait bool) (int, error) {
+	v := input.IssueImport
+	opt := giteaIssueOption{Title: v.Title, Body: v.Body}
+	if v.Assignee != nil {
+		opt.Assignees = []string{*v.Assignee}
+	}
+	var err error
+	if opt.Labels, err = g.labelIDs(ctx, v.Labels); err != nil {
+		return 0, err
+	}
+	if v.Milestone != nil {
+		if opt.Milestone, err = g.milestoneID(ctx, *v.Milestone); err != nil {
+			return 0, err
+		}
+	}
+	var comments []giteaCommentOption
+	for _, c := range input.Comments {
+		comments = append(comments, giteaCommentOption{Body: c.Body, Updated: c.CreatedAt})
+	}
+	closed := v.Closed != nil && *v.Closed
+	return g.createIssue(ctx, opt, comments, closed, v.ClosedAt)
+}
+
+func (g *Gitea) CreateIssue(ctx context.Context, input *IssueAndCommentsRequest) (int, error) {
+	v := input.Issue
+	opt := giteaIssueOption{Title: v.GetTitle(), Body: v.GetBody()}
+	if v.Assignee != nil {
+		opt.Assignees = []string{*v.Assignee}
+	}
+	if v.Labels != nil {
+		var err error
+		if opt.Labels, err = g.labelIDs(ctx, *v.Labels); err != nil {
+			return 0, err
+		}
+	}
+	if v.Milestone != nil {
+		var err error
+		if opt.Milestone, err = g.milestoneID(ctx, *v.Milestone); err != nil {
+			return 0, err
+		}
+	}
+	var comments []giteaCommentOption
+	for _, c := range input.Comments {
+		comments = append(comments, giteaCommentOption{Body: c.GetBody(), Updated: c.CreatedAt})
+	}
+	return g.createIssue(ctx, opt, comments, v.GetState() == "closed", nil)
+}
+
+func (g *Gitea) createIssue(ctx context.Context, opt giteaIssueOption, comments []giteaCommentOption, closed bool, closedAt *time.Time) (int, error) {
+	var issue giteaIssue
+	if err := g.do(ctx, "POST", g.repoPath("issues"), opt, &issue); err != nil {
+		return 0, err
+	}
+	n := int(issue.Number)
+	logf(g.Log, "created issue: #%d - %s\n", n, issue.Title)
+
+	for _, c := range comments {
+		if err := g.do(ctx, "POST", g.repoPath(fmt.Sprintf("issues/%d/comments", n)), c, nil); err != nil {
+			return n, err
+		}
+	}
+
+	if closed {
+		edit := giteaEditOption{State: "closed"}
+		if closedAt != nil && !closedAt.IsZero() {
+			edit.Updated = closedAt
+		}
+		if err := g.do(ctx, "PATCH", g.repoPath(fmt.Sprintf("issues/%d", n)), edit, nil); err != nil {
+			return n, err
+		}
+		logf(g.Log, "closed issue: #%d\n", n)
+	}
+
+	return n, nil
+}
+
+func (g *Gitea) LastNumber(ctx context.Context) (int, error) {
+	var issues []giteaIssue
+	q := url.Values{"state": {"all"}, "limit": {"1"}}
+	if err := g.do(ctx, "GET", g.repoPath("issues")+"?"+q.Encode(), nil, &issues); err != nil {
+		return 0, err
+	}
+	if len(issues) == 0 {
+		return 0, nil
+	}
+	return int(issues[0].Number), nil
+}
+
+// LockIssue does nothing, since the api of Gitea cannot lock issues.
+func (g *Gitea) LockIssue(ctx context.Context, n int, reason string) error {
+	logf(g.Log, "skipped lock: #%d - not supported by gitea\n", n)
+	return nil
+}
+
+func (g *Gitea) EnsureLabel(ctx context.Context, v Label) (bool, error) {
+	if err := g.loadLabels(ctx); err != nil {
+		return false, err
+	}
+	if _, ok := g.labels[v.Name]; ok {
+		return false, nil
+	}
+	if err := g.CreateLabel(ctx, v); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func (g *Gitea) UserExists(ctx context.Context, name string) bool {
+	key := g.Endpoint + "/" + name
+	if exist, ok := g.Users.Get(key); ok {
+		return exist
+	}
+	err := g.do(ctx, "GET", "users/"+url.PathEscape(name), nil, nil)
+	g.Users.Set(key, err == nil)
+	return err == nil
+}
+
+// labelIDs returns the ids of the labels of the names, which Gitea takes
+// instead of the names. The missing labels are created like GitHub does.
+func (g *Gitea) labelIDs(ctx context.Context, names []string) ([]int64, error) {
+	if err := g.loadLabels(ctx); err != nil {
+		return nil, err
+	}
+	var ids []int64
+	for _, name := range names {
+		if _, ok := g.labels[name]; !ok {
+			if err := g.CreateLabel(ctx, Label{Name: name, Color: giteaLabelColor}); err != nil {
+				return nil, err
+			}
+			logf(g.Log, "created label: %s\n", name)
+			if err := g.loadLabels(ctx); err != nil {
+				return nil, err
+			}
+		}
+		ids = append(ids, g.labels[name])
+	}
+	return ids, nil
+}
+
+func (g *Gitea) loadLabels(ctx context.Context) error {
+	if g.labels != nil {
+		return nil
+	}
+	var got []struct {
+		ID   int64  `json:"id"`
+		Name string `json:"name"`
+	}
+	if err := g.list(ctx, "labels", &got); err != nil {
+		return err
+	}
+	g.labels = map[string]int64{}
+	for _, v := range got {
+		g.labels[v.Name] = v.ID
+	}
+	return nil
+}
+
+// milestoneID returns the id of the milestone of the number. The ids of
+// Gitea are global, so the numbers are the orders of the ids in the
+// repository, as the milestones are created in the order of the numbers.
+func (g *Gitea) milestoneID(ctx context.Context, n int) (int64, error) {
+	if err := g.loadMilestones(ctx); err != nil {
+		return 0, err
+	}
+	id, ok := g.milestones[n]
+	if !ok {
+		return 0, fmt.Errorf("gitea milestone not found: %d", n)
+	}
+	return id, nil
+}
+
+func (g *Gitea) loadMilestones(ctx context.Context) error {
+	if g.milestones != nil {
+		return nil
+	}
+	var got []struct {
+		ID int64 `json:"id"`
+	}
+	if err := g.list(ctx, "milestones?state=all", &got); err != nil {
+		return err
+	}
+	sort.Slice(got, func(i, j int) bool { return got[i].ID < got[j].ID })
+	g.milestones = map[int]int64{}
+	for i, v := range got {
+		g.milestones[i+1] = v.ID
+	}
+	return nil
+}
+
+// list gets every page of the resource of the repository into v, a pointer to a slice.
+func (g *Gitea) list(ctx context.Context, resource string, v interface{}) error {
+	sep := "?"
+	if strings.Contains(resource, "?") {
+		sep = "&"
+	}
+	var all []json.RawMessage
+	for page := 1; ; page++ {
+		var got []json.RawMessage
+		p := fmt.Sprintf("%s%slimit=%d&page=%d", g.repoPath(resource), sep, giteaPerPage, page)
+		if err := g.do(ctx, "GET", p, nil, &got); err != nil {
+			return err
+		}
+		all = append(all, got...)
+		if len(got) < giteaPerPage {
+			break
+		}
+	}
+	buf, err := json.Marshal(all)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(buf, v)
+}
+
+func (g *Gitea) repoPath(resource string) string {
+	return fmt.Sprintf("repos/%s/%s/%s", url.PathEscape(g.Owner), url.PathEscape(g.Name), resource)
+}
+
+func (g *Gitea) do(ctx context.Context, method, p string, body, v interface{}) error {
+	var buf bytes.Buffer
+	if body != nil {
+		if err := json.NewEncoder(&buf).Encode(body); err != nil {
+			return err
+		}
+	}
+	endpoint := strings.TrimSuffix(strings.TrimSuffix(g.Endpoint, "/"), "/api/v1")
+	req, err := http.NewRequest(method, endpoint+"/api/v1/"+p, &buf)
+	if err != nil {
+		return err
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if g.Token != "" {
+		req.Header.Set("Authorization", "token "+g.Token)
+	}
+	client := g.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		var e struct {
+			Message string `json:"message"`
+		}
+		json.NewDecoder(resp.Body).Decode(&e)
+		return fmt.Errorf("gitea: %s %s: %s: %s", method, p, resp.Status, e.Message)
+	}
+	if v == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
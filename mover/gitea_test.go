@@ -0,0 +1,223 @@
+package mover
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+type giteaStubIssue struct {
+	Number    int64      `json:"number"`
+	Title     string     `json:"title"`
+	Body      string     `json:"body"`
+	State     string     `json:"state"`
+	Labels    []int64    `json:"-"`
+	Milestone int64      `json:"-"`
+	Assignees []string   `json:"-"`
+	Comments  []string   `json:"-"`
+	UpdatedAt *time.Time `json:"-"`
+}
+
+// giteaStub is an in-memory Gitea of the repository foo/dst.
+type giteaStub struct {
+	*httptest.Server
+	mu         sync.Mutex
+	nextID     int64
+	Labels     map[string]int64
+	Milestones map[string]int64
+	Issues     []*giteaStubIssue
+}
+
+func newGiteaStub(t *testing.T) *giteaStub {
+	g := &giteaStub{nextID: 100, Labels: map[string]int64{}, Milestones: map[string]int64{}}
+	g.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		g.mu.Lock()
+		defer g.mu.Unlock()
+		if r.Header.Get("Authorization") != "token dst-token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		p := strings.TrimPrefix(r.URL.Path, "/api/v1/")
+		if p == "users/alice-dst" {
+			json.NewEncoder(w).Encode(map[string]string{"login": "alice-dst"})
+			return
+		}
+		if !strings.HasPrefix(p, "repos/foo/dst/") {
+			w.WriteHeader(http.StatusNotFound)
+			json.NewEncoder(w).Encode(map[string]string{"message": "not found"})
+			return
+		}
+		p = strings.TrimPrefix(p, "repos/foo/dst/")
+		var in map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&in)
+
+		switch {
+		case p == "labels" && r.Method == "POST":
+			if !strings.HasPrefix(in["color"].(string), "#") {
+				t.Errorf("label color without #: %v", in["color"])
+			}
+			g.nextID++
+			g.Labels[in["name"].(string)] = g.nextID
+			w.WriteHeader(http.StatusCreated)
+		case p == "labels":
+			var got []interface{}
+			if r.URL.Query().Get("page") == "1" {
+				for name, id := range g.Labels {
+					got = append(got, map[string]interface{}{"id": id, "name": name})
+				}
+			}
+			json.NewEncoder(w).Encode(got)
+		case p == "milestones" && r.Method == "POST":
+			g.nextID++
+			g.Milestones[in["title"].(string)] = g.nextID
+			w.WriteHeader(http.StatusCreated)
+			json.NewEncoder(w).Encode(map[string]interface{}{"id": g.nextID})
+		case p == "milestones":
+			var got []interface{}
+			if r.URL.Query().Get("page") == "1" {
+				for _, id := range g.Milestones {
+					got = append(got, map[string]interface{}{"id": id})
+				}
+			}
+			json.NewEncoder(w).Encode(got)
+		case p == "issues" && r.Method == "POST":
+			var v giteaIssueOption
+			buf, _ := json.Marshal(in)
+			json.Unmarshal(buf, &v)
+			issue := &giteaStubIssue{
+				Number: int64(len(g.Issues) + 1), Title: v.Title, Body: v.Body, State: "open",
+				Labels: v.Labels, Milestone: v.Milestone, Assignees: v.Assignees,
+			}
+			g.Issues = append(g.Issues, issue)
+			w.WriteHeader(http.StatusCreated)
+			json.NewEncoder(w).Encode(issue)
+		case p == "issues":
+			got := []interface{}{}
+			if len(g.Issues) > 0 {
+				got = append(got, g.Issues[len(g.Issues)-1])
+			}
+			json.NewEncoder(w).Encode(got)
+		case strings.HasPrefix(p, "issues/"):
+			s := strings.Split(p, "/")
+			n, _ := strconv.Atoi(s[1])
+			if n < 1 || n > len(g.Issues) {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			issue := g.Issues[n-1]
+			if len(s) == 3 && s[2] == "comments" {
+				issue.Comments = append(issue.Comments, in["body"].(string))
+				w.WriteHeader(http.StatusCreated)
+				return
+			}
+			if state, ok := in["state"].(string); ok {
+				issue.State = state
+			}
+			if updated, ok := in["updated_at"].(string); ok {
+				tt, _ := time.Parse(time.RFC3339, updated)
+				issue.UpdatedAt = &tt
+			}
+			json.NewEncoder(w).Encode(issue)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			json.NewEncoder(w).Encode(map[string]string{"message": "not found"})
+		}
+	}))
+	return g
+}
+
+func TestExecGitea(t *testing.T) {
+	f := newFakeGitHub()
+	defer f.Close()
+	seedSrc(f)
+	g := newGiteaStub(t)
+	defer g.Close()
+	dir := testDir(t)
+	defer os.RemoveAll(dir)
+
+	o := testOptions(f, dir)
+	o.DstType = DstGitea
+	o.DstEndpoint = g.URL + "/api/v1"
+
+	tr, err := New(context.Background(), o, testShared())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tr.IsImport || tr.DST != nil {
+		t.Errorf("gitea transfer: import %v, DST %v", tr.IsImport, tr.DST)
+	}
+	if err := tr.Exec(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(g.Issues) != 5 {
+		t.Fatalf("issues: %d, want #1, #2, the dummy #3, the pull request #4 and #5", len(g.Issues))
+	}
+	first := g.Issues[0]
+	if first.Title != "first" || first.State != "closed" || !strings.Contains(first.Body, "dst.example.com") {
+		t.Errorf("first: %#v", first)
+	}
+	if len(first.Labels) != 1 || first.Labels[0] != g.Labels["bug"] || first.Milestone != g.Milestones["v1"] {
+		t.Errorf("first labels %v, milestone %d", first.Labels, first.Milestone)
+	}
+	if len(first.Assignees) != 1 || first.Assignees[0] != "alice-dst" || len(first.Comments) != 1 {
+		t.Errorf("first assignees %v, comments %v", first.Assignees, first.Comments)
+	}
+	if dummy := g.Issues[2]; dummy.Title != defaultDummyTitle || dummy.State != "closed" || len(dummy.Labels) != 1 {
+		t.Errorf("dummy: %#v", dummy)
+	}
+	if _, ok := g.Labels[defaultDummyLabel]; !ok {
+		t.Errorf("dummy label is not created: %v", g.Labels)
+	}
+
+	o.DstEndpoint = defaultEndpoint
+	if _, err := New(context.Background(), o, testShared()); err == nil {
+		t.Error("gitea without the endpoint")
+	}
+}
+
+func TestGiteaImportIssue(t *testing.T) {
+	g := newGiteaStub(t)
+	defer g.Close()
+	d := &Gitea{Owner: "foo", Name: "dst", Endpoint: g.URL, Token: "dst-token"}
+	ctx := context.Background()
+
+	closed := true
+	closedAt := time.Date(2019, 1, 3, 0, 0, 0, 0, time.UTC)
+	input := &IssueImportRequest{
+		IssueImport: IssueImport{Title: "imported", Body: "body", Closed: &closed, ClosedAt: &closedAt, Labels: []string{"new"}},
+		Comments:    []*IssueImportComment{{Body: "a comment"}},
+	}
+	n, err := d.ImportIssue(ctx, input, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 1 {
+		t.Errorf("number: %d", n)
+	}
+	issue := g.Issues[0]
+	if issue.State != "closed" || issue.UpdatedAt == nil || !issue.UpdatedAt.Equal(closedAt) || len(issue.Comments) != 1 {
+		t.Errorf("issue: %#v", issue)
+	}
+	if id, ok := g.Labels["new"]; !ok || len(issue.Labels) != 1 || issue.Labels[0] != id {
+		t.Errorf("missing label is not created: %v, %v", g.Labels, issue.Labels)
+	}
+
+	last, err := d.LastNumber(ctx)
+	if err != nil || last != 1 {
+		t.Errorf("last number: %d, %v", last, err)
+	}
+	if _, err := d.milestoneID(ctx, 1); err == nil {
+		t.Error("missing milestone")
+	}
+	if d.UserExists(ctx, "nobody") || !d.UserExists(ctx, "alice-dst") {
+		t.Error("user existence")
+	}
+}
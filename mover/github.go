@@ -0,0 +1,405 @@
+package mover
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/go-github/v32/github"
+	"github.com/shurcooL/githubv4"
+	"golang.org/x/oauth2"
+)
+
+// SRC is the Source of a GitHub or GitHub Enterprise repository.
+type SRC struct {
+	Owner    string
+	Name     string
+	Endpoint string
+	Client   *githubv4.Client
+	REST     *github.Client
+	// TokenSource gives the tokens of the git commands of the wiki.
+	TokenSource oauth2.TokenSource
+}
+
+// DST is the Destination of a GitHub or GitHub Enterprise repository.
+type DST struct {
+	Owner    string
+	Name     string
+	Endpoint string
+	Client   *github.Client
+	GraphQL  *githubv4.Client
+	// AdminGraphQL is the client of DST_ADMIN_TOKEN for the deletes, GraphQL when nil.
+	AdminGraphQL *githubv4.Client
+	// TokenSource gives the tokens of the git commands of the wiki.
+	TokenSource oauth2.TokenSource
+	Users       *UserCache
+	// Log is where the progress is printed, the standard output when nil.
+	Log io.Writer
+
+	// imports are the imports not waited for.
+	imports []pendingImport
+}
+
+type pendingImport struct {
+	id  int
+	src int
+}
+
+// importFailedError is the error of an import ended in the failed status.
+type importFailedError struct {
+	id int
+}
+
+func (e *importFailedError) Error() string {
+	return fmt.Sprintf("issue import failed: importID %d", e.id)
+}
+
+func (s *SRC) Repo() string {
+	return s.Owner + "/" + s.Name
+}
+
+func (s *SRC) Labels(ctx context.Context) ([]Label, error) {
+	var labels []Label
+	var lq LabelsQuery
+	lv := map[string]interface{}{
+		"owner":  githubv4.String(s.Owner),
+		"repo":   githubv4.String(s.Name),
+		"cursor": (*githubv4.String)(nil),
+	}
+	for {
+		err := s.Client.Query(ctx, &lq, lv)
+		if err != nil {
+			return nil, err
+		}
+		labels = append(labels, lq.Repository.Labels.Nodes...)
+		if !lq.Repository.Labels.PageInfo.HasNextPage {
+			break
+		}
+		lv["cursor"] = githubv4.NewString(lq.Repository.Labels.PageInfo.EndCursor)
+	}
+	return labels, nil
+}
+
+func (s *SRC) Milestones(ctx context.Context) ([]Milestone, error) {
+	var milestones []Milestone
+	var mq MilestonesQuery
+	mv := map[string]interface{}{
+		"owner":  githubv4.String(s.Owner),
+		"repo":   githubv4.String(s.Name),
+		"cursor": (*githubv4.String)(nil),
+	}
+	for {
+		err := s.Client.Query(ctx, &mq, mv)
+		if err != nil {
+			return nil, err
+		}
+		milestones = append(milestones, mq.Repository.Milestones.Nodes...)
+		if !mq.Repository.Milestones.PageInfo.HasNextPage {
+			break
+		}
+		mv["cursor"] = githubv4.NewString(mq.Repository.Milestones.PageInfo.EndCursor)
+	}
+	return milestones, nil
+}
+
+func (s *SRC) Issues(ctx context.Context, f *Filter) ([]Issue, error) {
+	var issues []Issue
+	var iq IssuesQuery
+	iv := map[string]interface{}{
+		"owner":    githubv4.String(s.Owner),
+		"repo":     githubv4.String(s.Name),
+		"cursor":   (*githubv4.String)(nil),
+		"filterBy": f.issueFilters(),
+	}
+	for {
+		err := s.Client.Query(ctx, &iq, iv)
+		if err != nil {
+			return nil, err
+		}
+		for _, v := range iq.Repository.Issues.Nodes {
+			if f.Match(&v) {
+				issues = append(issues, v)
+			}
+		}
+		if !iq.Repository.Issues.PageInfo.HasNextPage {
+			break
+		}
+		iv["cursor"] = githubv4.NewString(iq.Repository.Issues.PageInfo.EndCursor)
+	}
+	return issues, nil
+}
+
+func (s *SRC) Pulls(ctx context.Context, f *Filter) ([]Issue, error) {
+	var pulls []Issue
+	var pq PullReqeustsQuery
+	pv := map[string]interface{}{
+		"owner":  githubv4.String(s.Owner),
+		"repo":   githubv4.String(s.Name),
+		"cursor": (*githubv4.String)(nil),
+		"states": f.pullRequestStates(),
+		"labels": f.labels(),
+	}
+	for {
+		err := s.Client.Query(ctx, &pq, pv)
+		if err != nil {
+			return nil, err
+		}
+		for _, v := range pq.Repository.PullReqeusts.Nodes {
+			if f.Match(&v) {
+				pulls = append(pulls, v)
+			}
+		}
+		if !pq.Repository.PullReqeusts.PageInfo.HasNextPage {
+			break
+		}
+		pv["cursor"] = githubv4.NewString(pq.Repository.PullReqeusts.PageInfo.EndCursor)
+	}
+	return pulls, nil
+}
+
+func (d *DST) Repo() string {
+	return d.Owner + "/" + d.Name
+}
+
+// adminGraphQL returns the GraphQL client of the deletes.
+func (d *DST) adminGraphQL() *githubv4.Client {
+	if d.AdminGraphQL != nil {
+		return d.AdminGraphQL
+	}
+	return d.GraphQL
+}
+
+func (d *DST) CreateLabel(ctx context.Context, v Label) error {
+	input := &github.Label{
+		Name:        &v.Name,
+		Color:       &v.Color,
+		Description: &v.Description,
+	}
+	_, _, err := d.Client.Issues.CreateLabel(ctx, d.Owner, d.Name, input)
+	return err
+}
+
+func (d *DST) CreateMilestone(ctx context.Context, v Milestone) (int, error) {
+	state := strings.ToLower(v.State)
+	input := &github.Milestone{
+		Title:       &v.Title,
+		State:       &state,
+		DueOn:       &v.DueOn,
+		Description: &v.Description,
+	}
+	m, _, err := d.Client.Issues.CreateMilestone(ctx, d.Owner, d.Name, input)
+	if err != nil {
+		return 0, err
+	}
+	return m.GetNumber(), nil
+}
+
+func (d *DST) ImportIssue(ctx context.Context, input *IssueImportRequest, wait bool) (int, error) {
+	got, _, err := ImportIssue(d.Client, withAuthor(ctx, input.Author), d.Owner, d.Name, input)
+	if err != nil {
+		return 0, err
+	}
+
+	importID, _ := strconv.Atoi(path.Base(*got.URL))
+	logf(d.Log, "requested issue import: importID %d - %s\n", importID, input.IssueImport.Title)
+
+	if !wait {
+		d.imports = append(d.imports, pendingImport{id: importID, src: input.Src})
+		return 0, nil
+	}
+	return d.waitImportIssue(ctx, importID)
+}
+
+// WaitImports waits for the imports requested without waiting to end, and
+// returns the DST numbers of the SRC ones imported, and the SRC numbers of
+// the failed ones, 0 of the dummies.
+func (d *DST) WaitImports(ctx context.Context) (map[int]int, []int, error) {
+	if len(d.imports) > 0 {
+		logf(d.Log, "waiting for issue imports: %d\n", len(d.imports))
+	}
+	imported := map[int]int{}
+	var failed []int
+	for len(d.imports) > 0 {
+		v := d.imports[0]
+		n, err := d.waitImportIssue(ctx, v.id)
+		if _, ok := err.(*importFailedError); ok {
+			logf(d.Log, "failed issue import: #%d - %s\n", v.src, err)
+			failed = append(failed, v.src)
+		} else if err != nil {
+			return imported, failed, err
+		} else if v.src > 0 {
+			imported[v.src] = n
+		}
+		d.imports = d.imports[1:]
+	}
+	return imported, failed, nil
+}
+
+func (d *DST) waitImportIssue(ctx context.Context, importID int) (int, error) {
+	for {
+		got, _, err := CheckImportIssueStatus(d.Client, ctx, d.Owner, d.Name, int64(importID))
+		if err != nil {
+			return 0, err
+		}
+		switch got.GetStatus() {
+		case "imported":
+			number, err := strconv.Atoi(path.Base(got.GetIssueURL()))
+			if err != nil {
+				return 0, err
+			}
+			logf(d.Log, "imported issue: #%d\n", number)
+			return number, nil
+		case "failed":
+			for _, v := range got.Errors {
+				logf(d.Log, "%s [%s]: %s\n", *v.Field, *v.Code, *v.Value)
+			}
+			return 0, &importFailedError{id: importID}
+		}
+		select {
+		case <-ctx.Done():
+			return 0, ctx.Err()
+		case <-time.After(importWaitInterval):
+		}
+	}
+}
+
+func (d *DST) CreateIssue(ctx context.Context, input *IssueAndCommentsRequest) (int, error) {
+	issue, _, err := d.Client.Issues.Create(withAuthor(ctx, input.Author), d.Owner, d.Name, input.Issue)
+	if err != nil {
+		logf(d.Log, "%#v\n", input.Issue)
+		return 0, err
+	}
+	logf(d.Log, "created issue: #%d - %s\n", *issue.Number, *issue.Title)
+
+	for i, v := range input.Comments {
+		ctx := ctx
+		if i < len(input.CommentAuthors) {
+			ctx = withAuthor(ctx, input.CommentAuthors[i])
+		}
+		_, _, err := d.Client.Issues.CreateComment(ctx, d.Owner, d.Name, *issue.Number, v)
+		if err != nil {
+			switch err := err.(type) {
+			case *github.ErrorResponse:
+				return *issue.Number, err
+			default:
+				logf(d.Log, "comment error: %s\n", err.Error())
+				_, _, err2 := d.Client.Issues.CreateComment(ctx, d.Owner, d.Name, *issue.Number, v)
+				if err2 != nil {
+					logf(d.Log, "comment retry error: %s\n", err2.Error())
+				}
+			}
+		}
+	}
+
+	if *input.Issue.State == "closed" {
+		_, _, err = d.Client.Issues.Edit(withAuthor(ctx, input.Author), d.Owner, d.Name, *issue.Number, &github.IssueRequest{State: input.Issue.State})
+		if err != nil {
+			logf(d.Log, "%#v\n", input.Issue)
+			return *issue.Number, err
+		}
+		logf(d.Log, "closed issue: #%d\n", *issue.Number)
+	}
+
+	return *issue.Number, nil
+}
+
+// LastNumber returns the highest number of the issues and pull requests.
+// The newest by the creation time is not the last one, since the import api
+// keeps the creation times of SRC, so every issue is scanned.
+func (d *DST) LastNumber(ctx context.Context) (int, error) {
+	opt := &github.IssueListByRepoOptions{
+		State:       "all",
+		ListOptions: github.ListOptions{PerPage: 100},
+	}
+	last := 0
+	for {
+		issues, resp, err := d.Client.Issues.ListByRepo(ctx, d.Owner, d.Name, opt)
+		if err != nil {
+			return 0, err
+		}
+		for _, v := range issues {
+			if v.GetNumber() > last {
+				last = v.GetNumber()
+			}
+		}
+		if resp.NextPage == 0 {
+			break
+		}
+		opt.Page = resp.NextPage
+	}
+	return last, nil
+}
+
+func (d *DST) LockIssue(ctx context.Context, n int, reason string) error {
+	_, err := d.Client.Issues.Lock(ctx, d.Owner, d.Name, n, &github.LockIssueOptions{LockReason: reason})
+	return err
+}
+
+func (d *DST) EnsureLabel(ctx context.Context, v Label) (bool, error) {
+	_, resp, err := d.Client.Issues.GetLabel(ctx, d.Owner, d.Name, v.Name)
+	if err == nil {
+		return false, nil
+	}
+	if resp == nil || resp.StatusCode != 404 {
+		return false, err
+	}
+	if err := d.CreateLabel(ctx, v); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func (d *DST) UserExists(ctx context.Context, name string) bool {
+	key := d.Endpoint + "/" + name
+	if exist, ok := d.Users.Get(key); ok {
+		return exist
+	}
+	_, _, err := d.Client.Users.Get(ctx, name)
+	d.Users.Set(key, err == nil)
+	return err == nil
+}
+
+// newGraphQLClient returns the GraphQL client of GitHub, or of GitHub Enterprise
+// when the endpoint is not the default.
+func newGraphQLClient(ctx context.Context, endpoint string, ts oauth2.TokenSource) *githubv4.Client {
+	tc := oauth2.NewClient(ctx, ts)
+	if defaultEndpoint == endpoint {
+		return githubv4.NewClient(tc)
+	}
+	return githubv4.NewEnterpriseClient(graphqlURL(endpoint), tc)
+}
+
+// newRESTClient returns the REST client of GitHub, or of GitHub Enterprise
+// when the endpoint is not the default.
+func newRESTClient(ctx context.Context, endpoint string, ts oauth2.TokenSource) (*github.Client, error) {
+	return newRESTClientWith(endpoint, oauth2.NewClient(ctx, ts))
+}
+
+// newRESTClientWith returns the REST client of the endpoint with the http
+// client, which authenticates the requests.
+func newRESTClientWith(endpoint string, tc *http.Client) (*github.Client, error) {
+	if defaultEndpoint == endpoint {
+		return github.NewClient(tc), nil
+	}
+	return github.NewEnterpriseClient(restURL(endpoint), restURL(endpoint), tc)
+}
+
+// restURL returns the REST api endpoint of GitHub Enterprise
+// from its GraphQL endpoint or its host.
+func restURL(endpoint string) string {
+	return strings.TrimSuffix(graphqlURL(endpoint), "graphql") + "v3/"
+}
+
+// graphqlURL returns the GraphQL endpoint of GitHub Enterprise
+// from its REST api endpoint or its host.
+func graphqlURL(endpoint string) string {
+	u := strings.TrimSuffix(endpoint, "/")
+	u = strings.TrimSuffix(u, "/api/v3")
+	u = strings.TrimSuffix(u, "/api/graphql")
+	return u + "/api/graphql"
+}
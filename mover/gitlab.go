@@ -0,0 +1,346 @@
+package mover
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/shurcooL/githubv4"
+)
+
+const (
+	defaultGitLabEndpoint = "https://gitlab.com"
+	gitLabPerPage         = 100
+)
+
+// GitLab is the Source of a GitLab project. Its merge requests are moved
+// as pull requests, numbered after the issues by MROffset, since GitLab
+// numbers them apart from the issues. The milestones of the ancestor groups
+// are moved with the ones of the project, numbered after them.
+type GitLab struct {
+	Project  string
+	Endpoint string
+	Token    string
+	// MROffset is added to the numbers of the merge requests.
+	// When it is 0, the largest number of the issues is added.
+	MROffset int
+	Client   *http.Client
+	// Log is where the dropped milestones are printed, the standard output when nil.
+	Log io.Writer
+
+	// milestones are the milestones of the project and the groups, and
+	// groupMilestones maps the ids of the group ones to their numbers.
+	milestones      []gitLabMilestone
+	groupMilestones map[int]int
+}
+
+type gitLabMilestone struct {
+	ID          int    `json:"id"`
+	IID         int    `json:"iid"`
+	GroupID     int    `json:"group_id"`
+	Title       string `json:"title"`
+	Description string `json:"description"`
+	State       string `json:"state"`
+	DueDate     string `json:"due_date"`
+}
+
+type gitLabUser struct {
+	Username  string `json:"username"`
+	AvatarURL string `json:"avatar_url"`
+}
+
+type gitLabIssue struct {
+	IID         int              `json:"iid"`
+	Title       string           `json:"title"`
+	Description string           `json:"description"`
+	WebURL      string           `json:"web_url"`
+	State       string           `json:"state"`
+	CreatedAt   time.Time        `json:"created_at"`
+	UpdatedAt   time.Time        `json:"updated_at"`
+	ClosedAt    *time.Time       `json:"closed_at"`
+	MergedAt    *time.Time       `json:"merged_at"`
+	Labels      []string         `json:"labels"`
+	Author      gitLabUser       `json:"author"`
+	Assignees   []gitLabUser     `json:"assignees"`
+	Milestone   *gitLabMilestone `json:"milestone"`
+}
+
+type gitLabNote struct {
+	Body      string     `json:"body"`
+	System    bool       `json:"system"`
+	CreatedAt time.Time  `json:"created_at"`
+	Author    gitLabUser `json:"author"`
+}
+
+func (g *GitLab) Repo() string {
+	return g.Project
+}
+
+func (g *GitLab) Labels(ctx context.Context) ([]Label, error) {
+	var got []struct {
+		Name        string `json:"name"`
+		Color       string `json:"color"`
+		Description string `json:"description"`
+	}
+	if err := g.list(ctx, "labels", nil, &got); err != nil {
+		return nil, err
+	}
+	var labels []Label
+	for _, v := range got {
+		labels = append(labels, Label{
+			Name:        v.Name,
+			Color:       strings.TrimPrefix(v.Color, "#"),
+			Description: v.Description,
+		})
+	}
+	return labels, nil
+}
+
+func (g *GitLab) Milestones(ctx context.Context) ([]Milestone, error) {
+	if err := g.fetchMilestones(ctx); err != nil {
+		return nil, err
+	}
+	var milestones []Milestone
+	for _, v := range g.milestones {
+		m := Milestone{
+			Number:      g.milestoneNumber(v),
+			Title:       v.Title,
+			Description: v.Description,
+			State:       "OPEN",
+		}
+		if v.State == "closed" {
+			m.State = "CLOSED"
+			m.Closed = true
+		}
+		if v.DueDate != "" {
+			if due, err := time.Parse("2006-01-02", v.DueDate); err == nil {
+				m.DueOn = due
+			}
+		}
+		milestones = append(milestones, m)
+	}
+	// the milestones are created in the order of their numbers.
+	sort.Slice(milestones, func(i, j int) bool { return milestones[i].Number < milestones[j].Number })
+	return milestones, nil
+}
+
+// fetchMilestones gets the milestones of the project and its ancestor
+// groups once, and numbers the group ones after the largest iid of the
+// project ones, as the iids of the groups overlap them.
+func (g *GitLab) fetchMilestones(ctx context.Context) error {
+	if g.groupMilestones != nil {
+		return nil
+	}
+	var got []gitLabMilestone
+	if err := g.list(ctx, "milestones", url.Values{"include_ancestors": {"true"}}, &got); err != nil {
+		return err
+	}
+	last := 0
+	var groups []gitLabMilestone
+	for _, v := range got {
+		if v.GroupID != 0 {
+			groups = append(groups, v)
+		} else if v.IID > last {
+			last = v.IID
+		}
+	}
+	sort.Slice(groups, func(i, j int) bool { return groups[i].ID < groups[j].ID })
+	g.groupMilestones = map[int]int{}
+	for i, v := range groups {
+		g.groupMilestones[v.ID] = last + i + 1
+	}
+	g.milestones = got
+	return nil
+}
+
+// milestoneNumber returns the number of the milestone, or 0 for the group
+// milestone not fetched.
+func (g *GitLab) milestoneNumber(v gitLabMilestone) int {
+	if v.GroupID == 0 {
+		return v.IID
+	}
+	return g.groupMilestones[v.ID]
+}
+
+func (g *GitLab) Issues(ctx context.Context, f *Filter) ([]Issue, error) {
+	return g.issues(ctx, "issues", 0, f)
+}
+
+func (g *GitLab) Pulls(ctx context.Context, f *Filter) ([]Issue, error) {
+	offset := g.MROffset
+	if offset == 0 {
+		// the issues cannot be ordered by iid, and the last created one
+		// may be moved from another project with a smaller iid.
+		var all []struct {
+			IID int `json:"iid"`
+		}
+		if err := g.list(ctx, "issues", url.Values{"scope": {"all"}}, &all); err != nil {
+			return nil, err
+		}
+		for _, v := range all {
+			if v.IID > offset {
+				offset = v.IID
+			}
+		}
+	}
+	return g.issues(ctx, "merge_requests", offset, f)
+}
+
+// issues returns the issues or the merge requests of the kind as issues
+// numbered by their iid and the offset.
+func (g *GitLab) issues(ctx context.Context, kind string, offset int, f *Filter) ([]Issue, error) {
+	q := url.Values{"scope": {"all"}, "order_by": {"created_at"}, "sort": {"asc"}}
+	switch f.State {
+	case filterStateOpen:
+		q.Set("state", "opened")
+	case filterStateClose:
+		// merged merge requests are closed too.
+		if kind == "issues" {
+			q.Set("state", "closed")
+		}
+	}
+	if !f.Since.IsZero() {
+		q.Set("created_after", f.Since.Format(time.RFC3339))
+	}
+	if !f.Until.IsZero() {
+		q.Set("created_before", f.Until.Format(time.RFC3339))
+	}
+
+	var got []gitLabIssue
+	if err := g.list(ctx, kind, q, &got); err != nil {
+		return nil, err
+	}
+	if err := g.fetchMilestones(ctx); err != nil {
+		return nil, err
+	}
+
+	var issues []Issue
+	for _, v := range got {
+		issue := gitLabToIssue(v, offset)
+		if v.Milestone != nil {
+			issue.Milestone.Number = g.milestoneNumber(*v.Milestone)
+			if issue.Milestone.Number == 0 {
+				logf(g.Log, "dropped milestone: %s of #%d - group milestone not found\n", v.Milestone.Title, issue.Number)
+			}
+		}
+		if !f.Match(&issue) {
+			continue
+		}
+		var notes []gitLabNote
+		nq := url.Values{"order_by": {"created_at"}, "sort": {"asc"}}
+		if err := g.list(ctx, fmt.Sprintf("%s/%d/notes", kind, v.IID), nq, &notes); err != nil {
+			return nil, err
+		}
+		for _, n := range notes {
+			// system notes are the events like label changes.
+			if n.System {
+				continue
+			}
+			c := IssueComment{Body: n.Body, CreatedAt: n.CreatedAt}
+			c.Author.Login = n.Author.Username
+			c.Author.AvatarURL = n.Author.AvatarURL
+			issue.Comments.Nodes = append(issue.Comments.Nodes, c)
+		}
+		issue.Comments.TotalCount = githubv4.Int(len(issue.Comments.Nodes))
+		issues = append(issues, issue)
+	}
+	sort.Slice(issues, func(i, j int) bool { return issues[i].Number < issues[j].Number })
+	return issues, nil
+}
+
+func gitLabToIssue(v gitLabIssue, offset int) Issue {
+	var issue Issue
+	issue.Number = v.IID + offset
+	issue.Title = v.Title
+	issue.Body = v.Description
+	issue.URL = v.WebURL
+	issue.CreatedAt = v.CreatedAt
+	issue.UpdatedAt = v.UpdatedAt
+	issue.State = "OPEN"
+	if v.State != "opened" {
+		issue.State = "CLOSED"
+		issue.Closed = true
+	}
+	switch {
+	case v.ClosedAt != nil:
+		issue.ClosedAt = *v.ClosedAt
+	case v.MergedAt != nil:
+		issue.ClosedAt = *v.MergedAt
+	}
+	issue.Author.Login = v.Author.Username
+	issue.Author.AvatarURL = v.Author.AvatarURL
+	for _, a := range v.Assignees {
+		issue.Assignees.Nodes = append(issue.Assignees.Nodes, struct{ Login string }{a.Username})
+	}
+	issue.Assignees.TotalCount = githubv4.Int(len(v.Assignees))
+	for _, l := range v.Labels {
+		issue.Labels.Nodes = append(issue.Labels.Nodes, struct{ Name string }{l})
+	}
+	issue.Labels.TotalCount = githubv4.Int(len(v.Labels))
+	return issue
+}
+
+// list gets every page of the resource of the project into v, a pointer to a slice.
+func (g *GitLab) list(ctx context.Context, resource string, q url.Values, v interface{}) error {
+	if q == nil {
+		q = url.Values{}
+	}
+	q.Set("per_page", strconv.Itoa(gitLabPerPage))
+	var all []json.RawMessage
+	for page := "1"; page != ""; {
+		q.Set("page", page)
+		var got []json.RawMessage
+		resp, err := g.do(ctx, resource, q, &got)
+		if err != nil {
+			return err
+		}
+		all = append(all, got...)
+		page = resp.Header.Get("X-Next-Page")
+	}
+	buf, err := json.Marshal(all)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(buf, v)
+}
+
+func (g *GitLab) do(ctx context.Context, resource string, q url.Values, v interface{}) (*http.Response, error) {
+	endpoint := g.Endpoint
+	if endpoint == "" {
+		endpoint = defaultGitLabEndpoint
+	}
+	u := fmt.Sprintf("%s/api/v4/projects/%s/%s?%s",
+		strings.TrimSuffix(endpoint, "/"), url.PathEscape(g.Project), resource, q.Encode())
+	req, err := http.NewRequest("GET", u, nil)
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+	if g.Token != "" {
+		req.Header.Set("PRIVATE-TOKEN", g.Token)
+	}
+	client := g.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		var e struct {
+			Message interface{} `json:"message"`
+		}
+		json.NewDecoder(resp.Body).Decode(&e)
+		return nil, fmt.Errorf("gitlab: GET %s: %s: %v", resource, resp.Status, e.Message)
+	}
+	return resp, json.NewDecoder(resp.Body).Decode(v)
+}
@@ -0,0 +1,237 @@
+package mover
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+const testGitLabProject = "group/sub/proj"
+
+// newGitLabStub serves the labels, milestones, issues, merge requests and
+// notes of testGitLabProject, one item per page.
+func newGitLabStub(t *testing.T) *httptest.Server {
+	user := map[string]interface{}{"username": "alice", "avatar_url": "https://gitlab.example.com/alice.png"}
+	resources := map[string][]interface{}{
+		"labels": {
+			map[string]interface{}{"name": "bug", "color": "#ff0000", "description": "a bug"},
+			map[string]interface{}{"name": "feature", "color": "#00ff00"},
+		},
+		// the milestone of the group has the iid of a project one.
+		"milestones": {
+			map[string]interface{}{"id": 13, "iid": 3, "title": "v2", "state": "active", "due_date": "2020-02-01"},
+			map[string]interface{}{"id": 12, "iid": 2, "title": "v1", "state": "closed"},
+			map[string]interface{}{"id": 20, "iid": 2, "group_id": 9, "title": "q1", "state": "active"},
+		},
+		// the issue #3 moved from another project is created before #1.
+		"issues": {
+			map[string]interface{}{
+				"iid": 3, "title": "third", "state": "opened", "created_at": "2018-12-31T00:00:00Z", "author": user,
+				"milestone": map[string]interface{}{"id": 20, "iid": 2, "group_id": 9, "title": "q1"},
+			},
+			map[string]interface{}{
+				"iid": 1, "title": "first", "description": "body", "state": "closed",
+				"created_at": "2019-01-01T00:00:00Z", "closed_at": "2019-01-02T00:00:00Z",
+				"labels": []string{"bug"}, "author": user, "assignees": []interface{}{user},
+				"milestone": map[string]interface{}{"id": 12, "iid": 2},
+			},
+		},
+		// the milestone of the group not fetched is dropped.
+		"merge_requests": {
+			map[string]interface{}{
+				"iid": 1, "title": "mr", "state": "merged", "created_at": "2019-01-04T00:00:00Z",
+				"merged_at": "2019-01-05T00:00:00Z", "author": user,
+				"milestone": map[string]interface{}{"id": 99, "iid": 1, "group_id": 8, "title": "other"},
+			},
+		},
+		"issues/1/notes": {
+			map[string]interface{}{"body": "changed the label", "system": true, "author": user},
+			map[string]interface{}{"body": "a note", "system": false, "author": user, "created_at": "2019-01-01T01:00:00Z"},
+		},
+		"issues/3/notes":         {},
+		"merge_requests/1/notes": {},
+	}
+
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("PRIVATE-TOKEN") != "gitlab-token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		prefix := "/api/v4/projects/" + strings.Replace(testGitLabProject, "/", "%2F", -1) + "/"
+		if !strings.HasPrefix(r.URL.EscapedPath(), prefix) {
+			t.Errorf("unexpected path: %s", r.URL.EscapedPath())
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		items, ok := resources[strings.TrimPrefix(r.URL.EscapedPath(), prefix)]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			json.NewEncoder(w).Encode(map[string]string{"message": "404 Not Found"})
+			return
+		}
+		q := r.URL.Query()
+		if q.Get("sort") == "desc" {
+			var reversed []interface{}
+			for i := len(items) - 1; i >= 0; i-- {
+				reversed = append(reversed, items[i])
+			}
+			items = reversed
+		}
+		if q.Get("state") == "opened" {
+			var opened []interface{}
+			for _, v := range items {
+				if v.(map[string]interface{})["state"] == "opened" {
+					opened = append(opened, v)
+				}
+			}
+			items = opened
+		}
+		page, _ := strconv.Atoi(q.Get("page"))
+		if page == 0 {
+			page = 1
+		}
+		if page < len(items) {
+			w.Header().Set("X-Next-Page", strconv.Itoa(page+1))
+		}
+		if len(items) >= page {
+			items = items[page-1 : page]
+		} else {
+			items = []interface{}{}
+		}
+		if q.Get("per_page") == "1" {
+			w.Header().Del("X-Next-Page")
+		}
+		json.NewEncoder(w).Encode(items)
+	}))
+}
+
+func TestGitLabSource(t *testing.T) {
+	s := newGitLabStub(t)
+	defer s.Close()
+	g := &GitLab{Project: testGitLabProject, Endpoint: s.URL, Token: "gitlab-token"}
+	ctx := context.Background()
+
+	labels, err := g.Labels(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(labels) != 2 || labels[0].Color != "ff0000" || labels[0].Description != "a bug" {
+		t.Errorf("labels: %#v", labels)
+	}
+
+	milestones, err := g.Milestones(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(milestones) != 3 || milestones[0].Title != "v1" || !milestones[0].Closed || milestones[1].DueOn.IsZero() {
+		t.Errorf("milestones: %#v", milestones)
+	}
+	if m := milestones[2]; m.Number != 4 || m.Title != "q1" {
+		t.Errorf("group milestone after the project ones: %#v", m)
+	}
+
+	f, _ := NewFilter(filterStateAll, "", "", "", "", "", "")
+	issues, err := g.Issues(ctx, f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(issues) != 2 {
+		t.Fatalf("issues: %#v", issues)
+	}
+	first := issues[0]
+	if first.Number != 1 || !first.Closed || first.State != "CLOSED" || first.Milestone.Number != 2 {
+		t.Errorf("first: %#v", first)
+	}
+	if first.Author.Login != "alice" || len(first.Assignees.Nodes) != 1 || len(first.Labels.Nodes) != 1 {
+		t.Errorf("first: author %#v, assignees %#v, labels %#v", first.Author, first.Assignees, first.Labels)
+	}
+	if len(first.Comments.Nodes) != 1 || first.Comments.Nodes[0].Body != "a note" {
+		t.Errorf("first comments without system notes: %#v", first.Comments.Nodes)
+	}
+	if n := issues[1].Milestone.Number; n != 4 {
+		t.Errorf("group milestone of #3: %d", n)
+	}
+
+	pulls, err := g.Pulls(ctx, f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pulls) != 1 || pulls[0].Number != 4 || !pulls[0].Closed || pulls[0].ClosedAt.IsZero() {
+		t.Errorf("merge requests after the largest issue #3: %#v", pulls)
+	}
+	if n := pulls[0].Milestone.Number; n != 0 {
+		t.Errorf("milestone of another group: %d", n)
+	}
+
+	g.MROffset = 100
+	pulls, err = g.Pulls(ctx, f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pulls) != 1 || pulls[0].Number != 101 {
+		t.Errorf("merge requests with the offset: %#v", pulls)
+	}
+
+	open, _ := NewFilter(filterStateOpen, "", "", "", "", "", "")
+	issues, err = g.Issues(ctx, open)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(issues) != 1 || issues[0].Number != 3 {
+		t.Errorf("open issues: %#v", issues)
+	}
+}
+
+func TestExecGitLab(t *testing.T) {
+	s := newGitLabStub(t)
+	defer s.Close()
+	f := newFakeGitHub()
+	defer f.Close()
+	dir := testDir(t)
+	defer os.RemoveAll(dir)
+
+	o := testOptions(f, dir)
+	o.SrcType = SrcGitLab
+	o.Src = testGitLabProject
+	o.SrcEndpoint = s.URL
+	o.SrcToken = "gitlab-token"
+
+	tr, err := New(context.Background(), o, testShared())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := tr.Exec(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	dst := f.Repo(testDst)
+	if got := dst.Numbers(); len(got) != 4 {
+		t.Fatalf("dst numbers: %v, want #1, the dummy #2, #3 and the merge request #4", got)
+	}
+	if dst.Issues[2].Title != defaultDummyTitle || dst.Issues[4].Title != "mr" {
+		t.Errorf("#2 %q, #4 %q", dst.Issues[2].Title, dst.Issues[4].Title)
+	}
+	if len(dst.Milestones) != 3 || dst.Milestones[0].Title != "v1" {
+		t.Errorf("milestones: %#v", dst.Milestones)
+	}
+	// the milestone of the iid 2 is created as the first one of DST.
+	if n := dst.Issues[1].Milestone.Number; n != 1 {
+		t.Errorf("milestone of #1: %d", n)
+	}
+	if n := dst.Issues[3].Milestone.Number; n != 3 {
+		t.Errorf("group milestone of #3: %d", n)
+	}
+
+	// the milestones in the state are not created again.
+	if err := tr.DoMilestones(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if len(dst.Milestones) != 3 {
+		t.Errorf("milestones of the resumed run: %#v", dst.Milestones)
+	}
+}
@@ -1,4 +1,4 @@
-package main
+package mover
 
 import (
 	"context"
@@ -16,6 +16,11 @@ const (
 type IssueImportRequest struct {
 	IssueImport IssueImport           `json:"issue"`
 	Comments    []*IssueImportComment `json:"comments,omitempty"`
+	// Author is the DST login to import as when the token pool has its token.
+	Author string `json:"-"`
+	// Src is the SRC number of the issue, 0 of the dummies, to tell the
+	// imports not waited for.
+	Src int `json:"-"`
 }
 
 type IssueImport struct {
@@ -39,6 +44,7 @@ type IssueImportResponse struct {
 	ID               *int                `json:"id,omitempty"`
 	Status           *string             `json:"status,omitempty"`
 	URL              *string             `json:"url,omitempty"`
+	IssueURL         *string             `json:"issue_url,omitempty"`
 	ImportIssuesURL  *string             `json:"import_issues_url,omitempty"`
 	RepositoryURL    *string             `json:"repository_url,omitempty"`
 	CreatedAt        *time.Time          `json:"created_at,omitempty"`
@@ -56,6 +62,22 @@ type IssueImportError struct {
 	Code     *string `json:"code,omitempty"`
 }
 
+// GetStatus returns the Status field if it's non-nil, zero value otherwise.
+func (i *IssueImportResponse) GetStatus() string {
+	if i == nil || i.Status == nil {
+		return ""
+	}
+	return *i.Status
+}
+
+// GetIssueURL returns the IssueURL field if it's non-nil, zero value otherwise.
+func (i *IssueImportResponse) GetIssueURL() string {
+	if i == nil || i.IssueURL == nil {
+		return ""
+	}
+	return *i.IssueURL
+}
+
 func ImportIssue(client *github.Client, ctx context.Context, owner, repo string, issue *IssueImportRequest) (*IssueImportResponse, *github.Response, error) {
 	u := fmt.Sprintf("repos/%v/%v/import/issues", owner, repo)
 	req, err := client.NewRequest("POST", u, issue)
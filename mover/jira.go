@@ -0,0 +1,420 @@
+package mover
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/shurcooL/githubv4"
+)
+
+const (
+	jiraTimeLayout     = "2006-01-02T15:04:05.000-0700"
+	jiraDateLayout     = "2006-01-02"
+	jiraMaxResults     = 100
+	jiraLabelColor     = "ededed"
+	jiraComponentColor = "c5def5"
+	jiraStatusDone     = "done"
+	defaultJiraAPI     = "2"
+	jiraIssueFields    = "summary,description,comment,components,labels,fixVersions,assignee,reporter,status,created,updated,resolutiondate"
+	jiraLabelFields    = "components,labels"
+	jiraOrderBy        = " ORDER BY key ASC"
+)
+
+// Jira is the Source of the issues of a Jira project. The number of an
+// issue is the one of its key, the components and labels are labels and
+// the first fix version is the milestone. The descriptions and comments
+// are converted to Markdown from the wiki markup of the REST api v2, or
+// from the Atlassian Document Format of v3.
+type Jira struct {
+	Project  string
+	Endpoint string
+	// User is the email of the api token of Jira Cloud. The token is
+	// used as a bearer token of Jira Server or Data Center when it is
+	// empty, and the issues are searched by the api of them.
+	User  string
+	Token string
+	// API is the version of the REST api: 2 or 3.
+	API string
+	// JQL narrows the issues of the project down, like "type = Bug".
+	JQL    string
+	Client *http.Client
+
+	versions map[string]int
+}
+
+type jiraUser struct {
+	Name        string            `json:"name"`
+	AccountID   string            `json:"accountId"`
+	DisplayName string            `json:"displayName"`
+	AvatarURLs  map[string]string `json:"avatarUrls"`
+}
+
+// login returns the name of Jira Server, or the account id of Jira Cloud,
+// which has no name.
+func (u *jiraUser) login() string {
+	if u == nil {
+		return ""
+	}
+	if u.Name != "" {
+		return u.Name
+	}
+	return u.AccountID
+}
+
+func (u *jiraUser) avatarURL() string {
+	if u == nil {
+		return ""
+	}
+	return u.AvatarURLs["48x48"]
+}
+
+type jiraComment struct {
+	Author  *jiraUser       `json:"author"`
+	Body    json.RawMessage `json:"body"`
+	Created string          `json:"created"`
+}
+
+type jiraIssue struct {
+	Key    string `json:"key"`
+	Fields struct {
+		Summary     string          `json:"summary"`
+		Description json.RawMessage `json:"description"`
+		Created     string          `json:"created"`
+		Updated     string          `json:"updated"`
+		Resolved    string          `json:"resolutiondate"`
+		Reporter    *jiraUser       `json:"reporter"`
+		Assignee    *jiraUser       `json:"assignee"`
+		Labels      []string        `json:"labels"`
+		Components  []struct {
+			Name string `json:"name"`
+		} `json:"components"`
+		FixVersions []struct {
+			ID string `json:"id"`
+		} `json:"fixVersions"`
+		Status struct {
+			StatusCategory struct {
+				Key string `json:"key"`
+			} `json:"statusCategory"`
+		} `json:"status"`
+		Comment struct {
+			Comments []jiraComment `json:"comments"`
+			Total    int           `json:"total"`
+		} `json:"comment"`
+	} `json:"fields"`
+}
+
+type jiraVersion struct {
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Released    bool   `json:"released"`
+	ReleaseDate string `json:"releaseDate"`
+}
+
+func (j *Jira) Repo() string {
+	return j.Project
+}
+
+// Labels returns the components and the labels of the issues of the project.
+func (j *Jira) Labels(ctx context.Context) ([]Label, error) {
+	issues, err := j.search(ctx, jiraLabelFields)
+	if err != nil {
+		return nil, err
+	}
+	seen := map[string]bool{}
+	var labels []Label
+	add := func(name, color string) {
+		if seen[name] {
+			return
+		}
+		seen[name] = true
+		labels = append(labels, Label{Name: name, Color: color})
+	}
+	for _, v := range issues {
+		for _, c := range v.Fields.Components {
+			add(c.Name, jiraComponentColor)
+		}
+		for _, l := range v.Fields.Labels {
+			add(l, jiraLabelColor)
+		}
+	}
+	sort.Slice(labels, func(i, k int) bool { return labels[i].Name < labels[k].Name })
+	return labels, nil
+}
+
+// Milestones returns the versions of the project, numbered in their order.
+func (j *Jira) Milestones(ctx context.Context) ([]Milestone, error) {
+	versions, err := j.projectVersions(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var milestones []Milestone
+	for i, v := range versions {
+		m := Milestone{
+			Number:      i + 1,
+			Title:       v.Name,
+			Description: v.Description,
+			State:       "OPEN",
+		}
+		if v.Released {
+			m.State = "CLOSED"
+			m.Closed = true
+		}
+		if due, err := time.Parse(jiraDateLayout, v.ReleaseDate); err == nil {
+			m.DueOn = due
+		}
+		milestones = append(milestones, m)
+	}
+	return milestones, nil
+}
+
+func (j *Jira) projectVersions(ctx context.Context) ([]jiraVersion, error) {
+	var versions []jiraVersion
+	if err := j.get(ctx, fmt.Sprintf("project/%s/versions", url.PathEscape(j.Project)), nil, &versions); err != nil {
+		return nil, err
+	}
+	j.versions = map[string]int{}
+	for i, v := range versions {
+		j.versions[v.ID] = i + 1
+	}
+	return versions, nil
+}
+
+func (j *Jira) Issues(ctx context.Context, f *Filter) ([]Issue, error) {
+	if j.versions == nil {
+		if _, err := j.projectVersions(ctx); err != nil {
+			return nil, err
+		}
+	}
+	got, err := j.search(ctx, jiraIssueFields)
+	if err != nil {
+		return nil, err
+	}
+
+	var issues []Issue
+	for _, v := range got {
+		issue, err := j.toIssue(v)
+		if err != nil {
+			return nil, err
+		}
+		if !f.Match(&issue) {
+			continue
+		}
+		comments := v.Fields.Comment.Comments
+		if v.Fields.Comment.Total > len(comments) {
+			if comments, err = j.comments(ctx, v.Key); err != nil {
+				return nil, err
+			}
+		}
+		for _, c := range comments {
+			ic := IssueComment{Body: j.markdown(c.Body)}
+			ic.Author.Login = c.Author.login()
+			ic.Author.AvatarURL = c.Author.avatarURL()
+			ic.CreatedAt, _ = time.Parse(jiraTimeLayout, c.Created)
+			issue.Comments.Nodes = append(issue.Comments.Nodes, ic)
+		}
+		issue.Comments.TotalCount = githubv4.Int(len(issue.Comments.Nodes))
+		issues = append(issues, issue)
+	}
+	sort.Slice(issues, func(i, k int) bool { return issues[i].Number < issues[k].Number })
+	return issues, nil
+}
+
+// Pulls returns nothing, as Jira has no pull requests.
+func (j *Jira) Pulls(ctx context.Context, f *Filter) ([]Issue, error) {
+	return nil, nil
+}
+
+func (j *Jira) toIssue(v jiraIssue) (Issue, error) {
+	var issue Issue
+	n, err := jiraKeyNumber(v.Key)
+	if err != nil {
+		return issue, err
+	}
+	issue.Number = n
+	issue.Title = v.Fields.Summary
+	issue.Body = j.markdown(v.Fields.Description)
+	issue.URL = strings.TrimSuffix(j.Endpoint, "/") + "/browse/" + v.Key
+	issue.CreatedAt, _ = time.Parse(jiraTimeLayout, v.Fields.Created)
+	issue.UpdatedAt, _ = time.Parse(jiraTimeLayout, v.Fields.Updated)
+	issue.State = "OPEN"
+	if v.Fields.Status.StatusCategory.Key == jiraStatusDone {
+		issue.State = "CLOSED"
+		issue.Closed = true
+		issue.ClosedAt, _ = time.Parse(jiraTimeLayout, v.Fields.Resolved)
+	}
+	issue.Author.Login = v.Fields.Reporter.login()
+	issue.Author.AvatarURL = v.Fields.Reporter.avatarURL()
+	if login := v.Fields.Assignee.login(); login != "" {
+		issue.Assignees.Nodes = append(issue.Assignees.Nodes, struct{ Login string }{login})
+		issue.Assignees.TotalCount = 1
+	}
+	for _, c := range v.Fields.Components {
+		issue.Labels.Nodes = append(issue.Labels.Nodes, struct{ Name string }{c.Name})
+	}
+	for _, l := range v.Fields.Labels {
+		issue.Labels.Nodes = append(issue.Labels.Nodes, struct{ Name string }{l})
+	}
+	issue.Labels.TotalCount = githubv4.Int(len(issue.Labels.Nodes))
+	if len(v.Fields.FixVersions) > 0 {
+		issue.Milestone.Number = j.versions[v.Fields.FixVersions[0].ID]
+	}
+	return issue, nil
+}
+
+// jiraKeyNumber returns the number of the key: FOO-12 -> 12
+func jiraKeyNumber(key string) (int, error) {
+	i := strings.LastIndex(key, "-")
+	n, err := strconv.Atoi(key[i+1:])
+	if i < 0 || err != nil {
+		return 0, fmt.Errorf("invalid jira issue key: %s", key)
+	}
+	return n, nil
+}
+
+// markdown converts the description or the comment body of the api version.
+func (j *Jira) markdown(body json.RawMessage) string {
+	if len(body) == 0 || string(body) == "null" {
+		return ""
+	}
+	var s string
+	if err := json.Unmarshal(body, &s); err == nil {
+		return jiraWikiToMarkdown(s)
+	}
+	var doc adfNode
+	if err := json.Unmarshal(body, &doc); err != nil {
+		return string(body)
+	}
+	return adfToMarkdown(&doc)
+}
+
+func (j *Jira) search(ctx context.Context, fields string) ([]jiraIssue, error) {
+	jql := fmt.Sprintf("project = %q", j.Project)
+	if j.JQL != "" {
+		jql += " AND (" + j.JQL + ")"
+	}
+	jql += jiraOrderBy
+
+	if j.User != "" {
+		return j.searchJQL(ctx, jql, fields)
+	}
+
+	var issues []jiraIssue
+	for start := 0; ; {
+		var got struct {
+			Issues []jiraIssue `json:"issues"`
+			Total  int         `json:"total"`
+		}
+		q := url.Values{
+			"jql":        {jql},
+			"fields":     {fields},
+			"startAt":    {strconv.Itoa(start)},
+			"maxResults": {strconv.Itoa(jiraMaxResults)},
+		}
+		if err := j.get(ctx, "search", q, &got); err != nil {
+			return nil, err
+		}
+		issues = append(issues, got.Issues...)
+		start += len(got.Issues)
+		if len(got.Issues) == 0 || start >= got.Total {
+			break
+		}
+	}
+	return issues, nil
+}
+
+// searchJQL is search of Jira Cloud, which pages the issues by the token
+// of the next page instead of the start, as search is removed from it.
+func (j *Jira) searchJQL(ctx context.Context, jql, fields string) ([]jiraIssue, error) {
+	var issues []jiraIssue
+	for token := ""; ; {
+		var got struct {
+			Issues        []jiraIssue `json:"issues"`
+			NextPageToken string      `json:"nextPageToken"`
+			IsLast        bool        `json:"isLast"`
+		}
+		q := url.Values{
+			"jql":        {jql},
+			"fields":     {fields},
+			"maxResults": {strconv.Itoa(jiraMaxResults)},
+		}
+		if token != "" {
+			q.Set("nextPageToken", token)
+		}
+		if err := j.get(ctx, "search/jql", q, &got); err != nil {
+			return nil, err
+		}
+		issues = append(issues, got.Issues...)
+		if got.IsLast || got.NextPageToken == "" {
+			break
+		}
+		token = got.NextPageToken
+	}
+	return issues, nil
+}
+
+func (j *Jira) comments(ctx context.Context, key string) ([]jiraComment, error) {
+	var comments []jiraComment
+	for start := 0; ; {
+		var got struct {
+			Comments []jiraComment `json:"comments"`
+			Total    int           `json:"total"`
+		}
+		q := url.Values{"startAt": {strconv.Itoa(start)}, "maxResults": {strconv.Itoa(jiraMaxResults)}}
+		if err := j.get(ctx, fmt.Sprintf("issue/%s/comment", url.PathEscape(key)), q, &got); err != nil {
+			return nil, err
+		}
+		comments = append(comments, got.Comments...)
+		start += len(got.Comments)
+		if len(got.Comments) == 0 || start >= got.Total {
+			break
+		}
+	}
+	return comments, nil
+}
+
+func (j *Jira) get(ctx context.Context, resource string, q url.Values, v interface{}) error {
+	api := j.API
+	if api == "" {
+		api = defaultJiraAPI
+	}
+	u := fmt.Sprintf("%s/rest/api/%s/%s", strings.TrimSuffix(j.Endpoint, "/"), api, resource)
+	if len(q) > 0 {
+		u += "?" + q.Encode()
+	}
+	req, err := http.NewRequest("GET", u, nil)
+	if err != nil {
+		return err
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Accept", "application/json")
+	if j.User != "" {
+		req.SetBasicAuth(j.User, j.Token)
+	} else if j.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+j.Token)
+	}
+	client := j.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		var e struct {
+			ErrorMessages []string `json:"errorMessages"`
+		}
+		json.NewDecoder(resp.Body).Decode(&e)
+		return fmt.Errorf("jira: GET %s: %s: %s", resource, resp.Status, strings.Join(e.ErrorMessages, ", "))
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
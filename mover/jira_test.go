@@ -0,0 +1,269 @@
+package mover
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+const testJiraProject = "FOO"
+
+// newJiraStub serves the versions, issues and comments of testJiraProject,
+// one issue per page of the search: search/jql of Jira Cloud by the basic
+// auth, and search of Jira Server by the bearer token.
+func newJiraStub(t *testing.T) *httptest.Server {
+	alice := map[string]interface{}{"name": "alice", "avatarUrls": map[string]string{"48x48": "https://jira.example.com/alice.png"}}
+	cloud := map[string]interface{}{"accountId": "5b10ac8d82e05b22cc7d4ef5"}
+	issues := []interface{}{
+		map[string]interface{}{
+			"key": "FOO-1",
+			"fields": map[string]interface{}{
+				"summary": "first", "description": "h2. Steps\n* run *it*\n{code:go}\nx := *y*\n{code}",
+				"created": "2019-01-01T00:00:00.000+0000", "resolutiondate": "2019-01-02T00:00:00.000+0000",
+				"reporter": alice, "assignee": cloud, "labels": []string{"backend"},
+				"components":  []interface{}{map[string]string{"name": "api"}},
+				"fixVersions": []interface{}{map[string]string{"id": "10001"}},
+				"status":      map[string]interface{}{"statusCategory": map[string]string{"key": "done"}},
+				"comment": map[string]interface{}{
+					"total":    2,
+					"comments": []interface{}{map[string]interface{}{"body": "only the first", "author": alice}},
+				},
+			},
+		},
+		map[string]interface{}{
+			"key": "FOO-3",
+			"fields": map[string]interface{}{
+				"summary": "third", "created": "2019-01-03T00:00:00.000+0000", "reporter": alice,
+				"labels": []string{"backend"},
+				"status": map[string]interface{}{"statusCategory": map[string]string{"key": "indeterminate"}},
+			},
+		},
+	}
+
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, token, basic := r.BasicAuth()
+		server := r.Header.Get("Authorization") == "Bearer jira-token"
+		if !server && (!basic || user != "alice@example.com" || token != "jira-token") {
+			w.WriteHeader(http.StatusUnauthorized)
+			json.NewEncoder(w).Encode(map[string][]string{"errorMessages": {"unauthorized"}})
+			return
+		}
+		switch r.URL.Path {
+		case "/rest/api/2/project/FOO/versions":
+			json.NewEncoder(w).Encode([]interface{}{
+				map[string]interface{}{"id": "10000", "name": "v1", "released": true},
+				map[string]interface{}{"id": "10001", "name": "v2", "releaseDate": "2020-02-01"},
+			})
+		case "/rest/api/2/search", "/rest/api/2/search/jql":
+			if jql := r.URL.Query().Get("jql"); !strings.HasPrefix(jql, `project = "FOO"`) {
+				t.Errorf("jql: %s", jql)
+			}
+			if basic == strings.HasSuffix(r.URL.Path, "/search") {
+				w.WriteHeader(http.StatusGone)
+				json.NewEncoder(w).Encode(map[string][]string{"errorMessages": {"the api is not of this deployment"}})
+				return
+			}
+			start, _ := strconv.Atoi(r.URL.Query().Get("startAt"))
+			if basic {
+				start, _ = strconv.Atoi(strings.TrimPrefix(r.URL.Query().Get("nextPageToken"), "page-"))
+			}
+			got := []interface{}{}
+			if start < len(issues) {
+				got = issues[start : start+1]
+			}
+			if !basic {
+				json.NewEncoder(w).Encode(map[string]interface{}{"issues": got, "total": len(issues)})
+				return
+			}
+			v := map[string]interface{}{"issues": got, "isLast": start+1 >= len(issues)}
+			if start+1 < len(issues) {
+				v["nextPageToken"] = "page-" + strconv.Itoa(start+1)
+			}
+			json.NewEncoder(w).Encode(v)
+		case "/rest/api/2/issue/FOO-1/comment":
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"total": 2,
+				"comments": []interface{}{
+					map[string]interface{}{"body": "only the first", "author": alice},
+					map[string]interface{}{"body": "see [the docs|https://example.com]", "author": cloud},
+				},
+			})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			json.NewEncoder(w).Encode(map[string][]string{"errorMessages": {"not found"}})
+		}
+	}))
+}
+
+func TestJiraSource(t *testing.T) {
+	s := newJiraStub(t)
+	defer s.Close()
+	j := &Jira{Project: testJiraProject, Endpoint: s.URL, User: "alice@example.com", Token: "jira-token"}
+	ctx := context.Background()
+
+	labels, err := j.Labels(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(labels) != 2 || labels[0].Name != "api" || labels[0].Color != jiraComponentColor || labels[1].Name != "backend" {
+		t.Errorf("labels: %#v", labels)
+	}
+
+	milestones, err := j.Milestones(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(milestones) != 2 || !milestones[0].Closed || milestones[1].Number != 2 || milestones[1].DueOn.IsZero() {
+		t.Errorf("milestones: %#v", milestones)
+	}
+
+	f, _ := NewFilter(filterStateAll, "", "", "", "", "", "")
+	issues, err := j.Issues(ctx, f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(issues) != 2 || issues[0].Number != 1 || issues[1].Number != 3 {
+		t.Fatalf("issues: %#v", issues)
+	}
+	first := issues[0]
+	if !first.Closed || first.ClosedAt.IsZero() || first.Milestone.Number != 2 || len(first.Labels.Nodes) != 2 {
+		t.Errorf("first: %#v", first)
+	}
+	if first.Author.Login != "alice" || first.Assignees.Nodes[0].Login != "5b10ac8d82e05b22cc7d4ef5" {
+		t.Errorf("first: author %#v, assignees %#v", first.Author, first.Assignees)
+	}
+	if want := "## Steps\n- run **it**\n```go\nx := *y*\n```"; first.Body != want {
+		t.Errorf("first body: %q, want %q", first.Body, want)
+	}
+	if len(first.Comments.Nodes) != 2 || first.Comments.Nodes[1].Body != "see [the docs](https://example.com)" {
+		t.Errorf("first comments: %#v", first.Comments.Nodes)
+	}
+	if issues[1].Closed {
+		t.Errorf("third is closed")
+	}
+
+	pulls, err := j.Pulls(ctx, f)
+	if err != nil || len(pulls) != 0 {
+		t.Errorf("pulls: %#v, %v", pulls, err)
+	}
+
+	// Jira Server is searched by the start.
+	server := &Jira{Project: testJiraProject, Endpoint: s.URL, Token: "jira-token"}
+	issues, err = server.Issues(ctx, f)
+	if err != nil || len(issues) != 2 || issues[1].Number != 3 {
+		t.Errorf("issues of jira server: %#v, %v", issues, err)
+	}
+
+	j.Token = "wrong"
+	if _, err := j.Milestones(ctx); err == nil || !strings.Contains(err.Error(), "unauthorized") {
+		t.Errorf("unauthorized: %v", err)
+	}
+}
+
+func TestJiraWikiToMarkdown(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"h1. Title", "# Title"},
+		{"h3. *Sub* title", "### **Sub** title"},
+		{"a *bold* and _italic_ word", "a **bold** and _italic_ word"},
+		{"use {{a *b* c}} here", "use `a *b* c` here"},
+		{"a -deleted- word", "a ~~deleted~~ word"},
+		{"[the docs|https://example.com/docs]", "[the docs](https://example.com/docs)"},
+		{"[https://example.com]", "<https://example.com>"},
+		{"ping [~alice]", "ping @alice"},
+		{"!screen.png|thumbnail!", "![](screen.png)"},
+		{"* one\n** two\n# three\n## four", "- one\n  - two\n1. three\n   1. four"},
+		{"bq. quoted", "> quoted"},
+		{"{quote}\nfirst\nsecond\n{quote}", "> first\n> second"},
+		{"----", "---"},
+		{"||a||b||\n|1|2|", "| a | b |\n| --- | --- |\n| 1 | 2 |"},
+		{"{noformat}\n*raw* [x|y]\n{noformat}", "```\n*raw* [x|y]\n```"},
+		{"{code:title=a.rb|language=ruby}\nputs 1\n{code}", "```ruby\nputs 1\n```"},
+		{"{color:red}red{color} text", "red text"},
+		{"2 * 3 - 1 = 5", "2 * 3 - 1 = 5"},
+	}
+	for _, tt := range tests {
+		if got := jiraWikiToMarkdown(tt.in); got != tt.want {
+			t.Errorf("jiraWikiToMarkdown(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestADFToMarkdown(t *testing.T) {
+	doc := `{"type":"doc","version":1,"content":[
+		{"type":"heading","attrs":{"level":2},"content":[{"type":"text","text":"Steps"}]},
+		{"type":"paragraph","content":[
+			{"type":"text","text":"run "},
+			{"type":"text","text":"it","marks":[{"type":"strong"}]},
+			{"type":"text","text":" with "},
+			{"type":"text","text":"go","marks":[{"type":"code"}]},
+			{"type":"text","text":", see "},
+			{"type":"text","text":"docs","marks":[{"type":"link","attrs":{"href":"https://example.com"}}]},
+			{"type":"text","text":" "},
+			{"type":"mention","attrs":{"id":"1","text":"@alice"}}
+		]},
+		{"type":"bulletList","content":[
+			{"type":"listItem","content":[
+				{"type":"paragraph","content":[{"type":"text","text":"one"}]},
+				{"type":"orderedList","content":[
+					{"type":"listItem","content":[{"type":"paragraph","content":[{"type":"text","text":"two"}]}]}
+				]}
+			]}
+		]},
+		{"type":"codeBlock","attrs":{"language":"go"},"content":[{"type":"text","text":"x := 1"}]},
+		{"type":"blockquote","content":[{"type":"paragraph","content":[{"type":"text","text":"quoted"}]}]}
+	]}`
+	want := "## Steps\n\nrun **it** with `go`, see [docs](https://example.com) @alice\n\n" +
+		"- one\n  1. two\n\n```go\nx := 1\n```\n\n> quoted"
+	j := &Jira{API: "3"}
+	if got := j.markdown(json.RawMessage(doc)); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestExecJira(t *testing.T) {
+	s := newJiraStub(t)
+	defer s.Close()
+	f := newFakeGitHub()
+	defer f.Close()
+	dir := testDir(t)
+	defer os.RemoveAll(dir)
+
+	o := testOptions(f, dir)
+	o.SrcType = SrcJira
+	o.Src = testJiraProject
+	o.SrcEndpoint = s.URL
+	o.SrcToken = "jira-token"
+	o.JiraUser = "alice@example.com"
+
+	tr, err := New(context.Background(), o, testShared())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := tr.Exec(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	dst := f.Repo(testDst)
+	if got := dst.Numbers(); len(got) != 3 {
+		t.Fatalf("dst numbers: %v, want #1, the dummy #2 and #3", got)
+	}
+	if dst.Issues[1].Title != "first" || dst.Issues[2].Title != defaultDummyTitle || dst.Issues[3].Title != "third" {
+		t.Errorf("#1 %q, #2 %q, #3 %q", dst.Issues[1].Title, dst.Issues[2].Title, dst.Issues[3].Title)
+	}
+	if len(dst.Milestones) != 2 || len(dst.Labels) < 2 {
+		t.Errorf("milestones: %#v, labels: %#v", dst.Milestones, dst.Labels)
+	}
+
+	o.SrcEndpoint = defaultEndpoint
+	if _, err := New(context.Background(), o, testShared()); err == nil {
+		t.Error("jira without the endpoint")
+	}
+}
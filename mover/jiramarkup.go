@@ -0,0 +1,294 @@
+package mover
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+var (
+	reJiraCode      = regexp.MustCompile(`(?s)\{(code|noformat)(?::([^}]*))?\}\n?(.*?)\n?\{(?:code|noformat)\}`)
+	reJiraHeading   = regexp.MustCompile(`^h([1-6])\.\s+(.*)$`)
+	reJiraQuote     = regexp.MustCompile(`^bq\.\s+(.*)$`)
+	reJiraList      = regexp.MustCompile(`^([*#-]+)\s+(.*)$`)
+	reJiraRule      = regexp.MustCompile(`^-{4,}\s*$`)
+	reJiraMono      = regexp.MustCompile(`\{\{(.+?)\}\}`)
+	reJiraBold      = regexp.MustCompile(`(^|[\s(\[])\*([^\s*](?:[^*]*[^\s*])?)\*($|[\s).,:;!?\]])`)
+	reJiraStrike    = regexp.MustCompile(`(^|[\s(])-([^\s-](?:[^-]*[^\s-])?)-($|[\s).,:;!?])`)
+	reJiraLink      = regexp.MustCompile(`\[([^|\]\[]+)\|([^\]\[]+)\]`)
+	reJiraURL       = regexp.MustCompile(`\[((?:https?|mailto|ftp):[^\]\[|]+)\]`)
+	reJiraMention   = regexp.MustCompile(`\[~(?:accountid:)?([^\]]+)\]`)
+	reJiraImage     = regexp.MustCompile(`!([^\s!|]+\.(?:png|jpe?g|gif|svg))(?:\|[^!]*)?!`)
+	reJiraColor     = regexp.MustCompile(`\{color(?::[^}]*)?\}`)
+	reJiraCodeBlock = regexp.MustCompile("\x00code(\\d+)\x00")
+)
+
+// jiraWikiToMarkdown converts the Jira wiki markup to Markdown. The code
+// blocks are kept as they are.
+func jiraWikiToMarkdown(s string) string {
+	s = strings.Replace(s, "\r\n", "\n", -1)
+
+	var blocks []string
+	s = reJiraCode.ReplaceAllStringFunc(s, func(m string) string {
+		sm := reJiraCode.FindStringSubmatch(m)
+		lang := sm[2]
+		if strings.Contains(lang, "=") {
+			lang = ""
+			for _, p := range strings.Split(sm[2], "|") {
+				if kv := strings.SplitN(p, "=", 2); len(kv) == 2 && kv[0] == "language" {
+					lang = kv[1]
+				}
+			}
+		}
+		if sm[1] == "noformat" {
+			lang = ""
+		}
+		blocks = append(blocks, "```"+lang+"\n"+sm[3]+"\n```")
+		return fmt.Sprintf("\x00code%d\x00", len(blocks)-1)
+	})
+
+	var out []string
+	quote := false
+	header := false
+	for _, line := range strings.Split(s, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "{quote}" {
+			quote = !quote
+			continue
+		}
+		var md string
+		switch {
+		case reJiraRule.MatchString(trimmed):
+			md = "---"
+		case reJiraHeading.MatchString(trimmed):
+			m := reJiraHeading.FindStringSubmatch(trimmed)
+			md = strings.Repeat("#", int(m[1][0]-'0')) + " " + jiraInline(m[2])
+		case reJiraQuote.MatchString(trimmed):
+			md = "> " + jiraInline(reJiraQuote.FindStringSubmatch(trimmed)[1])
+		case reJiraList.MatchString(trimmed):
+			m := reJiraList.FindStringSubmatch(trimmed)
+			indent := ""
+			for _, c := range m[1][:len(m[1])-1] {
+				if c == '#' {
+					indent += "   "
+				} else {
+					indent += "  "
+				}
+			}
+			marker := "-"
+			if m[1][len(m[1])-1] == '#' {
+				marker = "1."
+			}
+			md = indent + marker + " " + jiraInline(m[2])
+		case strings.HasPrefix(trimmed, "||"):
+			cells := strings.Split(strings.Trim(trimmed, "|"), "||")
+			for i := range cells {
+				cells[i] = jiraInline(strings.TrimSpace(cells[i]))
+			}
+			md = "| " + strings.Join(cells, " | ") + " |\n|" + strings.Repeat(" --- |", len(cells))
+			header = true
+		case strings.HasPrefix(trimmed, "|"):
+			cells := strings.Split(strings.Trim(trimmed, "|"), "|")
+			for i := range cells {
+				cells[i] = jiraInline(strings.TrimSpace(cells[i]))
+			}
+			md = "| " + strings.Join(cells, " | ") + " |"
+			if !header {
+				// Markdown tables need a header.
+				md = "|" + strings.Repeat("   |", len(cells)) + "\n|" + strings.Repeat(" --- |", len(cells)) + "\n" + md
+				header = true
+			}
+		default:
+			md = jiraInline(line)
+		}
+		if !strings.HasPrefix(trimmed, "|") {
+			header = false
+		}
+		if quote {
+			md = "> " + md
+		}
+		out = append(out, md)
+	}
+
+	md := strings.Join(out, "\n")
+	return reJiraCodeBlock.ReplaceAllStringFunc(md, func(m string) string {
+		var i int
+		fmt.Sscanf(reJiraCodeBlock.FindStringSubmatch(m)[1], "%d", &i)
+		return blocks[i]
+	})
+}
+
+// jiraInline converts the inline markup of a line, except in monospace.
+func jiraInline(s string) string {
+	var out strings.Builder
+	for {
+		loc := reJiraMono.FindStringSubmatchIndex(s)
+		if loc == nil {
+			out.WriteString(jiraText(s))
+			break
+		}
+		out.WriteString(jiraText(s[:loc[0]]))
+		out.WriteString("`" + s[loc[2]:loc[3]] + "`")
+		s = s[loc[1]:]
+	}
+	return out.String()
+}
+
+func jiraText(s string) string {
+	s = reJiraColor.ReplaceAllString(s, "")
+	s = reJiraImage.ReplaceAllString(s, "![]($1)")
+	s = reJiraMention.ReplaceAllString(s, "@$1")
+	s = reJiraLink.ReplaceAllString(s, "[$1]($2)")
+	s = reJiraURL.ReplaceAllString(s, "<$1>")
+	s = reJiraBold.ReplaceAllString(s, "$1**$2**$3")
+	s = reJiraStrike.ReplaceAllString(s, "$1~~$2~~$3")
+	return s
+}
+
+// adfNode is a node of the Atlassian Document Format of the Jira api v3.
+type adfNode struct {
+	Type    string                 `json:"type"`
+	Text    string                 `json:"text"`
+	Attrs   map[string]interface{} `json:"attrs"`
+	Marks   []adfMark              `json:"marks"`
+	Content []adfNode              `json:"content"`
+}
+
+type adfMark struct {
+	Type  string                 `json:"type"`
+	Attrs map[string]interface{} `json:"attrs"`
+}
+
+func (n *adfNode) attr(key string) string {
+	if v, ok := n.Attrs[key]; ok && v != nil {
+		return fmt.Sprint(v)
+	}
+	return ""
+}
+
+// adfToMarkdown converts the document to Markdown.
+func adfToMarkdown(n *adfNode) string {
+	return strings.TrimSpace(adfBlocks(n.Content, ""))
+}
+
+func adfBlocks(nodes []adfNode, indent string) string {
+	var blocks []string
+	for i := range nodes {
+		if b := adfBlock(&nodes[i], indent); b != "" {
+			blocks = append(blocks, b)
+		}
+	}
+	return strings.Join(blocks, "\n\n")
+}
+
+func adfBlock(n *adfNode, indent string) string {
+	switch n.Type {
+	case "paragraph":
+		return indent + adfInline(n.Content)
+	case "heading":
+		level := 1
+		fmt.Sscanf(n.attr("level"), "%d", &level)
+		return strings.Repeat("#", level) + " " + adfInline(n.Content)
+	case "codeBlock":
+		var text strings.Builder
+		for _, c := range n.Content {
+			text.WriteString(c.Text)
+		}
+		return "```" + n.attr("language") + "\n" + text.String() + "\n```"
+	case "blockquote", "panel":
+		lines := strings.Split(adfBlocks(n.Content, ""), "\n")
+		for i := range lines {
+			lines[i] = "> " + lines[i]
+		}
+		return strings.Join(lines, "\n")
+	case "rule":
+		return "---"
+	case "bulletList", "orderedList":
+		marker := "- "
+		if n.Type == "orderedList" {
+			marker = "1. "
+		}
+		var items []string
+		for _, item := range n.Content {
+			var parts []string
+			for i := range item.Content {
+				c := &item.Content[i]
+				if c.Type == "bulletList" || c.Type == "orderedList" {
+					parts = append(parts, adfBlock(c, indent+strings.Repeat(" ", len(marker))))
+					continue
+				}
+				parts = append(parts, adfBlock(c, ""))
+			}
+			if len(parts) > 0 && !strings.HasPrefix(parts[0], indent+" ") {
+				parts[0] = indent + marker + parts[0]
+			}
+			items = append(items, strings.Join(parts, "\n"))
+		}
+		return strings.Join(items, "\n")
+	case "table":
+		var rows []string
+		for i, row := range n.Content {
+			var cells []string
+			for _, cell := range row.Content {
+				cells = append(cells, strings.Replace(adfBlocks(cell.Content, ""), "\n", " ", -1))
+			}
+			rows = append(rows, "| "+strings.Join(cells, " | ")+" |")
+			if i == 0 {
+				rows = append(rows, "|"+strings.Repeat(" --- |", len(cells)))
+			}
+		}
+		return strings.Join(rows, "\n")
+	case "mediaSingle", "mediaGroup", "media":
+		return ""
+	}
+	if len(n.Content) > 0 {
+		return adfBlocks(n.Content, indent)
+	}
+	return adfInline([]adfNode{*n})
+}
+
+func adfInline(nodes []adfNode) string {
+	var out strings.Builder
+	for i := range nodes {
+		n := &nodes[i]
+		switch n.Type {
+		case "text":
+			out.WriteString(adfMarks(n.Text, n.Marks))
+		case "hardBreak":
+			out.WriteString("\n")
+		case "mention":
+			text := n.attr("text")
+			if !strings.HasPrefix(text, "@") {
+				text = "@" + text
+			}
+			out.WriteString(text)
+		case "emoji":
+			out.WriteString(n.attr("shortName"))
+		case "inlineCard":
+			out.WriteString("<" + n.attr("url") + ">")
+		default:
+			out.WriteString(adfInline(n.Content))
+		}
+	}
+	return out.String()
+}
+
+func adfMarks(text string, marks []adfMark) string {
+	for _, m := range marks {
+		switch m.Type {
+		case "code":
+			text = "`" + text + "`"
+		case "strong":
+			text = "**" + text + "**"
+		case "em":
+			text = "_" + text + "_"
+		case "strike":
+			text = "~~" + text + "~~"
+		case "link":
+			if href, ok := m.Attrs["href"].(string); ok {
+				text = "[" + text + "](" + href + ")"
+			}
+		}
+	}
+	return text
+}
@@ -0,0 +1,98 @@
+package mover
+
+import (
+	"regexp"
+	"sort"
+
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/text"
+)
+
+// codeRanges returns the byte ranges of the code spans, code blocks and
+// fenced code blocks of the Markdown, sorted and merged.
+func codeRanges(source []byte) [][2]int {
+	var ranges [][2]int
+	add := func(start, stop int) {
+		if start < stop {
+			ranges = append(ranges, [2]int{start, stop})
+		}
+	}
+	addLines := func(n ast.Node) {
+		lines := n.Lines()
+		if lines.Len() > 0 {
+			add(lines.At(0).Start, lines.At(lines.Len()-1).Stop)
+		}
+	}
+
+	doc := goldmark.DefaultParser().Parse(text.NewReader(source))
+	ast.Walk(doc, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if !entering {
+			return ast.WalkContinue, nil
+		}
+		switch v := n.(type) {
+		case *ast.FencedCodeBlock:
+			if v.Info != nil {
+				add(v.Info.Segment.Start, v.Info.Segment.Stop)
+			}
+			addLines(v)
+			return ast.WalkSkipChildren, nil
+		case *ast.CodeBlock:
+			addLines(v)
+			return ast.WalkSkipChildren, nil
+		case *ast.CodeSpan:
+			start, stop := -1, -1
+			for c := v.FirstChild(); c != nil; c = c.NextSibling() {
+				if t, ok := c.(*ast.Text); ok {
+					if start < 0 {
+						start = t.Segment.Start
+					}
+					stop = t.Segment.Stop
+				}
+			}
+			add(start, stop)
+			return ast.WalkSkipChildren, nil
+		}
+		return ast.WalkContinue, nil
+	})
+
+	sort.Slice(ranges, func(i, j int) bool { return ranges[i][0] < ranges[j][0] })
+	var merged [][2]int
+	for _, r := range ranges {
+		if last := len(merged) - 1; last >= 0 && r[0] <= merged[last][1] {
+			if r[1] > merged[last][1] {
+				merged[last][1] = r[1]
+			}
+			continue
+		}
+		merged = append(merged, r)
+	}
+	return merged
+}
+
+// rewriteMarkdown rewrites the prose, links and the other markup of the
+// Markdown by prose, and its code by code, so that the replacements of the
+// texts do not break the code samples and logs.
+func rewriteMarkdown(s string, prose, code func(string) string) string {
+	source := []byte(s)
+	var out []byte
+	pos := 0
+	for _, r := range codeRanges(source) {
+		out = append(out, prose(string(source[pos:r[0]]))...)
+		out = append(out, code(string(source[r[0]:r[1]]))...)
+		pos = r[1]
+	}
+	out = append(out, prose(string(source[pos:]))...)
+	return string(out)
+}
+
+var reMention = regexp.MustCompile(`(^|[^\w@/` + "`" + `])@([A-Za-z0-9](?:[A-Za-z0-9-]*[A-Za-z0-9])?)\b`)
+
+// rewriteMentions replaces the logins of the @mentions by replace, leaving
+// the email addresses and the mentions of the unknown users as they are.
+func rewriteMentions(s string, replace func(string) string) string {
+	return reMention.ReplaceAllStringFunc(s, func(m string) string {
+		sub := reMention.FindStringSubmatch(m)
+		return sub[1] + "@" + replace(sub[2])
+	})
+}
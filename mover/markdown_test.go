@@ -0,0 +1,97 @@
+package mover
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRewriteMarkdown(t *testing.T) {
+	upper := strings.ToUpper
+	same := func(s string) string { return s }
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{"prose", "see host and [host](http://host/x)", "SEE HOST AND [HOST](HTTP://HOST/X)"},
+		{"code span", "run `host -v` on host", "RUN `host -v` ON HOST"},
+		{"fenced", "host\n\n```sh\nhost\n```\n\nhost\n", "HOST\n\n```sh\nhost\n```\n\nHOST\n"},
+		{"indented", "host\n\n    host\n    host\n\nhost", "HOST\n\n    host\n    host\n\nHOST"},
+		{"list", "- host\n  ```\n  host\n  ```\n", "- HOST\n  ```\n  host\n  ```\n"},
+		{"reference", "[a][1]\n\n[1]: http://host/", "[A][1]\n\n[1]: HTTP://HOST/"},
+	}
+	for _, tt := range tests {
+		if got := rewriteMarkdown(tt.in, upper, same); got != tt.want {
+			t.Errorf("%s: %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestRewriteMentions(t *testing.T) {
+	users := map[string]string{"alice": "alice-dst", "bob": "robert"}
+	replace := func(login string) string {
+		if v, ok := users[login]; ok {
+			return v
+		}
+		return login
+	}
+	in := "@alice and @bob, cc @carol (@alice) alice@example.com @alicex"
+	want := "@alice-dst and @robert, cc @carol (@alice-dst) alice@example.com @alicex"
+	if got := rewriteMentions(in, replace); got != want {
+		t.Errorf("%q, want %q", got, want)
+	}
+}
+
+func TestExecMarkdown(t *testing.T) {
+	f := newFakeGitHub()
+	defer f.Close()
+	f.AddUser("alice-dst")
+	seedSrc(f)
+	src := f.Repo(testSrc)
+	src.Issues[1].Body = "@alice see https://src.example.com/foo and " + f.URL + "/" + testSrc + "/issues/2\n\n" +
+		"```\ncurl https://src.example.com/foo # @alice\n```\n\n`src.example.com` PROJ-1"
+	src.Issues[1].Comments.Nodes[0].Body = "`PROJ-2` and PROJ-3"
+	dir := testDir(t)
+	defer os.RemoveAll(dir)
+	yml := `user:
+  - wrong: alice
+    right: alice-dst
+body:
+  - wrong: src.example.com
+    right: dst.example.com
+rules:
+  - wrong: 'PROJ-(\d+)'
+    right: 'FOO-$1'
+    regex: true
+    code: true
+`
+	if err := ioutil.WriteFile(filepath.Join(dir, "replace.yml"), []byte(yml), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	o := testOptions(f, dir)
+	o.Markdown = true
+	o.RewriteMentions = true
+	o.RewriteLinks = true
+	tr, err := New(context.Background(), o, testShared())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := tr.Exec(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	first := f.Repo(testDst).Issues[1]
+	want := "@alice-dst see https://dst.example.com/foo and " + f.URL + "/" + testDst + "/issues/2\n\n" +
+		"```\ncurl https://src.example.com/foo # @alice\n```\n\n`src.example.com` FOO-1"
+	if !strings.HasSuffix(first.Body, want) {
+		t.Errorf("#1 body: %q, want %q", first.Body, want)
+	}
+	if got := first.Comments.Nodes[0].Body; !strings.HasSuffix(got, "`FOO-2` and FOO-3") {
+		t.Errorf("#1 comment: %q", got)
+	}
+}
@@ -0,0 +1,100 @@
+// Package mover moves the labels, milestones, issues and pull requests of a
+// repository to another one, keeping their numbers. The repositories are
+// accessed through Source and Destination, and SRC and DST are the ones of
+// GitHub and GitHub Enterprise, which are also required by the discussions,
+// projects, wiki, cleanup and repository creation stages.
+package mover
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+const (
+	SrcGitHub = "github"
+	SrcGitLab = "gitlab"
+	SrcJira   = "jira"
+
+	DstGitHub = "github"
+	DstGitea  = "gitea"
+)
+
+func validSrcType(typ string) bool {
+	switch typ {
+	case SrcGitHub, SrcGitLab, SrcJira:
+		return true
+	}
+	return false
+}
+
+func validDstType(typ string) bool {
+	switch typ {
+	case DstGitHub, DstGitea:
+		return true
+	}
+	return false
+}
+
+// validSrc checks the name of the source of the type.
+func validSrc(typ, src string) error {
+	switch typ {
+	case SrcJira:
+		if src == "" || strings.ContainsAny(src, "/ ") {
+			return fmt.Errorf("invalid source project: %q, expected project key", src)
+		}
+		return nil
+	case SrcGitLab:
+		s := strings.Split(src, "/")
+		for _, v := range s {
+			if v == "" {
+				return fmt.Errorf("invalid source project: %q, expected group/name", src)
+			}
+		}
+		if len(s) < 2 {
+			return fmt.Errorf("invalid source project: %q, expected group/name", src)
+		}
+		return nil
+	}
+	if !validRepo(src) {
+		return fmt.Errorf("invalid source repository: %q, expected owner/name", src)
+	}
+	return nil
+}
+
+// Source is the repository to move from.
+type Source interface {
+	// Repo returns the name of the repository, like owner/name.
+	Repo() string
+	Labels(ctx context.Context) ([]Label, error)
+	Milestones(ctx context.Context) ([]Milestone, error)
+	// Issues returns the issues matching the filter, with their comments.
+	Issues(ctx context.Context, f *Filter) ([]Issue, error)
+	// Pulls returns the pull requests matching the filter, with their comments.
+	Pulls(ctx context.Context, f *Filter) ([]Issue, error)
+}
+
+// Destination is the repository to move to.
+type Destination interface {
+	// Repo returns the name of the repository, like owner/name.
+	Repo() string
+	CreateLabel(ctx context.Context, v Label) error
+	// CreateMilestone creates the milestone and returns its number, which
+	// the issues of the milestone are created with.
+	CreateMilestone(ctx context.Context, v Milestone) (int, error)
+	// ImportIssue imports the issue with its comments at once. The number of
+	// the issue is returned when wait is true, otherwise 0.
+	ImportIssue(ctx context.Context, input *IssueImportRequest, wait bool) (int, error)
+	// CreateIssue creates the issue, its comments, and closes it when the
+	// state of the request is closed. The number of the issue is returned,
+	// also with the error of the comments or the close after it is created.
+	CreateIssue(ctx context.Context, input *IssueAndCommentsRequest) (int, error)
+	// LastNumber returns the number of the latest issue or pull request,
+	// or 0 when there is none.
+	LastNumber(ctx context.Context) (int, error)
+	LockIssue(ctx context.Context, n int, reason string) error
+	// EnsureLabel creates the label when no label of its name exists, and
+	// reports whether it was created.
+	EnsureLabel(ctx context.Context, v Label) (bool, error)
+	UserExists(ctx context.Context, name string) bool
+}
@@ -0,0 +1,469 @@
+package mover
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	envPrefix = "MOVER_"
+
+	// the commands of the CLI, which take the flags of their own.
+	CmdMigrate  = "migrate"
+	CmdExport   = "export"
+	CmdImport   = "import"
+	CmdVerify   = "verify"
+	CmdUsers    = "users"
+	CmdStatus   = "status"
+	CmdFinalize = "finalize"
+)
+
+// Options are the settings of a transfer. They are taken from the defaults,
+// the config file, the environment variables and the flags, in ascending
+// order of precedence. The yaml keys are used by the config file and by the
+// defaults and the repositories of a manifest, and the environment variables
+// are the keys in upper case with MOVER_ prefix, like MOVER_SKIP_LABELS.
+// The tokens are also taken from SRC_TOKEN, DST_TOKEN, DST_ADMIN_TOKEN and
+// DST_TOKENS.
+type Options struct {
+	SrcType              string `yaml:"src_type"`
+	Src                  string `yaml:"src"`
+	Dst                  string `yaml:"dst"`
+	SrcEndpoint          string `yaml:"src_endpoint"`
+	DstEndpoint          string `yaml:"dst_endpoint"`
+	DstType              string `yaml:"dst_type"`
+	SrcToken             string `yaml:"src_token"`
+	DstToken             string `yaml:"dst_token"`
+	DstAdminToken        string `yaml:"dst_admin_token"`
+	DstTokens            string `yaml:"dst_tokens"`
+	DstUserTokens        string `yaml:"dst_user_tokens"`
+	SrcAppID             int    `yaml:"src_app_id"`
+	SrcAppInstallationID int    `yaml:"src_app_installation_id"`
+	SrcAppKey            string `yaml:"src_app_key"`
+	DstAppID             int    `yaml:"dst_app_id"`
+	DstAppInstallationID int    `yaml:"dst_app_installation_id"`
+	DstAppKey            string `yaml:"dst_app_key"`
+	IsImport             bool   `yaml:"import"`
+	SkipLabels           bool   `yaml:"skip_labels"`
+	SkipMilestones       bool   `yaml:"skip_milestones"`
+	State                string `yaml:"state"`
+	Labels               string `yaml:"labels"`
+	Milestone            string `yaml:"milestone"`
+	Author               string `yaml:"author"`
+	Since                string `yaml:"since"`
+	Until                string `yaml:"until"`
+	Numbers              string `yaml:"numbers"`
+	Align                string `yaml:"align"`
+	StatePath            string `yaml:"state_file"`
+	DummyTitle           string `yaml:"dummy_title"`
+	DummyBody            string `yaml:"dummy_body"`
+	DummyLabel           string `yaml:"dummy_label"`
+	DummyLock            bool   `yaml:"dummy_lock"`
+	Cleanup              string `yaml:"cleanup"`
+	TrashRepo            string `yaml:"trash_repo"`
+	Discussions          string `yaml:"discussions"`
+	DiscussionCategory   string `yaml:"discussion_category"`
+	Projects             string `yaml:"projects"`
+	Wiki                 bool   `yaml:"wiki"`
+	SrcWikiURL           string `yaml:"src_wiki_url"`
+	DstWikiURL           string `yaml:"dst_wiki_url"`
+	ReplacePath          string `yaml:"replace"`
+	CreateDst            bool   `yaml:"create_dst"`
+	NoDefaultLabels      bool   `yaml:"no_default_labels"`
+	Manifest             string `yaml:"manifest"`
+	SrcOrg               string `yaml:"src_org"`
+	DstOrg               string `yaml:"dst_org"`
+	Include              string `yaml:"include"`
+	Exclude              string `yaml:"exclude"`
+	Archived             bool   `yaml:"archived"`
+	Forks                bool   `yaml:"forks"`
+	BatchStatePath       string `yaml:"batch_state_file"`
+	ExportPath           string `yaml:"export_file"`
+	MROffset             int    `yaml:"mr_offset"`
+	JiraUser             string `yaml:"jira_user"`
+	JiraAPI              string `yaml:"jira_api"`
+	JiraJQL              string `yaml:"jira_jql"`
+	IssueHeader          string `yaml:"issue_header"`
+	CommentHeader        string `yaml:"comment_header"`
+	Footer               string `yaml:"footer"`
+	TimeZone             string `yaml:"time_zone"`
+	TimeFormat           string `yaml:"time_format"`
+	OriginFooter         bool   `yaml:"origin_footer"`
+	Backlink             bool   `yaml:"backlink"`
+	MovedNotice          string `yaml:"moved_notice"`
+	CloseSrc             bool   `yaml:"close_src"`
+	LockSrc              bool   `yaml:"lock_src"`
+	DisableIssues        bool   `yaml:"disable_issues"`
+	ArchiveSrc           bool   `yaml:"archive_src"`
+	MovedDescription     string `yaml:"moved_description"`
+	Markdown             bool   `yaml:"markdown"`
+	RewriteMentions      bool   `yaml:"rewrite_mentions"`
+	RewriteLinks         bool   `yaml:"rewrite_links"`
+	Confirm              string `yaml:"-"`
+	Config               string `yaml:"-"`
+	// Log is where the progress is printed, the standard output when nil.
+	Log io.Writer `yaml:"-"`
+}
+
+// DefaultOptions returns the default options. The files of the state, the
+// batch state and the export are left empty, so that the library does not
+// write to the working directory, and LoadOptions gives them for the CLI.
+func DefaultOptions() *Options {
+	return &Options{
+		SrcType:            SrcGitHub,
+		DstType:            DstGitHub,
+		SrcEndpoint:        defaultEndpoint,
+		DstEndpoint:        defaultEndpoint,
+		IsImport:           true,
+		State:              filterStateAll,
+		Align:              AlignFill,
+		DummyTitle:         defaultDummyTitle,
+		DummyBody:          defaultDummyBody,
+		DummyLabel:         defaultDummyLabel,
+		DummyLock:          true,
+		Cleanup:            CleanupNone,
+		Discussions:        DiscussionsNone,
+		DiscussionCategory: defaultDiscussionCategory,
+		Projects:           ProjectsNone,
+		JiraAPI:            defaultJiraAPI,
+		IssueHeader:        defaultIssueHeader,
+		CommentHeader:      defaultCommentHeader,
+		TimeZone:           defaultTimeZone,
+		TimeFormat:         defaultTimeFormat,
+		MovedNotice:        defaultMovedNotice,
+		DisableIssues:      true,
+		ArchiveSrc:         true,
+		MovedDescription:   defaultMovedDescription,
+	}
+}
+
+// FlagSet returns the flags of the command. They set the options, and
+// show the current values of them as the defaults.
+func (o *Options) FlagSet(name string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.StringVar(&o.Config, "config", o.Config, "config file: mover.yml")
+	fs.StringVar(&o.SrcType, "src-type", o.SrcType, "source type: github, gitlab or jira")
+	fs.StringVar(&o.Src, "src", o.Src, "source repository: foo/bar, project path of gitlab: group/sub/bar, or project key of jira: FOO")
+	fs.StringVar(&o.Dst, "dst", o.Dst, "destination repository: foo/bar")
+	fs.StringVar(&o.SrcEndpoint, "src-endpoint", o.SrcEndpoint, "source api endpoint")
+	fs.StringVar(&o.DstEndpoint, "dst-endpoint", o.DstEndpoint, "destination api endpoint")
+	fs.StringVar(&o.DstType, "dst-type", o.DstType, "destination type: github or gitea")
+	fs.IntVar(&o.SrcAppID, "src-app-id", o.SrcAppID, "github app id to authenticate to src instead of SRC_TOKEN")
+	fs.IntVar(&o.SrcAppInstallationID, "src-app-installation-id", o.SrcAppInstallationID, "installation id of the src github app")
+	fs.StringVar(&o.SrcAppKey, "src-app-key", o.SrcAppKey, "private key file of the src github app")
+	fs.IntVar(&o.DstAppID, "dst-app-id", o.DstAppID, "github app id to authenticate to dst instead of DST_TOKEN")
+	fs.IntVar(&o.DstAppInstallationID, "dst-app-installation-id", o.DstAppInstallationID, "installation id of the dst github app")
+	fs.StringVar(&o.DstAppKey, "dst-app-key", o.DstAppKey, "private key file of the dst github app")
+	fs.StringVar(&o.DstUserTokens, "dst-user-tokens", o.DstUserTokens, "yaml file of dst logins and their tokens to author the issues and comments as")
+	fs.StringVar(&o.StatePath, "state-file", o.StatePath, "file to record the src to dst number map")
+	fs.Var(&listValue{p: &o.ReplacePath}, "replace", "replacement map files instead of replace.yml, merged in order: org.yml,repo.yml")
+	fs.StringVar(&o.State, "state", o.State, "filter by state: all, open or closed")
+	fs.StringVar(&o.Labels, "labels", o.Labels, "filter by labels: bug,help wanted")
+	fs.StringVar(&o.Milestone, "milestone", o.Milestone, "filter by milestone number, * or none")
+	fs.StringVar(&o.Author, "author", o.Author, "filter by author login")
+	fs.StringVar(&o.Since, "since", o.Since, "filter by created at or after: 2019-01-01")
+	fs.StringVar(&o.Until, "until", o.Until, "filter by created before: 2020-01-01")
+	fs.StringVar(&o.Numbers, "numbers", o.Numbers, "filter by number range: 10-200")
+	fs.StringVar(&o.Align, "align", o.Align, "number alignment: strict, fill or none")
+	fs.StringVar(&o.Discussions, "discussions", o.Discussions, "discussions: none, migrate or issues")
+	fs.IntVar(&o.MROffset, "mr-offset", o.MROffset, "number added to gitlab merge requests, 0 for the last issue number")
+	fs.StringVar(&o.JiraUser, "jira-user", o.JiraUser, "jira cloud user email of the src token, or empty for a bearer token")
+	fs.StringVar(&o.JiraAPI, "jira-api", o.JiraAPI, "jira rest api version: 2 or 3")
+	fs.StringVar(&o.JiraJQL, "jira-jql", o.JiraJQL, "jql to filter the jira issues: type = Bug")
+	if name == CmdVerify || name == CmdUsers || name == CmdStatus {
+		return fs
+	}
+	if name == CmdFinalize {
+		fs.StringVar(&o.Confirm, "confirm", o.Confirm, "name of the source repository to confirm the finalize: foo/bar")
+		fs.BoolVar(&o.DisableIssues, "disable-issues", o.DisableIssues, "disable the issues of the source repository")
+		fs.BoolVar(&o.ArchiveSrc, "archive-src", o.ArchiveSrc, "archive the source repository")
+		fs.StringVar(&o.MovedDescription, "moved-description", o.MovedDescription, "template of the description of the source repository, empty to keep it")
+		return fs
+	}
+
+	fs.BoolVar(&o.IsImport, "import", o.IsImport, "use issue import api")
+	fs.BoolVar(&o.SkipLabels, "skip-labels", o.SkipLabels, "skip create labels")
+	fs.BoolVar(&o.SkipMilestones, "skip-milestones", o.SkipMilestones, "skip create milestones")
+	fs.StringVar(&o.ExportPath, "export-file", o.ExportPath, "file to export to and import from")
+	if name == CmdExport {
+		return fs
+	}
+
+	fs.StringVar(&o.DummyTitle, "dummy-title", o.DummyTitle, "title of dummy issues")
+	fs.StringVar(&o.DummyBody, "dummy-body", o.DummyBody, "body of dummy issues")
+	fs.StringVar(&o.DummyLabel, "dummy-label", o.DummyLabel, "label for dummy issues, empty for none")
+	fs.BoolVar(&o.DummyLock, "dummy-lock", o.DummyLock, "lock dummy issues")
+	fs.StringVar(&o.Cleanup, "cleanup", o.Cleanup, "cleanup of dummy issues: none, delete or transfer")
+	fs.StringVar(&o.TrashRepo, "trash-repo", o.TrashRepo, "repository to transfer dummy issues to: foo/trash")
+	fs.StringVar(&o.DiscussionCategory, "discussion-category", o.DiscussionCategory, "fallback category of discussions")
+	fs.StringVar(&o.Projects, "projects", o.Projects, "projects: none, classic, v2 or all")
+	fs.BoolVar(&o.Wiki, "wiki", o.Wiki, "move wiki")
+	fs.StringVar(&o.SrcWikiURL, "src-wiki-url", o.SrcWikiURL, "source wiki git url instead of the one of -src")
+	fs.StringVar(&o.DstWikiURL, "dst-wiki-url", o.DstWikiURL, "destination wiki git url instead of the one of -dst")
+	fs.BoolVar(&o.CreateDst, "create-dst", o.CreateDst, "create destination repository with the settings of source if missing")
+	fs.BoolVar(&o.NoDefaultLabels, "no-default-labels", o.NoDefaultLabels, "delete the default labels of created destination repositories")
+	fs.StringVar(&o.IssueHeader, "issue-header", o.IssueHeader, "template of the header of issues")
+	fs.StringVar(&o.CommentHeader, "comment-header", o.CommentHeader, "template of the header of comments")
+	fs.StringVar(&o.Footer, "footer", o.Footer, "template of the footer of issues and comments")
+	fs.StringVar(&o.TimeZone, "time-zone", o.TimeZone, "time zone of the dates of the templates: Asia/Tokyo")
+	fs.StringVar(&o.TimeFormat, "time-format", o.TimeFormat, "go layout of the dates of the templates")
+	fs.BoolVar(&o.Markdown, "markdown", o.Markdown, "replace the bodies as markdown, leaving the code untouched")
+	fs.BoolVar(&o.RewriteMentions, "rewrite-mentions", o.RewriteMentions, "replace the @mentions of the bodies by the user map")
+	fs.BoolVar(&o.RewriteLinks, "rewrite-links", o.RewriteLinks, "point the links to the source repository at the destination")
+	fs.BoolVar(&o.OriginFooter, "origin-footer", o.OriginFooter, "add the footer of the source url to the issues")
+	fs.BoolVar(&o.Backlink, "backlink", o.Backlink, "comment on the source issues with the links to the moved ones")
+	fs.StringVar(&o.MovedNotice, "moved-notice", o.MovedNotice, "template of the comment of -backlink")
+	fs.BoolVar(&o.CloseSrc, "close-src", o.CloseSrc, "close the source issues by -backlink")
+	fs.BoolVar(&o.LockSrc, "lock-src", o.LockSrc, "lock the source issues by -backlink")
+	if name == CmdImport {
+		return fs
+	}
+
+	fs.StringVar(&o.Manifest, "manifest", o.Manifest, "manifest file of repositories to move in batch")
+	fs.StringVar(&o.SrcOrg, "src-org", o.SrcOrg, "source organization to move all repositories of")
+	fs.StringVar(&o.DstOrg, "dst-org", o.DstOrg, "destination organization")
+	fs.StringVar(&o.Include, "include", o.Include, "repositories to move in organization mode: foo-*,bar")
+	fs.StringVar(&o.Exclude, "exclude", o.Exclude, "repositories not to move in organization mode: *-old")
+	fs.BoolVar(&o.Archived, "archived", o.Archived, "move archived repositories in organization mode")
+	fs.BoolVar(&o.Forks, "forks", o.Forks, "move forked repositories in organization mode")
+	fs.StringVar(&o.BatchStatePath, "batch-state-file", o.BatchStatePath, "file to record the results of a batch")
+
+	return fs
+}
+
+// listValue is the flag of a comma separated list, which can be given
+// several times. The first one overrides the value of the config file.
+type listValue struct {
+	p   *string
+	set bool
+}
+
+func (v *listValue) String() string {
+	if v.p == nil {
+		return ""
+	}
+	return *v.p
+}
+
+func (v *listValue) Set(s string) error {
+	if v.set && *v.p != "" {
+		s = *v.p + "," + s
+	}
+	*v.p, v.set = s, true
+	return nil
+}
+
+// replacePaths returns the replacement map files of the options.
+func (o *Options) replacePaths() []string {
+	var paths []string
+	for _, v := range strings.Split(o.ReplacePath, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			paths = append(paths, v)
+		}
+	}
+	return paths
+}
+
+// LoadOptions returns the options of the command from the defaults, the config
+// file given by -config, the environment variables and the flags. The files
+// default to the ones of the working directory, with replace.yml if exists.
+func LoadOptions(name string, args []string) (*Options, error) {
+	// the flags are parsed twice: to find the config file, and to override it.
+	o := cliOptions()
+	fs := o.FlagSet(name)
+	fs.SetOutput(ioutil.Discard)
+	if err := fs.Parse(args); err != nil {
+		if err == flag.ErrHelp {
+			fs.SetOutput(os.Stderr)
+			fs.Usage()
+		}
+		return nil, err
+	}
+
+	config := o.Config
+	o = cliOptions()
+	if config != "" {
+		if err := o.LoadConfig(config); err != nil {
+			return nil, err
+		}
+	}
+	if err := o.LoadEnv(); err != nil {
+		return nil, err
+	}
+	if err := o.FlagSet(name).Parse(args); err != nil {
+		return nil, err
+	}
+
+	if o.DstAdminToken == "" {
+		o.DstAdminToken = o.DstToken
+	}
+	if o.ReplacePath == "" {
+		if _, err := os.Stat(defaultReplacePath); err == nil {
+			o.ReplacePath = defaultReplacePath
+		}
+	}
+
+	return o, o.Validate(name)
+}
+
+// cliOptions returns the default options with the files of the working
+// directory.
+func cliOptions() *Options {
+	o := DefaultOptions()
+	o.StatePath = defaultStatePath
+	o.BatchStatePath = defaultBatchStatePath
+	o.ExportPath = defaultExportPath
+	return o
+}
+
+func (o *Options) LoadConfig(path string) error {
+	buf, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	if err := yaml.UnmarshalStrict(buf, o); err != nil {
+		return fmt.Errorf("config %s: %s", path, err)
+	}
+	return nil
+}
+
+// LoadEnv sets the options given by the environment variables.
+func (o *Options) LoadEnv() error {
+	for k, v := range map[string]*string{
+		"SRC_TOKEN":       &o.SrcToken,
+		"DST_TOKEN":       &o.DstToken,
+		"DST_ADMIN_TOKEN": &o.DstAdminToken,
+		"DST_TOKENS":      &o.DstTokens,
+	} {
+		if s, ok := os.LookupEnv(k); ok {
+			*v = s
+		}
+	}
+
+	rv := reflect.ValueOf(o).Elem()
+	rt := rv.Type()
+	for i := 0; i < rt.NumField(); i++ {
+		key := rt.Field(i).Tag.Get("yaml")
+		if key == "" || key == "-" {
+			continue
+		}
+		name := envPrefix + strings.ToUpper(key)
+		s, ok := os.LookupEnv(name)
+		if !ok {
+			continue
+		}
+		f := rv.Field(i)
+		switch f.Kind() {
+		case reflect.String:
+			f.SetString(s)
+		case reflect.Bool:
+			b, err := strconv.ParseBool(s)
+			if err != nil {
+				return fmt.Errorf("invalid %s: %s", name, s)
+			}
+			f.SetBool(b)
+		case reflect.Int:
+			n, err := strconv.Atoi(s)
+			if err != nil {
+				return fmt.Errorf("invalid %s: %s", name, s)
+			}
+			f.SetInt(int64(n))
+		}
+	}
+
+	return nil
+}
+
+// Validate checks the options used by the command.
+func (o *Options) Validate(name string) error {
+	needsSrc, needsDst := true, true
+	switch name {
+	case CmdMigrate:
+		if o.SrcOrg != "" && o.DstOrg == "" {
+			return fmt.Errorf("-dst-org is required with -src-org")
+		}
+		if o.Manifest != "" || o.SrcOrg != "" {
+			needsSrc, needsDst = false, false
+		}
+	case CmdExport:
+		needsDst = false
+	case CmdImport:
+		needsSrc = o.Wiki || o.Projects != ProjectsNone
+	case CmdStatus:
+		needsSrc, needsDst = false, false
+	case CmdFinalize:
+		if o.SrcType != SrcGitHub || o.DstType != DstGitHub {
+			return fmt.Errorf("finalize is only for GitHub repositories")
+		}
+		// the archive is hard to undo, so the name is typed again.
+		if o.Confirm != o.Src {
+			return fmt.Errorf("finalize needs -confirm=%s", o.Src)
+		}
+	}
+	if !validSrcType(o.SrcType) {
+		return fmt.Errorf("invalid source type: %s", o.SrcType)
+	}
+	if !validDstType(o.DstType) {
+		return fmt.Errorf("invalid destination type: %s", o.DstType)
+	}
+	if needsSrc {
+		if err := validSrc(o.SrcType, o.Src); err != nil {
+			return err
+		}
+	}
+	if needsDst && !validRepo(o.Dst) {
+		return fmt.Errorf("invalid destination repository: %q, expected owner/name", o.Dst)
+	}
+
+	if _, err := NewFilter(o.State, o.Labels, o.Milestone, o.Author, o.Since, o.Until, o.Numbers); err != nil {
+		return err
+	}
+	if !validAlign(o.Align) {
+		return fmt.Errorf("invalid align mode: %s", o.Align)
+	}
+	if !validCleanup(o.Cleanup) {
+		return fmt.Errorf("invalid cleanup mode: %s", o.Cleanup)
+	}
+	if o.Cleanup == CleanupTransfer && !validRepo(o.TrashRepo) {
+		return fmt.Errorf("invalid trash repository: %q, expected owner/name", o.TrashRepo)
+	}
+	if !validDiscussions(o.Discussions) {
+		return fmt.Errorf("invalid discussions mode: %s", o.Discussions)
+	}
+	if !validProjects(o.Projects) {
+		return fmt.Errorf("invalid projects mode: %s", o.Projects)
+	}
+	if o.SrcAppID != 0 {
+		if o.SrcType != SrcGitHub {
+			return fmt.Errorf("src github app is only for github")
+		}
+		if o.SrcAppInstallationID == 0 || o.SrcAppKey == "" {
+			return fmt.Errorf("src github app needs the installation id and the private key")
+		}
+	}
+	if o.DstAppID != 0 {
+		if o.DstType != DstGitHub {
+			return fmt.Errorf("dst github app is only for github")
+		}
+		if o.DstAppInstallationID == 0 || o.DstAppKey == "" {
+			return fmt.Errorf("dst github app needs the installation id and the private key")
+		}
+	}
+	// the issues of Gitea are created by DST_TOKEN alone.
+	if o.DstType == DstGitea && (o.DstTokens != "" || o.DstUserTokens != "") {
+		return fmt.Errorf("DST_TOKENS and -dst-user-tokens are only for github")
+	}
+	if o.SrcType == SrcJira && o.JiraAPI != "2" && o.JiraAPI != "3" {
+		return fmt.Errorf("invalid jira api version: %s", o.JiraAPI)
+	}
+
+	return nil
+}
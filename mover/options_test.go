@@ -0,0 +1,126 @@
+package mover
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadOptionsPrecedence(t *testing.T) {
+	dir := testDir(t)
+	defer os.RemoveAll(dir)
+	config := filepath.Join(dir, "mover.yml")
+	yml := "dst: foo/config\ndummy_title: config\ndummy_lock: false\nmr_offset: 10\nreplace: config.yml\n"
+	if err := ioutil.WriteFile(config, []byte(yml), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		env   map[string]string
+		args  []string
+		title string
+		lock  bool
+		mr    int
+		paths string
+	}{
+		{nil, nil, defaultDummyTitle, true, 0, ""},
+		{nil, []string{"-config", config}, "config", false, 10, "config.yml"},
+		{map[string]string{"MOVER_DUMMY_TITLE": "env", "MOVER_DUMMY_LOCK": "true", "MOVER_MR_OFFSET": "20"},
+			[]string{"-config", config}, "env", true, 20, "config.yml"},
+		{map[string]string{"MOVER_DUMMY_TITLE": "env", "MOVER_REPLACE": "env.yml"},
+			[]string{"-config", config, "-dummy-title", "flag", "-mr-offset", "30", "-replace", "a.yml", "-replace", "b.yml"}, "flag", false, 30, "a.yml,b.yml"},
+	}
+	for _, tt := range tests {
+		for k, v := range tt.env {
+			os.Setenv(k, v)
+		}
+		o, err := LoadOptions(CmdMigrate, append([]string{"-src", "foo/src", "-dst", "foo/dst"}, tt.args...))
+		for k := range tt.env {
+			os.Unsetenv(k)
+		}
+		if err != nil {
+			t.Errorf("%v %v: %s", tt.env, tt.args, err)
+			continue
+		}
+		if o.Dst != "foo/dst" || o.DummyTitle != tt.title || o.DummyLock != tt.lock || o.MROffset != tt.mr || o.ReplacePath != tt.paths {
+			t.Errorf("%v %v: dst %s, title %s, lock %t, mr offset %d, replace %q", tt.env, tt.args, o.Dst, o.DummyTitle, o.DummyLock, o.MROffset, o.ReplacePath)
+		}
+	}
+}
+
+func TestLoadOptionsInvalid(t *testing.T) {
+	dir := testDir(t)
+	defer os.RemoveAll(dir)
+	config := filepath.Join(dir, "mover.yml")
+	if err := ioutil.WriteFile(config, []byte("unknown: true\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadOptions(CmdMigrate, []string{"-config", config, "-src", "foo/src", "-dst", "foo/dst"}); err == nil {
+		t.Error("no error of the unknown key")
+	}
+
+	os.Setenv("MOVER_DUMMY_LOCK", "maybe")
+	_, err := LoadOptions(CmdMigrate, []string{"-src", "foo/src", "-dst", "foo/dst"})
+	os.Unsetenv("MOVER_DUMMY_LOCK")
+	if err == nil {
+		t.Error("no error of the invalid bool")
+	}
+
+	// the flags of the other commands are not taken.
+	if _, err := LoadOptions(CmdVerify, []string{"-src", "foo/src", "-dst", "foo/dst", "-backlink"}); err == nil {
+		t.Error("no error of -backlink of verify")
+	}
+}
+
+func TestLoadOptionsAdminToken(t *testing.T) {
+	os.Setenv("DST_TOKEN", "dst")
+	defer os.Unsetenv("DST_TOKEN")
+	o, err := LoadOptions(CmdMigrate, []string{"-src", "foo/src", "-dst", "foo/dst"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if o.DstToken != "dst" || o.DstAdminToken != "dst" {
+		t.Errorf("tokens: %q %q", o.DstToken, o.DstAdminToken)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name   string
+		set    func(o *Options)
+		hasErr bool
+	}{
+		{CmdMigrate, func(o *Options) {}, false},
+		{CmdMigrate, func(o *Options) { o.Dst = "" }, true},
+		{CmdMigrate, func(o *Options) { o.Src = "foo" }, true},
+		{CmdMigrate, func(o *Options) { o.Src, o.Dst, o.Manifest = "", "", "repos.yml" }, false},
+		{CmdMigrate, func(o *Options) { o.Src, o.Dst, o.SrcOrg = "", "", "foo" }, true},
+		{CmdMigrate, func(o *Options) { o.Src, o.Dst, o.SrcOrg, o.DstOrg = "", "", "foo", "bar" }, false},
+		{CmdMigrate, func(o *Options) { o.Align = "loose" }, true},
+		{CmdMigrate, func(o *Options) { o.Cleanup = CleanupTransfer }, true},
+		{CmdMigrate, func(o *Options) { o.Cleanup, o.TrashRepo = CleanupTransfer, "foo/trash" }, false},
+		{CmdMigrate, func(o *Options) { o.Projects = "beta" }, true},
+		{CmdMigrate, func(o *Options) { o.SrcType, o.Src = SrcGitLab, "group/sub/proj" }, false},
+		{CmdMigrate, func(o *Options) { o.SrcType, o.Src, o.JiraAPI = SrcJira, "FOO", "4" }, true},
+		{CmdMigrate, func(o *Options) { o.DstType = DstGitea }, false},
+		{CmdMigrate, func(o *Options) { o.DstType, o.DstTokens = DstGitea, "a,b" }, true},
+		{CmdMigrate, func(o *Options) { o.DstType, o.DstUserTokens = DstGitea, "users.yml" }, true},
+		{CmdExport, func(o *Options) { o.Dst = "" }, false},
+		{CmdExport, func(o *Options) { o.Src = "" }, true},
+		{CmdImport, func(o *Options) { o.Src = "" }, false},
+		{CmdImport, func(o *Options) { o.Src, o.Wiki = "", true }, true},
+		{CmdImport, func(o *Options) { o.Dst = "" }, true},
+		{CmdStatus, func(o *Options) { o.Src, o.Dst = "", "" }, false},
+		{CmdFinalize, func(o *Options) { o.Confirm = "foo/src" }, false},
+		{CmdFinalize, func(o *Options) {}, true},
+	}
+	for i, tt := range tests {
+		o := DefaultOptions()
+		o.Src, o.Dst = "foo/src", "foo/dst"
+		tt.set(o)
+		if err := o.Validate(tt.name); (err != nil) != tt.hasErr {
+			t.Errorf("%d %s: %v", i, tt.name, err)
+		}
+	}
+}
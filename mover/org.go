@@ -0,0 +1,147 @@
+package mover
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/google/go-github/v32/github"
+)
+
+// RunOrg moves every repository of the SRC organization matching the
+// include and exclude patterns to the DST organization in batch, creating
+// the DST repositories that do not exist.
+func RunOrg(ctx context.Context, base *Options) error {
+	return runOrg(ctx, base, NewShared())
+}
+
+func runOrg(ctx context.Context, base *Options, shared *Shared) error {
+	if base.DstOrg == "" {
+		return fmt.Errorf("destination organization is required")
+	}
+	sctx := shared.Context(ctx)
+	srcTS, err := base.srcTokenSource(sctx)
+	if err != nil {
+		return err
+	}
+	src, err := newRESTClient(sctx, base.SrcEndpoint, srcTS)
+	if err != nil {
+		return err
+	}
+	dstTS, err := base.dstTokenSource(sctx)
+	if err != nil {
+		return err
+	}
+	dst, err := newRESTClient(sctx, base.DstEndpoint, dstTS)
+	if err != nil {
+		return err
+	}
+
+	repos, err := listOrgRepos(sctx, src, base)
+	if err != nil {
+		return err
+	}
+
+	var opts []*Options
+	// branches are the default branches of the created repositories.
+	branches := map[string]string{}
+	for _, r := range repos {
+		created, err := ensureDstRepo(sctx, base.Log, dst, base.DstOrg, r.GetName(), r, base.NoDefaultLabels)
+		if err != nil {
+			return err
+		}
+		if created && r.GetDefaultBranch() != "" {
+			branches[r.GetName()] = r.GetDefaultBranch()
+		}
+		o := *base
+		o.Src = base.SrcOrg + "/" + r.GetName()
+		o.Dst = base.DstOrg + "/" + r.GetName()
+		o.StatePath = repoStatePath(base.StatePath, o.Src)
+		opts = append(opts, &o)
+	}
+	logf(base.Log, "found repositories: %d in %s\n", len(opts), base.SrcOrg)
+
+	err = runBatch(ctx, base, opts, shared)
+
+	var unset []string
+	for _, r := range repos {
+		branch, ok := branches[r.GetName()]
+		if !ok {
+			continue
+		}
+		if err := setDefaultBranch(sctx, base.Log, dst, base.DstOrg, r.GetName(), branch); err != nil {
+			printError(base.Log, "default branch set", err)
+			unset = append(unset, base.DstOrg+"/"+r.GetName())
+		}
+	}
+	if err != nil {
+		return err
+	}
+	if len(unset) > 0 {
+		return fmt.Errorf("default branches are not set: %s", strings.Join(unset, ", "))
+	}
+	return nil
+}
+
+func listOrgRepos(ctx context.Context, client *github.Client, o *Options) ([]*github.Repository, error) {
+	var repos []*github.Repository
+	opt := &github.RepositoryListByOrgOptions{Type: "all", ListOptions: github.ListOptions{PerPage: 100}}
+	for {
+		got, resp, err := client.Repositories.ListByOrg(ctx, o.SrcOrg, opt)
+		if err != nil {
+			return nil, err
+		}
+		for _, r := range got {
+			if r.GetArchived() && !o.Archived {
+				continue
+			}
+			if r.GetFork() && !o.Forks {
+				continue
+			}
+			ok, err := matchRepo(r.GetName(), o.Include, o.Exclude)
+			if err != nil {
+				return nil, err
+			}
+			if ok {
+				repos = append(repos, r)
+			}
+		}
+		if resp.NextPage == 0 {
+			break
+		}
+		opt.Page = resp.NextPage
+	}
+	return repos, nil
+}
+
+// matchRepo reports whether the name matches any of the comma separated
+// include patterns, and none of the exclude ones.
+func matchRepo(name, include, exclude string) (bool, error) {
+	if include != "" {
+		ok, err := matchAny(name, include)
+		if err != nil || !ok {
+			return false, err
+		}
+	}
+	if exclude != "" {
+		ok, err := matchAny(name, exclude)
+		if err != nil || ok {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+func matchAny(name, patterns string) (bool, error) {
+	for _, p := range strings.Split(patterns, ",") {
+		ok, err := path.Match(strings.TrimSpace(p), name)
+		if err != nil {
+			return false, fmt.Errorf("invalid pattern: %s", p)
+		}
+		if ok {
+			return true, nil
+		}
+	}
+	return false, nil
+}
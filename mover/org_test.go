@@ -0,0 +1,93 @@
+package mover
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestMatchRepo(t *testing.T) {
+	tests := []struct {
+		name, include, exclude string
+		want                   bool
+	}{
+		{"api", "", "", true},
+		{"api", "api,web", "", true},
+		{"api-old", "api*", "*-old", false},
+		{"api-v2", "api*", "*-old", true},
+		{"web", "api*", "", false},
+		{"web", "", " web , docs", false},
+	}
+	for _, tt := range tests {
+		got, err := matchRepo(tt.name, tt.include, tt.exclude)
+		if err != nil {
+			t.Errorf("%+v: %s", tt, err)
+		}
+		if got != tt.want {
+			t.Errorf("%+v: %t", tt, got)
+		}
+	}
+	if _, err := matchRepo("api", "[", ""); err == nil {
+		t.Error("invalid pattern")
+	}
+}
+
+func TestRunOrg(t *testing.T) {
+	f := newFakeGitHub()
+	defer f.Close()
+	f.AddOrg("foo")
+	f.AddOrg("bar")
+	seedSrc(f)
+	f.Repo("foo/src2").AddIssue(testIssue("one", false))
+	// the default branch not pushed to the created bar/src2 is skipped.
+	f.Repo("foo/src2").DefaultBranch = "main"
+	f.Repo("foo/archived").Archived = true
+	f.Repo("foo/fork").Fork = true
+	f.Repo("foo/src-old").AddIssue(testIssue("old", false))
+	// bar/src exists with a default label of GitHub, and bar/src2 does not.
+	f.Repo("bar/src").Labels = []Label{{Name: "bug", Color: "d73a4a"}}
+	f.Repo("bar/src2").Missing = true
+	dir := testDir(t)
+	defer os.RemoveAll(dir)
+
+	base := testOptions(f, dir)
+	base.SrcOrg = "foo"
+	base.DstOrg = "bar"
+	base.Exclude = "*-old"
+	base.BatchStatePath = filepath.Join(dir, "batch.json")
+
+	// bar/src fails at the lock of its dummy, and bar/src2 is moved.
+	f.Fail("PUT", "/repos/bar/src/issues/3/lock", http.StatusInternalServerError, 1)
+	if err := runOrg(context.Background(), base, testShared()); err == nil || !strings.Contains(err.Error(), "1 of 2") {
+		t.Fatalf("org with a failure: %v", err)
+	}
+	if dst := f.Repo("bar/src2"); dst.Missing || dst.Owner != "bar" || len(dst.Issues) != 1 {
+		t.Errorf("bar/src2: %+v", dst)
+	}
+	if dst := f.Repo("bar/src"); dst.Owner != "" {
+		t.Errorf("existing bar/src is created for %s", dst.Owner)
+	}
+	for _, name := range []string{"bar/archived", "bar/fork", "bar/src-old"} {
+		if v, ok := f.repos[name]; ok && len(v.Issues) > 0 {
+			t.Errorf("%s is moved", name)
+		}
+	}
+
+	// the resumed run moves bar/src, keeping its label.
+	if err := runOrg(context.Background(), base, testShared()); err != nil {
+		t.Fatal(err)
+	}
+	dst := f.Repo("bar/src")
+	if got := dst.Numbers(); len(got) != 5 {
+		t.Errorf("bar/src numbers: %v", got)
+	}
+	if len(dst.Labels) != 3 || dst.Labels[0].Color != "d73a4a" {
+		t.Errorf("bar/src labels: %v", dst.Labels)
+	}
+	if got := len(f.Repo("bar/src2").Imports); got != 1 {
+		t.Errorf("bar/src2 imported again: %d", got)
+	}
+}
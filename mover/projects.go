@@ -0,0 +1,861 @@
+package mover
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/google/go-github/v32/github"
+	"github.com/shurcooL/githubv4"
+)
+
+const (
+	ProjectsNone    = "none"
+	ProjectsClassic = "classic"
+	ProjectsV2      = "v2"
+	ProjectsAll     = "all"
+)
+
+func validProjects(mode string) bool {
+	switch mode {
+	case ProjectsNone, ProjectsClassic, ProjectsV2, ProjectsAll:
+		return true
+	}
+	return false
+}
+
+// DoProjects recreates the projects referencing SRC issues in DST, and
+// links their cards and items to the moved issues by the number map.
+func (t *Transfer) DoProjects(ctx context.Context) error {
+	if t.ProjectsMode == ProjectsClassic || t.ProjectsMode == ProjectsAll {
+		if err := t.doClassicProjects(ctx); err != nil {
+			return err
+		}
+	}
+	if t.ProjectsMode == ProjectsV2 || t.ProjectsMode == ProjectsAll {
+		if err := t.doProjectsV2(ctx); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// dstIssue returns the DST issue moved from the SRC number.
+func (t *Transfer) dstIssue(ctx context.Context, n int) (*github.Issue, error) {
+	dn, ok := t.State.Numbers[n]
+	if !ok {
+		return nil, nil
+	}
+	issue, _, err := t.DST.Client.Issues.Get(ctx, t.DST.Owner, t.DST.Name, dn)
+	if err != nil {
+		return nil, err
+	}
+	return issue, nil
+}
+
+// srcIssueNumber returns the number of the SRC issue the api url points at.
+func (t *Transfer) srcIssueNumber(contentURL string) (int, bool) {
+	prefix := strings.ToLower(fmt.Sprintf("/repos/%s/%s/issues/", t.SRC.Owner, t.SRC.Name))
+	if !strings.Contains(strings.ToLower(contentURL), prefix) {
+		return 0, false
+	}
+	n, err := strconv.Atoi(path.Base(contentURL))
+	if err != nil {
+		return 0, false
+	}
+	return n, true
+}
+
+func (t *Transfer) doClassicProjects(ctx context.Context) error {
+	var projects []*github.Project
+	popt := &github.ProjectListOptions{State: "all", ListOptions: github.ListOptions{PerPage: 100}}
+	for {
+		got, resp, err := t.SRC.REST.Repositories.ListProjects(ctx, t.SRC.Owner, t.SRC.Name, popt)
+		if err != nil {
+			return err
+		}
+		projects = append(projects, got...)
+		if resp.NextPage == 0 {
+			break
+		}
+		popt.Page = resp.NextPage
+	}
+	repoProjects := len(projects)
+
+	// organization projects are moved only when they have SRC issues.
+	popt.Page = 0
+	for {
+		got, resp, err := t.SRC.REST.Organizations.ListProjects(ctx, t.SRC.Owner, popt)
+		if err != nil {
+			if resp != nil && resp.StatusCode == http.StatusNotFound {
+				break
+			}
+			return err
+		}
+		projects = append(projects, got...)
+		if resp.NextPage == 0 {
+			break
+		}
+		popt.Page = resp.NextPage
+	}
+
+	for i, p := range projects {
+		key := fmt.Sprintf("classic:%d", p.GetID())
+		if id, ok := t.State.Projects[key]; ok {
+			logf(t.Log, "skipped project: %s - already moved to %s\n", p.GetName(), id)
+			continue
+		}
+
+		columns, err := t.classicProjectColumns(ctx, p.GetID())
+		if err != nil {
+			return err
+		}
+		if i >= repoProjects && !t.hasSrcCards(columns) {
+			continue
+		}
+
+		opt := &github.ProjectOptions{Name: p.Name, Body: p.Body}
+		var created *github.Project
+		if i < repoProjects {
+			created, _, err = t.DST.Client.Repositories.CreateProject(ctx, t.DST.Owner, t.DST.Name, opt)
+		} else {
+			created, err = t.createOwnerProject(ctx, opt)
+		}
+		if err != nil {
+			return err
+		}
+		logf(t.Log, "created project: %s\n", created.GetName())
+
+		for _, c := range columns {
+			column, _, err := t.DST.Client.Projects.CreateProjectColumn(ctx, created.GetID(), &github.ProjectColumnOptions{Name: c.Column.GetName()})
+			if err != nil {
+				return err
+			}
+			// a new card is put on the top of the column.
+			for j := len(c.Cards) - 1; j >= 0; j-- {
+				copt, err := t.classicProjectCard(ctx, c.Cards[j])
+				if err != nil {
+					return err
+				}
+				if _, _, err := t.DST.Client.Projects.CreateProjectCard(ctx, column.GetID(), copt); err != nil {
+					return err
+				}
+			}
+			logf(t.Log, "created project column: %s (%d cards)\n", column.GetName(), len(c.Cards))
+		}
+
+		t.State.Projects[key] = strconv.FormatInt(created.GetID(), 10)
+		if err := t.State.Save(t.StatePath); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// createOwnerProject creates the project of the DST owner, by the
+// user-projects endpoint when the owner is a user.
+func (t *Transfer) createOwnerProject(ctx context.Context, opt *github.ProjectOptions) (*github.Project, error) {
+	user, err := isUserOwner(ctx, t.DST.Client, t.DST.Owner)
+	if err != nil {
+		return nil, err
+	}
+	if !user {
+		p, _, err := t.DST.Client.Organizations.CreateProject(ctx, t.DST.Owner, opt)
+		return p, err
+	}
+	p, _, err := t.DST.Client.Users.CreateProject(ctx, &github.CreateUserProjectOptions{Name: opt.GetName(), Body: opt.Body})
+	return p, err
+}
+
+type classicProjectColumn struct {
+	Column *github.ProjectColumn
+	Cards  []*github.ProjectCard
+}
+
+func (t *Transfer) classicProjectColumns(ctx context.Context, projectID int64) ([]*classicProjectColumn, error) {
+	var columns []*classicProjectColumn
+	opt := &github.ListOptions{PerPage: 100}
+	for {
+		got, resp, err := t.SRC.REST.Projects.ListProjectColumns(ctx, projectID, opt)
+		if err != nil {
+			return nil, err
+		}
+		for _, v := range got {
+			columns = append(columns, &classicProjectColumn{Column: v})
+		}
+		if resp.NextPage == 0 {
+			break
+		}
+		opt.Page = resp.NextPage
+	}
+
+	for _, c := range columns {
+		copt := &github.ProjectCardListOptions{ListOptions: github.ListOptions{PerPage: 100}}
+		for {
+			got, resp, err := t.SRC.REST.Projects.ListProjectCards(ctx, c.Column.GetID(), copt)
+			if err != nil {
+				return nil, err
+			}
+			c.Cards = append(c.Cards, got...)
+			if resp.NextPage == 0 {
+				break
+			}
+			copt.Page = resp.NextPage
+		}
+	}
+
+	return columns, nil
+}
+
+func (t *Transfer) hasSrcCards(columns []*classicProjectColumn) bool {
+	for _, c := range columns {
+		for _, v := range c.Cards {
+			if _, ok := t.srcIssueNumber(v.GetContentURL()); ok {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// classicProjectCard links the card to the moved issue, and makes a note
+// of the original url when the issue was not moved.
+func (t *Transfer) classicProjectCard(ctx context.Context, v *github.ProjectCard) (*github.ProjectCardOptions, error) {
+	if v.GetContentURL() == "" {
+		return &github.ProjectCardOptions{Note: t.replaceBody(v.GetNote())}, nil
+	}
+	if n, ok := t.srcIssueNumber(v.GetContentURL()); ok {
+		issue, err := t.dstIssue(ctx, n)
+		if err != nil {
+			return nil, err
+		}
+		if issue != nil {
+			return &github.ProjectCardOptions{ContentID: issue.GetID(), ContentType: "Issue"}, nil
+		}
+	}
+	return &github.ProjectCardOptions{Note: t.replaceBody(v.GetContentURL())}, nil
+}
+
+type ProjectV2 struct {
+	ID               string
+	Title            string
+	ShortDescription string
+	Readme           string
+	Fields           struct {
+		Nodes []ProjectV2Field
+	} `graphql:"fields(first: 50)"`
+}
+
+type ProjectV2Field struct {
+	Common struct {
+		ID       string
+		Name     string
+		DataType string
+	} `graphql:"... on ProjectV2FieldCommon"`
+	SingleSelect struct {
+		Options []ProjectV2SingleSelectFieldOption
+	} `graphql:"... on ProjectV2SingleSelectField"`
+}
+
+type ProjectV2SingleSelectFieldOption struct {
+	ID          string
+	Name        string
+	Color       string
+	Description string
+}
+
+type ProjectV2FieldValueField struct {
+	Common struct {
+		Name string
+	} `graphql:"... on ProjectV2FieldCommon"`
+}
+
+type ProjectV2Item struct {
+	ID      string
+	Type    string
+	Content struct {
+		Issue struct {
+			Number     int
+			Repository struct {
+				NameWithOwner string
+			}
+		} `graphql:"... on Issue"`
+		PullRequest struct {
+			Number     int
+			Repository struct {
+				NameWithOwner string
+			}
+		} `graphql:"... on PullRequest"`
+		DraftIssue struct {
+			Title string
+			Body  string
+		} `graphql:"... on DraftIssue"`
+	}
+	FieldValues struct {
+		// Typename tells the fragment of the value, since the field of
+		// every fragment is decoded.
+		Nodes []struct {
+			Typename string `graphql:"__typename"`
+			Text     struct {
+				Text  string
+				Field ProjectV2FieldValueField
+			} `graphql:"... on ProjectV2ItemFieldTextValue"`
+			Number struct {
+				Number float64
+				Field  ProjectV2FieldValueField
+			} `graphql:"... on ProjectV2ItemFieldNumberValue"`
+			Date struct {
+				Date  string
+				Field ProjectV2FieldValueField
+			} `graphql:"... on ProjectV2ItemFieldDateValue"`
+			SingleSelect struct {
+				Name  string
+				Field ProjectV2FieldValueField
+			} `graphql:"... on ProjectV2ItemFieldSingleSelectValue"`
+		}
+	} `graphql:"fieldValues(first: 50)"`
+}
+
+// number returns the SRC number of the issue or pull request of the item.
+func (i *ProjectV2Item) number(src string) (int, bool) {
+	switch i.Type {
+	case "ISSUE":
+		if strings.EqualFold(i.Content.Issue.Repository.NameWithOwner, src) {
+			return i.Content.Issue.Number, true
+		}
+	case "PULL_REQUEST":
+		if strings.EqualFold(i.Content.PullRequest.Repository.NameWithOwner, src) {
+			return i.Content.PullRequest.Number, true
+		}
+	}
+	return 0, false
+}
+
+type ProjectsV2Connection struct {
+	Nodes    []ProjectV2
+	PageInfo struct {
+		EndCursor   githubv4.String
+		HasNextPage bool
+	}
+}
+
+type RepositoryProjectsV2Query struct {
+	Repository struct {
+		ProjectsV2 ProjectsV2Connection `graphql:"projectsV2(first: 20, after: $cursor)"`
+	} `graphql:"repository(owner: $owner, name: $repo)"`
+}
+
+type OrganizationProjectsV2Query struct {
+	Organization struct {
+		ProjectsV2 ProjectsV2Connection `graphql:"projectsV2(first: 20, after: $cursor)"`
+	} `graphql:"organization(login: $owner)"`
+}
+
+type ProjectV2ItemsQuery struct {
+	Node struct {
+		ProjectV2 struct {
+			Items struct {
+				Nodes    []ProjectV2Item
+				PageInfo struct {
+					EndCursor   githubv4.String
+					HasNextPage bool
+				}
+			} `graphql:"items(first: 100, after: $cursor)"`
+		} `graphql:"... on ProjectV2"`
+	} `graphql:"node(id: $id)"`
+}
+
+type ProjectV2FieldsQuery struct {
+	Node struct {
+		ProjectV2 struct {
+			Fields struct {
+				Nodes []ProjectV2Field
+			} `graphql:"fields(first: 50)"`
+		} `graphql:"... on ProjectV2"`
+	} `graphql:"node(id: $id)"`
+}
+
+type OwnerQuery struct {
+	RepositoryOwner struct {
+		ID string
+	} `graphql:"repositoryOwner(login: $owner)"`
+	Repository struct {
+		ID string
+	} `graphql:"repository(owner: $owner, name: $repo)"`
+}
+
+// CreateProjectV2Input is an input type of CreateProjectV2.
+type CreateProjectV2Input struct {
+	OwnerID      githubv4.ID     `json:"ownerId"`
+	Title        githubv4.String `json:"title"`
+	RepositoryID *githubv4.ID    `json:"repositoryId,omitempty"`
+}
+
+// UpdateProjectV2Input is an input type of UpdateProjectV2.
+type UpdateProjectV2Input struct {
+	ProjectID        githubv4.ID      `json:"projectId"`
+	ShortDescription *githubv4.String `json:"shortDescription,omitempty"`
+	Readme           *githubv4.String `json:"readme,omitempty"`
+}
+
+// CreateProjectV2FieldInput is an input type of CreateProjectV2Field.
+type CreateProjectV2FieldInput struct {
+	ProjectID           githubv4.ID                             `json:"projectId"`
+	DataType            githubv4.String                         `json:"dataType"`
+	Name                githubv4.String                         `json:"name"`
+	SingleSelectOptions []ProjectV2SingleSelectFieldOptionInput `json:"singleSelectOptions,omitempty"`
+}
+
+type ProjectV2SingleSelectFieldOptionInput struct {
+	Name        githubv4.String `json:"name"`
+	Color       githubv4.String `json:"color"`
+	Description githubv4.String `json:"description"`
+}
+
+// UpdateProjectV2FieldInput is an input type of UpdateProjectV2Field.
+type UpdateProjectV2FieldInput struct {
+	FieldID             githubv4.ID                             `json:"fieldId"`
+	SingleSelectOptions []ProjectV2SingleSelectFieldOptionInput `json:"singleSelectOptions,omitempty"`
+}
+
+// AddProjectV2ItemByIdInput is an input type of AddProjectV2ItemById.
+type AddProjectV2ItemByIdInput struct {
+	ProjectID githubv4.ID `json:"projectId"`
+	ContentID githubv4.ID `json:"contentId"`
+}
+
+// AddProjectV2DraftIssueInput is an input type of AddProjectV2DraftIssue.
+type AddProjectV2DraftIssueInput struct {
+	ProjectID githubv4.ID     `json:"projectId"`
+	Title     githubv4.String `json:"title"`
+	Body      githubv4.String `json:"body,omitempty"`
+}
+
+// UpdateProjectV2ItemFieldValueInput is an input type of UpdateProjectV2ItemFieldValue.
+type UpdateProjectV2ItemFieldValueInput struct {
+	ProjectID githubv4.ID         `json:"projectId"`
+	ItemID    githubv4.ID         `json:"itemId"`
+	FieldID   githubv4.ID         `json:"fieldId"`
+	Value     ProjectV2FieldValue `json:"value"`
+}
+
+type ProjectV2FieldValue struct {
+	Text                 *githubv4.String `json:"text,omitempty"`
+	Number               *githubv4.Float  `json:"number,omitempty"`
+	Date                 *githubv4.String `json:"date,omitempty"`
+	SingleSelectOptionID *githubv4.String `json:"singleSelectOptionId,omitempty"`
+}
+
+// customFieldTypes are the field types which can be created by the api,
+// the others are built in every project.
+var customFieldTypes = map[string]bool{
+	"TEXT":          true,
+	"NUMBER":        true,
+	"DATE":          true,
+	"SINGLE_SELECT": true,
+}
+
+func (t *Transfer) doProjectsV2(ctx context.Context) error {
+	projects, linked, err := t.srcProjectsV2(ctx)
+	if err != nil {
+		return err
+	}
+
+	var oq OwnerQuery
+	ov := map[string]interface{}{
+		"owner": githubv4.String(t.DST.Owner),
+		"repo":  githubv4.String(t.DST.Name),
+	}
+	if err := t.DST.GraphQL.Query(ctx, &oq, ov); err != nil {
+		return err
+	}
+
+	src := t.SRC.Owner + "/" + t.SRC.Name
+	for _, p := range projects {
+		key := "v2:" + p.ID
+		items, err := t.srcProjectV2Items(ctx, p.ID)
+		if err != nil {
+			return err
+		}
+
+		// the project created by the run failed is resumed, since the
+		// fields and the items added are skipped.
+		projectID, ok := t.State.Projects[key]
+		if ok {
+			logf(t.Log, "resumed project: %s - %s\n", p.Title, projectID)
+		} else {
+			if !linked[p.ID] && !hasSrcItems(items, src) {
+				continue
+			}
+			projectID, err = t.createProjectV2(ctx, oq, p)
+			if err != nil {
+				return err
+			}
+		}
+
+		if p.ShortDescription != "" || p.Readme != "" {
+			var um struct {
+				UpdateProjectV2 struct {
+					ClientMutationID string
+				} `graphql:"updateProjectV2(input: $input)"`
+			}
+			uinput := UpdateProjectV2Input{
+				ProjectID:        projectID,
+				ShortDescription: githubv4.NewString(githubv4.String(p.ShortDescription)),
+				Readme:           githubv4.NewString(githubv4.String(t.replaceBody(p.Readme))),
+			}
+			if err := t.DST.GraphQL.Mutate(ctx, &um, uinput, nil); err != nil {
+				return err
+			}
+		}
+
+		fields, err := t.createProjectV2Fields(ctx, projectID, p.Fields.Nodes)
+		if err != nil {
+			return err
+		}
+
+		added := 0
+		for _, item := range items {
+			if t.State.IsAddedProjectItem(item.ID) {
+				continue
+			}
+			if err := t.addProjectV2Item(ctx, projectID, fields, item, src); err != nil {
+				return err
+			}
+			t.State.ProjectItems = append(t.State.ProjectItems, item.ID)
+			if err := t.State.Save(t.StatePath); err != nil {
+				return err
+			}
+			added++
+		}
+		logf(t.Log, "created project items: %s (%d items)\n", p.Title, added)
+	}
+
+	return nil
+}
+
+// createProjectV2 creates the DST project of the SRC one, and records it in
+// the state right away, so that it is not created again by the resumed run.
+func (t *Transfer) createProjectV2(ctx context.Context, oq OwnerQuery, p ProjectV2) (string, error) {
+	var m struct {
+		CreateProjectV2 struct {
+			ProjectV2 struct {
+				ID    string
+				Title string
+			}
+		} `graphql:"createProjectV2(input: $input)"`
+	}
+	repositoryID := githubv4.ID(oq.Repository.ID)
+	input := CreateProjectV2Input{
+		OwnerID:      oq.RepositoryOwner.ID,
+		Title:        githubv4.String(p.Title),
+		RepositoryID: &repositoryID,
+	}
+	if err := t.DST.GraphQL.Mutate(ctx, &m, input, nil); err != nil {
+		return "", err
+	}
+	projectID := m.CreateProjectV2.ProjectV2.ID
+	logf(t.Log, "created project: %s\n", p.Title)
+
+	t.State.Projects["v2:"+p.ID] = projectID
+	if err := t.State.Save(t.StatePath); err != nil {
+		return "", err
+	}
+	return projectID, nil
+}
+
+// srcProjectsV2 returns the projects of the SRC organization and the
+// projects linked to the SRC repository, which are moved in any case.
+func (t *Transfer) srcProjectsV2(ctx context.Context) ([]ProjectV2, map[string]bool, error) {
+	var projects []ProjectV2
+	linked := map[string]bool{}
+
+	var rq RepositoryProjectsV2Query
+	v := map[string]interface{}{
+		"owner":  githubv4.String(t.SRC.Owner),
+		"repo":   githubv4.String(t.SRC.Name),
+		"cursor": (*githubv4.String)(nil),
+	}
+	for {
+		if err := t.SRC.Client.Query(ctx, &rq, v); err != nil {
+			return nil, nil, err
+		}
+		for _, p := range rq.Repository.ProjectsV2.Nodes {
+			linked[p.ID] = true
+			projects = append(projects, p)
+		}
+		if !rq.Repository.ProjectsV2.PageInfo.HasNextPage {
+			break
+		}
+		v["cursor"] = githubv4.NewString(rq.Repository.ProjectsV2.PageInfo.EndCursor)
+	}
+
+	var oq OrganizationProjectsV2Query
+	ov := map[string]interface{}{
+		"owner":  githubv4.String(t.SRC.Owner),
+		"cursor": (*githubv4.String)(nil),
+	}
+	for {
+		// the owner of SRC may be a user.
+		if err := t.SRC.Client.Query(ctx, &oq, ov); err != nil {
+			break
+		}
+		for _, p := range oq.Organization.ProjectsV2.Nodes {
+			if !linked[p.ID] {
+				projects = append(projects, p)
+			}
+		}
+		if !oq.Organization.ProjectsV2.PageInfo.HasNextPage {
+			break
+		}
+		ov["cursor"] = githubv4.NewString(oq.Organization.ProjectsV2.PageInfo.EndCursor)
+	}
+
+	return projects, linked, nil
+}
+
+func (t *Transfer) srcProjectV2Items(ctx context.Context, projectID string) ([]ProjectV2Item, error) {
+	var items []ProjectV2Item
+	var iq ProjectV2ItemsQuery
+	v := map[string]interface{}{
+		"id":     githubv4.ID(projectID),
+		"cursor": (*githubv4.String)(nil),
+	}
+	for {
+		if err := t.SRC.Client.Query(ctx, &iq, v); err != nil {
+			return nil, err
+		}
+		items = append(items, iq.Node.ProjectV2.Items.Nodes...)
+		if !iq.Node.ProjectV2.Items.PageInfo.HasNextPage {
+			break
+		}
+		v["cursor"] = githubv4.NewString(iq.Node.ProjectV2.Items.PageInfo.EndCursor)
+	}
+	return items, nil
+}
+
+func hasSrcItems(items []ProjectV2Item, src string) bool {
+	for i := range items {
+		if _, ok := items[i].number(src); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// createProjectV2Fields creates the custom fields missing in the DST project,
+// adds the options missing in the single select fields it has, such as the
+// built-in Status, and returns all fields of it by name.
+func (t *Transfer) createProjectV2Fields(ctx context.Context, projectID string, srcFields []ProjectV2Field) (map[string]ProjectV2Field, error) {
+	fields, err := t.dstProjectV2Fields(ctx, projectID)
+	if err != nil {
+		return nil, err
+	}
+
+	changed := false
+	for _, f := range srcFields {
+		if !customFieldTypes[f.Common.DataType] {
+			continue
+		}
+		if dst, ok := fields[f.Common.Name]; ok {
+			added, err := t.addProjectV2FieldOptions(ctx, dst, f.SingleSelect.Options)
+			if err != nil {
+				return nil, err
+			}
+			changed = changed || added
+			continue
+		}
+		var m struct {
+			CreateProjectV2Field struct {
+				ClientMutationID string
+			} `graphql:"createProjectV2Field(input: $input)"`
+		}
+		input := CreateProjectV2FieldInput{
+			ProjectID:           projectID,
+			DataType:            githubv4.String(f.Common.DataType),
+			Name:                githubv4.String(f.Common.Name),
+			SingleSelectOptions: projectV2OptionInputs(f.SingleSelect.Options),
+		}
+		if err := t.DST.GraphQL.Mutate(ctx, &m, input, nil); err != nil {
+			return nil, err
+		}
+		logf(t.Log, "created project field: %s\n", f.Common.Name)
+		changed = true
+	}
+
+	if !changed {
+		return fields, nil
+	}
+	return t.dstProjectV2Fields(ctx, projectID)
+}
+
+// addProjectV2FieldOptions adds the options missing in the DST single select
+// field, and reports whether any was added. The options are replaced all at
+// once by the api, so the ones of DST are given too.
+func (t *Transfer) addProjectV2FieldOptions(ctx context.Context, dst ProjectV2Field, options []ProjectV2SingleSelectFieldOption) (bool, error) {
+	if dst.Common.DataType != "SINGLE_SELECT" {
+		return false, nil
+	}
+	have := map[string]bool{}
+	for _, o := range dst.SingleSelect.Options {
+		have[o.Name] = true
+	}
+	all := dst.SingleSelect.Options
+	for _, o := range options {
+		if !have[o.Name] {
+			all = append(all, o)
+		}
+	}
+	if len(all) == len(dst.SingleSelect.Options) {
+		return false, nil
+	}
+	var m struct {
+		UpdateProjectV2Field struct {
+			ClientMutationID string
+		} `graphql:"updateProjectV2Field(input: $input)"`
+	}
+	input := UpdateProjectV2FieldInput{
+		FieldID:             dst.Common.ID,
+		SingleSelectOptions: projectV2OptionInputs(all),
+	}
+	if err := t.DST.GraphQL.Mutate(ctx, &m, input, nil); err != nil {
+		return false, err
+	}
+	logf(t.Log, "added project field options: %s (%d options)\n", dst.Common.Name, len(all)-len(dst.SingleSelect.Options))
+	return true, nil
+}
+
+func projectV2OptionInputs(options []ProjectV2SingleSelectFieldOption) []ProjectV2SingleSelectFieldOptionInput {
+	var inputs []ProjectV2SingleSelectFieldOptionInput
+	for _, o := range options {
+		color := o.Color
+		if color == "" {
+			color = "GRAY"
+		}
+		inputs = append(inputs, ProjectV2SingleSelectFieldOptionInput{
+			Name:        githubv4.String(o.Name),
+			Color:       githubv4.String(color),
+			Description: githubv4.String(o.Description),
+		})
+	}
+	return inputs
+}
+
+func (t *Transfer) dstProjectV2Fields(ctx context.Context, projectID string) (map[string]ProjectV2Field, error) {
+	var fq ProjectV2FieldsQuery
+	v := map[string]interface{}{
+		"id": githubv4.ID(projectID),
+	}
+	if err := t.DST.GraphQL.Query(ctx, &fq, v); err != nil {
+		return nil, err
+	}
+	fields := map[string]ProjectV2Field{}
+	for _, f := range fq.Node.ProjectV2.Fields.Nodes {
+		fields[f.Common.Name] = f
+	}
+	return fields, nil
+}
+
+func (t *Transfer) addProjectV2Item(ctx context.Context, projectID string, fields map[string]ProjectV2Field, item ProjectV2Item, src string) error {
+	var itemID string
+	if n, ok := item.number(src); ok {
+		issue, err := t.dstIssue(ctx, n)
+		if err != nil {
+			return err
+		}
+		if issue == nil {
+			return nil
+		}
+		var m struct {
+			AddProjectV2ItemById struct {
+				Item struct {
+					ID string
+				}
+			} `graphql:"addProjectV2ItemById(input: $input)"`
+		}
+		input := AddProjectV2ItemByIdInput{ProjectID: projectID, ContentID: issue.GetNodeID()}
+		if err := t.DST.GraphQL.Mutate(ctx, &m, input, nil); err != nil {
+			return err
+		}
+		itemID = m.AddProjectV2ItemById.Item.ID
+	} else if item.Type == "DRAFT_ISSUE" {
+		var m struct {
+			AddProjectV2DraftIssue struct {
+				ProjectItem struct {
+					ID string
+				}
+			} `graphql:"addProjectV2DraftIssue(input: $input)"`
+		}
+		input := AddProjectV2DraftIssueInput{
+			ProjectID: projectID,
+			Title:     githubv4.String(item.Content.DraftIssue.Title),
+			Body:      githubv4.String(t.replaceBody(item.Content.DraftIssue.Body)),
+		}
+		if err := t.DST.GraphQL.Mutate(ctx, &m, input, nil); err != nil {
+			return err
+		}
+		itemID = m.AddProjectV2DraftIssue.ProjectItem.ID
+	} else {
+		// items of other repositories are left in the source project.
+		return nil
+	}
+
+	for _, fv := range item.FieldValues.Nodes {
+		var name string
+		var value ProjectV2FieldValue
+		switch fv.Typename {
+		case "ProjectV2ItemFieldTextValue":
+			name = fv.Text.Field.Common.Name
+			value.Text = githubv4.NewString(githubv4.String(fv.Text.Text))
+		case "ProjectV2ItemFieldNumberValue":
+			name = fv.Number.Field.Common.Name
+			number := githubv4.Float(fv.Number.Number)
+			value.Number = &number
+		case "ProjectV2ItemFieldDateValue":
+			name = fv.Date.Field.Common.Name
+			value.Date = githubv4.NewString(githubv4.String(fv.Date.Date))
+		case "ProjectV2ItemFieldSingleSelectValue":
+			name = fv.SingleSelect.Field.Common.Name
+			for _, o := range fields[name].SingleSelect.Options {
+				if o.Name == fv.SingleSelect.Name {
+					value.SingleSelectOptionID = githubv4.NewString(githubv4.String(o.ID))
+				}
+			}
+			if value.SingleSelectOptionID == nil {
+				logf(t.Log, "skipped project field value: %s of %s - option not found\n", fv.SingleSelect.Name, name)
+				continue
+			}
+		default:
+			continue
+		}
+		f, ok := fields[name]
+		if !ok {
+			logf(t.Log, "skipped project field value: %s - field not found\n", name)
+			continue
+		}
+		// the built-in title is of the issue or the draft.
+		if !customFieldTypes[f.Common.DataType] {
+			continue
+		}
+		var m struct {
+			UpdateProjectV2ItemFieldValue struct {
+				ClientMutationID string
+			} `graphql:"updateProjectV2ItemFieldValue(input: $input)"`
+		}
+		input := UpdateProjectV2ItemFieldValueInput{
+			ProjectID: projectID,
+			ItemID:    itemID,
+			FieldID:   f.Common.ID,
+			Value:     value,
+		}
+		if err := t.DST.GraphQL.Mutate(ctx, &m, input, nil); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,261 @@
+package mover
+
+import (
+	"context"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// seedClassicProjects makes testSrc have a project of two columns, and its
+// organization a project with an issue of testSrc and one without.
+func seedClassicProjects(f *fakeGitHub) {
+	f.AddOrg("foo")
+	issue := func(repo string, n int) *fakeCard {
+		return &fakeCard{ContentURL: f.URL + "/api/v3/repos/" + repo + "/issues/" + strconv.Itoa(n)}
+	}
+	board := f.AddProject("repos/"+testSrc, "Board", "To do", "Done")
+	f.AddCard(board.Columns[0], issue(testSrc, 1))
+	f.AddCard(board.Columns[0], &fakeCard{Note: "see src.example.com"})
+	f.AddCard(board.Columns[1], issue(testSrc, 5))
+	f.AddCard(board.Columns[1], issue("foo/other", 1))
+
+	roadmap := f.AddProject("orgs/foo", "Roadmap", "Next")
+	f.AddCard(roadmap.Columns[0], issue(testSrc, 2))
+	other := f.AddProject("orgs/foo", "Other", "Next")
+	f.AddCard(other.Columns[0], issue("foo/other", 1))
+}
+
+func TestExecClassicProjects(t *testing.T) {
+	f := newFakeGitHub()
+	defer f.Close()
+	// the issues are found only after their imports.
+	f.ImportPolls = 2
+	seedSrc(f)
+	seedClassicProjects(f)
+	f.AddOrg("bar")
+	dir := testDir(t)
+	defer os.RemoveAll(dir)
+
+	o := testOptions(f, dir)
+	o.Dst = "bar/dst"
+	o.Projects = ProjectsClassic
+	tr, err := New(context.Background(), o, testShared())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := tr.Exec(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	projects := f.Projects("repos/bar/dst")
+	if len(projects) != 1 || projects[0].Name != "Board" || len(projects[0].Columns) != 2 {
+		t.Fatalf("repository projects: %v", projects)
+	}
+	todo, done := projects[0].Columns[0], projects[0].Columns[1]
+	if todo.Name != "To do" || len(todo.Cards) != 2 || done.Name != "Done" || len(done.Cards) != 2 {
+		t.Fatalf("columns: %s %d, %s %d", todo.Name, len(todo.Cards), done.Name, len(done.Cards))
+	}
+	// the cards are in the order of SRC, linked to the moved issues.
+	if c := todo.Cards[0]; c.ContentID != issueID("bar/dst", 1) || c.ContentType != "Issue" {
+		t.Errorf("card of #1: %+v", c)
+	}
+	if c := todo.Cards[1]; c.Note != "see dst.example.com" {
+		t.Errorf("note: %+v", c)
+	}
+	if c := done.Cards[0]; c.ContentID != issueID("bar/dst", 5) {
+		t.Errorf("card of #5: %+v", c)
+	}
+	if c := done.Cards[1]; !strings.HasSuffix(c.Note, "/repos/foo/other/issues/1") {
+		t.Errorf("card of another repository: %+v", c)
+	}
+
+	orgProjects := f.Projects("orgs/bar")
+	if len(orgProjects) != 1 || orgProjects[0].Name != "Roadmap" {
+		t.Fatalf("organization projects: %v", orgProjects)
+	}
+	if c := orgProjects[0].Columns[0].Cards; len(c) != 1 || c[0].ContentID != issueID("bar/dst", 2) {
+		t.Errorf("card of #2: %v", c)
+	}
+}
+
+func TestExecClassicProjectsOfUser(t *testing.T) {
+	for _, login := range []string{"bar", "baz"} {
+		f := newFakeGitHub()
+		f.ImportPolls = 2
+		seedSrc(f)
+		seedClassicProjects(f)
+		f.AddUser("bar")
+		f.AuthLogin = login
+		dir := testDir(t)
+
+		o := testOptions(f, dir)
+		o.Dst = "bar/dst"
+		o.Projects = ProjectsClassic
+		tr, err := New(context.Background(), o, testShared())
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = tr.Exec(context.Background())
+		if login == "bar" {
+			if err != nil {
+				t.Error(err)
+			}
+			if p := f.Projects("users/bar"); len(p) != 1 || p[0].Name != "Roadmap" {
+				t.Errorf("user projects: %v", p)
+			}
+		} else if err == nil || !strings.Contains(err.Error(), "token of baz") {
+			t.Errorf("project of bar by the token of baz: %v", err)
+		}
+
+		f.Close()
+		os.RemoveAll(dir)
+	}
+}
+
+// seedProjectsV2 makes testSrc have a project with custom fields, an issue,
+// a draft and an issue of another repository.
+func seedProjectsV2(f *fakeGitHub) {
+	f.AddOrg("foo")
+	f.AddOrg("bar")
+	p := f.AddProjectV2("foo", testSrc, "Board")
+	p.ShortDescription = "the board"
+	p.Readme = "see src.example.com"
+	for _, v := range p.Fields {
+		if v.Name == "Status" {
+			f.setOptions(v, []string{"Todo", "Blocked", "Done"})
+		}
+	}
+	f.AddField(p, "Points", "NUMBER")
+	f.AddField(p, "Size", "SINGLE_SELECT", "S", "M")
+	f.AddItem(p, &fakeProjectV2Item{Type: "ISSUE", Repo: testSrc, Number: 1, Values: []fakeProjectV2Value{
+		{Field: "Title", Type: "TITLE", Text: "first"},
+		{Field: "Status", Type: "SINGLE_SELECT", Option: "Blocked"},
+		{Field: "Points", Type: "NUMBER", Number: 3},
+		{Field: "Size", Type: "SINGLE_SELECT", Option: "M"},
+	}})
+	f.AddItem(p, &fakeProjectV2Item{Type: "DRAFT_ISSUE", Title: "plan", Body: "on src.example.com", Values: []fakeProjectV2Value{
+		{Field: "Status", Type: "SINGLE_SELECT", Option: "Todo"},
+	}})
+	f.AddItem(p, &fakeProjectV2Item{Type: "ISSUE", Repo: "foo/other", Number: 1})
+}
+
+func TestExecProjectsV2(t *testing.T) {
+	f := newFakeGitHub()
+	defer f.Close()
+	// the issues are found only after their imports.
+	f.ImportPolls = 2
+	seedSrc(f)
+	seedProjectsV2(f)
+	dir := testDir(t)
+	defer os.RemoveAll(dir)
+
+	o := testOptions(f, dir)
+	o.Dst = "bar/dst"
+	o.Projects = ProjectsV2
+	tr, err := New(context.Background(), o, testShared())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := tr.Exec(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	projects := f.ProjectsV2("bar/dst")
+	if len(projects) != 1 {
+		t.Fatalf("%d projects", len(projects))
+	}
+	dst := projects[0]
+	if dst.Owner != "bar" || dst.Title != "Board" || dst.ShortDescription != "the board" || dst.Readme != "see dst.example.com" {
+		t.Errorf("project: %s %q %q %q", dst.Owner, dst.Title, dst.ShortDescription, dst.Readme)
+	}
+	var status []string
+	for _, v := range dst.Fields {
+		if v.Name == "Status" {
+			for _, o := range v.Options {
+				status = append(status, o.Name)
+			}
+		}
+	}
+	// the missing option is added to the built-in options.
+	if got := strings.Join(status, ","); got != "Todo,In Progress,Done,Blocked" {
+		t.Errorf("status options: %s", got)
+	}
+	if len(dst.Items) != 2 {
+		t.Fatalf("%d items", len(dst.Items))
+	}
+	issue, draft := dst.Items[0], dst.Items[1]
+	if issue.Type != "ISSUE" || issue.Repo != "bar/dst" || issue.Number != 1 {
+		t.Errorf("issue item: %+v", issue)
+	}
+	if v, _ := issue.Value("Status"); v.Option != "Blocked" {
+		t.Errorf("status of the issue: %+v", v)
+	}
+	if v, _ := issue.Value("Points"); v.Number != 3 {
+		t.Errorf("points of the issue: %+v", v)
+	}
+	if v, _ := issue.Value("Size"); v.Option != "M" {
+		t.Errorf("size of the issue: %+v", v)
+	}
+	if _, ok := issue.Value("Title"); ok {
+		t.Error("the built-in title is set")
+	}
+	if draft.Type != "DRAFT_ISSUE" || draft.Title != "plan" || draft.Body != "on dst.example.com" {
+		t.Errorf("draft item: %+v", draft)
+	}
+	if v, _ := draft.Value("Status"); v.Option != "Todo" {
+		t.Errorf("status of the draft: %+v", v)
+	}
+}
+
+func TestExecProjectsV2Resume(t *testing.T) {
+	f := newFakeGitHub()
+	defer f.Close()
+	seedSrc(f)
+	seedProjectsV2(f)
+	dir := testDir(t)
+	defer os.RemoveAll(dir)
+
+	o := testOptions(f, dir)
+	o.Dst = "bar/dst"
+	o.Projects = ProjectsV2
+	// the run fails at the draft, after the project and the issue item.
+	f.FailGraphQL("addProjectV2DraftIssue", 1)
+	tr, err := New(context.Background(), o, testShared())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := tr.Exec(context.Background()); err == nil {
+		t.Fatal("no error of the draft")
+	}
+	if got := f.ProjectsV2("bar/dst"); len(got) != 1 || len(got[0].Items) != 1 {
+		t.Fatalf("projects of the failed run: %v", got)
+	}
+
+	tr, err = New(context.Background(), o, testShared())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := tr.Exec(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	projects := f.ProjectsV2("bar/dst")
+	if len(projects) != 1 {
+		t.Fatalf("project is created again: %d projects", len(projects))
+	}
+	dst := projects[0]
+	names := map[string]int{}
+	for _, v := range dst.Fields {
+		names[v.Name]++
+	}
+	if names["Points"] != 1 || names["Size"] != 1 {
+		t.Errorf("fields: %v", names)
+	}
+	if len(dst.Items) != 2 || dst.Items[0].Number != 1 || dst.Items[1].Title != "plan" {
+		t.Fatalf("items: %+v", dst.Items)
+	}
+	if v, _ := dst.Items[1].Value("Status"); v.Option != "Todo" {
+		t.Errorf("status of the draft: %+v", v)
+	}
+}
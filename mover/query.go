@@ -1,4 +1,4 @@
-package main
+package mover
 
 import (
 	"time"
@@ -30,6 +30,7 @@ type Issue struct {
 	State     string
 	Number    int
 	Closed    bool
+	URL       string
 	Milestone struct {
 		Number int
 	}
@@ -46,18 +47,21 @@ type Issue struct {
 		TotalCount githubv4.Int
 	} `graphql:"labels(first: 100, after: null)"`
 	Comments struct {
-		Nodes []struct {
-			Author struct {
-				Login     string
-				AvatarURL string `graphql:"avatarUrl(size: 100)"`
-			}
-			Body      string
-			CreatedAt time.Time
-		}
+		Nodes      []IssueComment
 		TotalCount githubv4.Int
 	} `graphql:"comments(first: 100, after: null)"`
 }
 
+type IssueComment struct {
+	Author struct {
+		Login     string
+		AvatarURL string `graphql:"avatarUrl(size: 100)"`
+	}
+	Body      string
+	CreatedAt time.Time
+	URL       string
+}
+
 type LabelsQuery struct {
 	Repository struct {
 		Labels struct {
@@ -90,7 +94,7 @@ type IssuesQuery struct {
 				EndCursor   githubv4.String
 				HasNextPage bool
 			}
-		} `graphql:"issues(first: 100, after: $cursor, orderBy: {field: CREATED_AT, direction: ASC})"`
+		} `graphql:"issues(first: 100, after: $cursor, orderBy: {field: CREATED_AT, direction: ASC}, filterBy: $filterBy)"`
 	} `graphql:"repository(owner: $owner, name: $repo)"`
 }
 
@@ -102,6 +106,6 @@ type PullReqeustsQuery struct {
 				EndCursor   githubv4.String
 				HasNextPage bool
 			}
-		} `graphql:"pullRequests(first: 100, after: $cursor, orderBy: {field: CREATED_AT, direction: ASC})"`
+		} `graphql:"pullRequests(first: 100, after: $cursor, orderBy: {field: CREATED_AT, direction: ASC}, states: $states, labels: $labels)"`
 	} `graphql:"repository(owner: $owner, name: $repo)"`
 }
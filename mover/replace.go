@@ -0,0 +1,436 @@
+package mover
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// The scopes of the replacement rules. The body scope is also of the
+// discussions, project notes and wiki pages.
+const (
+	defaultReplacePath = "replace.yml"
+
+	ScopeTitle      = "title"
+	ScopeBody       = "body"
+	ScopeComments   = "comments"
+	ScopeLabels     = "labels"
+	ScopeMilestones = "milestones"
+)
+
+func validScope(scope string) bool {
+	switch scope {
+	case ScopeTitle, ScopeBody, ScopeComments, ScopeLabels, ScopeMilestones:
+		return true
+	}
+	return false
+}
+
+// The scopes of the rules without the scope: the body rules keep
+// replacing the bodies and comments as before the scopes.
+var (
+	defaultBodyScopes = []string{ScopeBody, ScopeComments}
+	defaultRuleScopes = []string{ScopeTitle, ScopeBody, ScopeComments}
+)
+
+// R is a replacement rule. Wrong is a regular expression when Regex is
+// true, and Right can have its groups like $1. Code is whether the rule
+// replaces the code of the bodies too, when they are rewritten as Markdown.
+type R struct {
+	Wrong string   `yaml:"wrong"`
+	Right string   `yaml:"right"`
+	Regex bool     `yaml:"regex"`
+	Scope []string `yaml:"scope"`
+	Code  bool     `yaml:"code"`
+
+	re   *regexp.Regexp
+	file string
+	line int
+}
+
+// LabelRule renames the labels of Wrong to Right, which merges them when
+// Wrong has several, drops them when Drop is true, and sets the color and
+// the description when given.
+type LabelRule struct {
+	Wrong       Names  `yaml:"wrong"`
+	Right       string `yaml:"right"`
+	Drop        bool   `yaml:"drop"`
+	Color       string `yaml:"color"`
+	Description string `yaml:"description"`
+
+	file string
+	line int
+}
+
+// Names are the names given by a name or a list of them in yaml.
+type Names []string
+
+func (n *Names) UnmarshalYAML(value *yaml.Node) error {
+	if value.Kind == yaml.ScalarNode {
+		*n = Names{value.Value}
+		return nil
+	}
+	var names []string
+	if err := value.Decode(&names); err != nil {
+		return err
+	}
+	*n = names
+	return nil
+}
+
+type Map struct {
+	User []R `yaml:"user"`
+	Body []R `yaml:"body"`
+	// Rules are applied in order after Body, to the title, body and
+	// comments unless the scope is given.
+	Rules  []R         `yaml:"rules"`
+	Labels []LabelRule `yaml:"labels"`
+}
+
+// LoadReplacementMap loads replace.yml of the working directory, or
+// returns the empty map when it does not exist.
+func LoadReplacementMap() (*Map, error) {
+	dir, err := os.Getwd()
+	if err != nil {
+		return nil, err
+	}
+	m, err := LoadReplacementMapFile(filepath.Join(dir, defaultReplacePath))
+	if os.IsNotExist(err) {
+		return &Map{}, nil
+	}
+	return m, err
+}
+
+// LoadReplacementMapFiles loads the files and merges them in order: the
+// users and labels of a later file override the ones of the former files,
+// and the body rules and rules are appended to them. The overrides are
+// printed, and the rules conflicting with the ones of the former files
+// are errors, as the former ones would replace their wrongs first.
+func LoadReplacementMapFiles(paths []string) (*Map, error) {
+	return loadReplacementMapFiles(paths, nil)
+}
+
+// loadReplacementMapFiles is LoadReplacementMapFiles printing the
+// overrides to w, which is empty without the files.
+func loadReplacementMapFiles(paths []string, w io.Writer) (*Map, error) {
+	m := &Map{}
+	for _, path := range paths {
+		v, err := LoadReplacementMapFile(path)
+		if err != nil {
+			return nil, err
+		}
+		if err := m.merge(v, w); err != nil {
+			return nil, err
+		}
+	}
+	return m, nil
+}
+
+func LoadReplacementMapFile(path string) (*Map, error) {
+	buf, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	m := Map{}
+	dec := yaml.NewDecoder(bytes.NewReader(buf))
+	// the unknown keys are errors, so that a misspelled key like rigth
+	// does not leave the rule without its right.
+	dec.KnownFields(true)
+	if err := dec.Decode(&m); err != nil && err != io.EOF {
+		return nil, fmt.Errorf("%s: %s", path, err)
+	}
+	var doc yaml.Node
+	if err := yaml.Unmarshal(buf, &doc); err != nil {
+		return nil, fmt.Errorf("%s: %s", path, err)
+	}
+	m.setLines(&doc)
+	m.setFile(path)
+	if err := m.compile(); err != nil {
+		return nil, fmt.Errorf("%s:%s", path, err)
+	}
+
+	return &m, nil
+}
+
+// setLines sets the lines of the wrong of the rules for the errors.
+func (m *Map) setLines(doc *yaml.Node) {
+	if len(doc.Content) == 0 || doc.Content[0].Kind != yaml.MappingNode {
+		return
+	}
+	root := doc.Content[0]
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		items := root.Content[i+1].Content
+		for j, item := range items {
+			line := item.Line
+			for k := 0; k+1 < len(item.Content); k += 2 {
+				if item.Content[k].Value == "wrong" {
+					line = item.Content[k+1].Line
+				}
+			}
+			switch root.Content[i].Value {
+			case "user":
+				if j < len(m.User) {
+					m.User[j].line = line
+				}
+			case "body":
+				if j < len(m.Body) {
+					m.Body[j].line = line
+				}
+			case "rules":
+				if j < len(m.Rules) {
+					m.Rules[j].line = line
+				}
+			case "labels":
+				if j < len(m.Labels) {
+					m.Labels[j].line = line
+				}
+			}
+		}
+	}
+}
+
+func (m *Map) setFile(path string) {
+	for _, rules := range [][]R{m.User, m.Body, m.Rules} {
+		for i := range rules {
+			rules[i].file = path
+		}
+	}
+	for i := range m.Labels {
+		m.Labels[i].file = path
+	}
+}
+
+// compile compiles the regular expressions and checks the rules, and the
+// duplicate and conflicting wrongs. The errors start with the line.
+func (m *Map) compile() error {
+	users := map[string]R{}
+	for _, r := range m.User {
+		if r.Wrong == "" {
+			return fmt.Errorf("%d: empty wrong of the user", r.line)
+		}
+		if err := duplicate("user", users, r); err != nil {
+			return err
+		}
+	}
+	for _, rules := range [][]R{m.Body, m.Rules} {
+		seen := map[string]R{}
+		for i := range rules {
+			r := &rules[i]
+			if r.Wrong == "" {
+				return fmt.Errorf("%d: empty wrong of the rule", r.line)
+			}
+			if err := duplicate("rule", seen, *r); err != nil {
+				return err
+			}
+			for _, s := range r.Scope {
+				if !validScope(s) {
+					return fmt.Errorf("%d: invalid scope of %q: %s", r.line, r.Wrong, s)
+				}
+			}
+			if !r.Regex {
+				continue
+			}
+			re, err := regexp.Compile(r.Wrong)
+			if err != nil {
+				return fmt.Errorf("%d: invalid regex %q: %s", r.line, r.Wrong, err)
+			}
+			r.re = re
+		}
+	}
+	seen := map[string]int{}
+	for _, r := range m.Labels {
+		if len(r.Wrong) == 0 {
+			return fmt.Errorf("%d: empty wrong of the label rule", r.line)
+		}
+		if r.Drop && (r.Right != "" || r.Color != "" || r.Description != "") {
+			return fmt.Errorf("%d: dropped label %s has right, color or description", r.line, r.Wrong[0])
+		}
+		if r.Color != "" && !validColor(r.Color) {
+			return fmt.Errorf("%d: invalid color of label %s: %s", r.line, r.Wrong[0], r.Color)
+		}
+		for _, name := range r.Wrong {
+			if line, ok := seen[strings.ToLower(name)]; ok {
+				return fmt.Errorf("%d: duplicate label %s, first at line %d", r.line, name, line)
+			}
+			seen[strings.ToLower(name)] = r.line
+		}
+	}
+	return nil
+}
+
+// ruleKey is the key of the wrong of r. The same wrong of the other
+// scopes is not a duplicate.
+func ruleKey(r R) string {
+	return fmt.Sprintf("%t %v %s", r.Regex, r.Scope, r.Wrong)
+}
+
+// duplicate returns the error of r when seen has its wrong, which is a
+// duplicate with the same right, or a conflict with another right.
+func duplicate(what string, seen map[string]R, r R) error {
+	key := ruleKey(r)
+	first, ok := seen[key]
+	if !ok {
+		seen[key] = r
+		return nil
+	}
+	if first.Right == r.Right {
+		return fmt.Errorf("%d: duplicate %s %q, first at line %d", r.line, what, r.Wrong, first.line)
+	}
+	return fmt.Errorf("%d: conflicting %s %q: %q and %q at line %d", r.line, what, r.Wrong, r.Right, first.Right, first.line)
+}
+
+// merge merges the map of a later file into m.
+func (m *Map) merge(o *Map, w io.Writer) error {
+	for _, r := range o.User {
+		overridden := false
+		for i := range m.User {
+			if m.User[i].Wrong == r.Wrong {
+				if m.User[i].Right != r.Right {
+					logf(w, "overridden user: %s - %s at %s:%d by %s at %s:%d\n",
+						r.Wrong, m.User[i].Right, m.User[i].file, m.User[i].line, r.Right, r.file, r.line)
+				}
+				m.User[i] = r
+				overridden = true
+			}
+		}
+		if !overridden {
+			m.User = append(m.User, r)
+		}
+	}
+
+	for _, v := range []struct {
+		rules *[]R
+		later []R
+	}{{&m.Body, o.Body}, {&m.Rules, o.Rules}} {
+		seen := map[string]R{}
+		for _, r := range *v.rules {
+			seen[ruleKey(r)] = r
+		}
+		for _, r := range v.later {
+			first, ok := seen[ruleKey(r)]
+			if ok && first.Right != r.Right {
+				return fmt.Errorf("%s:%d: conflicting rule %q: %q and %q at %s:%d, which is applied first",
+					r.file, r.line, r.Wrong, r.Right, first.Right, first.file, first.line)
+			}
+		}
+		*v.rules = append(*v.rules, v.later...)
+	}
+
+	overridden := map[string]LabelRule{}
+	for _, r := range o.Labels {
+		for _, name := range r.Wrong {
+			overridden[strings.ToLower(name)] = r
+		}
+	}
+	var labels []LabelRule
+	for _, r := range m.Labels {
+		var names Names
+		for _, name := range r.Wrong {
+			later, ok := overridden[strings.ToLower(name)]
+			if !ok {
+				names = append(names, name)
+				continue
+			}
+			logf(w, "overridden label: %s - %s:%d by %s:%d\n", name, r.file, r.line, later.file, later.line)
+		}
+		if len(names) > 0 {
+			r.Wrong = names
+			labels = append(labels, r)
+		}
+	}
+	m.Labels = append(labels, o.Labels...)
+	return nil
+}
+
+var reColor = regexp.MustCompile(`^[0-9a-fA-F]{6}$`)
+
+func validColor(color string) bool {
+	return reColor.MatchString(color)
+}
+
+// Label returns the label renamed, merged and recolored by the label rules,
+// or false when it is dropped. The names are matched case-insensitively,
+// as GitHub does.
+func (m *Map) Label(v Label) (Label, bool) {
+	for _, r := range m.Labels {
+		for _, name := range r.Wrong {
+			if !strings.EqualFold(name, v.Name) {
+				continue
+			}
+			if r.Drop {
+				return v, false
+			}
+			if r.Right != "" {
+				v.Name = r.Right
+			}
+			if r.Color != "" {
+				v.Color = r.Color
+			}
+			if r.Description != "" {
+				v.Description = r.Description
+			}
+			return v, true
+		}
+	}
+	return v, true
+}
+
+// Replace applies the body rules and then the rules of the scope to s.
+func (m *Map) Replace(scope, s string) string {
+	return m.replace(scope, s, false)
+}
+
+// ReplaceCode applies the rules of the scope for the code to s.
+func (m *Map) ReplaceCode(scope, s string) string {
+	return m.replace(scope, s, true)
+}
+
+func (m *Map) replace(scope, s string, code bool) string {
+	for _, r := range m.Body {
+		if r.in(scope, defaultBodyScopes) && (!code || r.Code) {
+			s = r.replace(s)
+		}
+	}
+	for _, r := range m.Rules {
+		if r.in(scope, defaultRuleScopes) && (!code || r.Code) {
+			s = r.replace(s)
+		}
+	}
+	return s
+}
+
+func (r R) in(scope string, defaults []string) bool {
+	scopes := r.Scope
+	if len(scopes) == 0 {
+		scopes = defaults
+	}
+	for _, s := range scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+func (r R) replace(s string) string {
+	if r.Regex {
+		// the rules not compiled by the map are compiled here.
+		re := r.re
+		if re == nil {
+			var err error
+			if re, err = regexp.Compile(r.Wrong); err != nil {
+				return s
+			}
+		}
+		return re.ReplaceAllString(s, r.Right)
+	}
+	return strings.ReplaceAll(s, r.Wrong, r.Right)
+}
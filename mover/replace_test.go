@@ -0,0 +1,329 @@
+package mover
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestReplacementRules(t *testing.T) {
+	dir := testDir(t)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "rules.yml")
+	yml := `body:
+  - wrong: old.example.com
+    right: new.example.com
+rules:
+  - wrong: '\b(?:JIRA|PROJ)-(\d+)'
+    right: 'FOO-$1'
+    regex: true
+  - wrong: 'https://new\.example\.com/([^/]+)/commit/([0-9a-f]{7})[0-9a-f]*'
+    right: '$1@$2'
+    regex: true
+    scope: [body]
+  - wrong: 'area/'
+    right: ''
+    scope: [labels, milestones]
+`
+	if err := ioutil.WriteFile(path, []byte(yml), 0644); err != nil {
+		t.Fatal(err)
+	}
+	m, err := LoadReplacementMapFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		scope string
+		in    string
+		want  string
+	}{
+		{ScopeTitle, "Fix PROJ-12 and JIRA-3", "Fix FOO-12 and FOO-3"},
+		{ScopeTitle, "see old.example.com", "see old.example.com"},
+		// the body rules are applied before the rules.
+		{ScopeBody, "https://old.example.com/bar/commit/0123456789abcdef", "bar@0123456"},
+		{ScopeComments, "https://old.example.com/bar/commit/0123456789abcdef", "https://new.example.com/bar/commit/0123456789abcdef"},
+		{ScopeLabels, "area/api", "api"},
+		{ScopeLabels, "PROJ-1", "PROJ-1"},
+		{ScopeMilestones, "area/v1", "v1"},
+	}
+	for _, tt := range tests {
+		if got := m.Replace(tt.scope, tt.in); got != tt.want {
+			t.Errorf("%s %q: %q, want %q", tt.scope, tt.in, got, tt.want)
+		}
+	}
+
+	invalid := []string{
+		"rules:\n  - wrong: '('\n    regex: true\n",
+		"rules:\n  - wrong: foo\n    scope: [titles]\n",
+		"body:\n  - wrong: ''\n    right: foo\n",
+	}
+	for _, v := range invalid {
+		if err := ioutil.WriteFile(path, []byte(v), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := LoadReplacementMapFile(path); err == nil {
+			t.Errorf("no error: %q", v)
+		}
+	}
+}
+
+func TestExecReplacementRules(t *testing.T) {
+	f := newFakeGitHub()
+	defer f.Close()
+	f.AddUser("alice-dst")
+	seedSrc(f)
+	dir := testDir(t)
+	defer os.RemoveAll(dir)
+	yml := "rules:\n  - wrong: '^(first|second)$'\n    right: 'the $1'\n    regex: true\n  - wrong: help wanted\n    right: bug\n    scope: [labels]\n"
+	if err := ioutil.WriteFile(filepath.Join(dir, "replace.yml"), []byte(yml), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tr, err := New(context.Background(), testOptions(f, dir), testShared())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := tr.Exec(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	dst := f.Repo(testDst)
+	if got := dst.Issues[1].Title; got != "the first" {
+		t.Errorf("#1 title: %q", got)
+	}
+	if got := dst.Issues[4].Title; got != "pull" {
+		t.Errorf("#4 title: %q", got)
+	}
+	// help wanted is merged into bug, and the dummy label is added.
+	if len(dst.Labels) != 2 || dst.Labels[0].Name != "bug" {
+		t.Errorf("labels: %v", dst.Labels)
+	}
+
+	tr, err = New(context.Background(), testOptions(f, dir), testShared())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := tr.Fetch(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if err := tr.Verify(context.Background()); err != nil {
+		t.Errorf("verify of the replaced titles: %s", err)
+	}
+}
+
+func TestExecLabelRules(t *testing.T) {
+	f := newFakeGitHub()
+	defer f.Close()
+	f.AddUser("alice-dst")
+	seedSrc(f)
+	src := f.Repo(testSrc)
+	src.Labels = append(src.Labels, Label{Name: "defect", Color: "0000ff"}, Label{Name: "wontfix", Color: "ffffff"})
+	for _, name := range []string{"Defect", "wontfix"} {
+		src.Issues[1].Labels.Nodes = append(src.Issues[1].Labels.Nodes, struct{ Name string }{name})
+	}
+	src.Issues[2].Labels.Nodes = append(src.Issues[2].Labels.Nodes, struct{ Name string }{"help wanted"})
+	dir := testDir(t)
+	defer os.RemoveAll(dir)
+	yml := `labels:
+  - wrong: [bug, defect]
+    right: type/bug
+    color: d73a4a
+  - wrong: wontfix
+    drop: true
+  - wrong: help wanted
+    color: 008672
+`
+	if err := ioutil.WriteFile(filepath.Join(dir, "replace.yml"), []byte(yml), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, isImport := range []bool{true, false} {
+		delete(f.repos, testDst)
+		os.Remove(filepath.Join(dir, "state.json"))
+		o := testOptions(f, dir)
+		o.IsImport = isImport
+		o.DummyLabel = ""
+		tr, err := New(context.Background(), o, testShared())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := tr.Exec(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+
+		dst := f.Repo(testDst)
+		want := []Label{{Name: "type/bug", Color: "d73a4a"}, {Name: "help wanted", Color: "008672"}}
+		if len(dst.Labels) != len(want) {
+			t.Fatalf("import %t: labels %v, want %v", isImport, dst.Labels, want)
+		}
+		for i, l := range want {
+			if dst.Labels[i].Name != l.Name || dst.Labels[i].Color != l.Color {
+				t.Errorf("import %t: label %d: %v, want %v", isImport, i, dst.Labels[i], l)
+			}
+		}
+		if got := dst.Issues[1].Labels.Nodes; len(got) != 1 || got[0].Name != "type/bug" {
+			t.Errorf("import %t: #1 labels: %v", isImport, got)
+		}
+		if got := dst.Issues[2].Labels.Nodes; len(got) != 1 || got[0].Name != "help wanted" {
+			t.Errorf("import %t: #2 labels: %v", isImport, got)
+		}
+	}
+
+	invalid := []string{
+		"labels:\n  - wrong: foo\n    drop: true\n    right: bar\n",
+		"labels:\n  - wrong: foo\n    color: red\n",
+		"labels:\n  - wrong: [foo, Bar]\n    right: a\n  - wrong: bar\n    right: b\n",
+	}
+	for _, v := range invalid {
+		path := filepath.Join(dir, "invalid.yml")
+		if err := ioutil.WriteFile(path, []byte(v), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := LoadReplacementMapFile(path); err == nil {
+			t.Errorf("no error: %q", v)
+		}
+	}
+}
+
+func TestLoadReplacementMapFiles(t *testing.T) {
+	dir := testDir(t)
+	defer os.RemoveAll(dir)
+	write := func(name, yml string) string {
+		path := filepath.Join(dir, name)
+		if err := ioutil.WriteFile(path, []byte(yml), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	org := write("org.yml", `user:
+  - wrong: alice
+    right: alice-org
+  - wrong: bob
+    right: bob-org
+body:
+  - wrong: old.example.com
+    right: new.example.com
+labels:
+  - wrong: [bug, defect]
+    right: type/bug
+`)
+	repo := write("repo.yml", `user:
+  - wrong: alice
+    right: alice-repo
+body:
+  - wrong: new.example.com/foo
+    right: new.example.com/bar
+labels:
+  - wrong: defect
+    drop: true
+`)
+
+	m, err := LoadReplacementMapFiles([]string{org, repo})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tr := &Transfer{Replace: m}
+	if got := tr.replaceUser("alice"); got != "alice-repo" {
+		t.Errorf("alice: %s, want the one of the later file", got)
+	}
+	if got := tr.replaceUser("bob"); got != "bob-org" {
+		t.Errorf("bob: %s", got)
+	}
+	if got := tr.replaceBody("old.example.com/foo"); got != "new.example.com/bar" {
+		t.Errorf("body: %s, want the rules in order", got)
+	}
+	if l, ok := m.Label(Label{Name: "bug"}); !ok || l.Name != "type/bug" {
+		t.Errorf("bug: %v %t", l, ok)
+	}
+	if _, ok := m.Label(Label{Name: "defect"}); ok {
+		t.Error("defect is not dropped by the later file")
+	}
+
+	invalid := []struct {
+		yml  string
+		want string
+	}{
+		{"user:\n  - wrong: alice\n    right: a\n  - wrong: alice\n    right: a\n", `:4: duplicate user "alice", first at line 2`},
+		{"user:\n  - wrong: alice\n    right: a\n  - wrong: alice\n    right: b\n", `:4: conflicting user "alice": "b" and "a" at line 2`},
+		{"body:\n  - wrong: foo\n    right: a\n\n  - wrong: foo\n    right: b\n", `:5: conflicting rule "foo"`},
+		{"labels:\n  - wrong: [bug]\n    right: a\n  - wrong:\n      - Bug\n    right: b\n", `:5: duplicate label Bug, first at line 2`},
+		{"rules:\n  - wrong: foo\n    rigth: bar\n", "field rigth not found"},
+		// the rule of the former file replaces the wrong first.
+		{"body:\n  - wrong: old.example.com\n    right: other.example.com\n",
+			`invalid.yml:2: conflicting rule "old.example.com": "other.example.com" and "new.example.com" at ` + org + ":7"},
+	}
+	for _, tt := range invalid {
+		path := write("invalid.yml", tt.yml)
+		_, err := LoadReplacementMapFiles([]string{org, path})
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%q: %v, want %q", tt.yml, err, tt.want)
+		}
+	}
+	// the same wrong of another scope is not a duplicate.
+	path := write("scopes.yml", "rules:\n  - wrong: foo\n    right: a\n    scope: [labels]\n  - wrong: foo\n    right: b\n")
+	if _, err := LoadReplacementMapFile(path); err != nil {
+		t.Error(err)
+	}
+	// the same rule of the former file is not a conflict.
+	path = write("same.yml", "body:\n  - wrong: old.example.com\n    right: new.example.com\n")
+	if _, err := LoadReplacementMapFiles([]string{org, path}); err != nil {
+		t.Error(err)
+	}
+	if _, err := LoadReplacementMapFiles([]string{org, filepath.Join(dir, "missing.yml")}); err == nil {
+		t.Error("missing file given explicitly")
+	}
+}
+
+func TestLoadReplacementMapOptional(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(wd)
+	empty, err := ioutil.TempDir("", "mover-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(empty)
+	if err := os.Chdir(empty); err != nil {
+		t.Fatal(err)
+	}
+	m, err := LoadReplacementMap()
+	if err != nil || len(m.User) != 0 {
+		t.Errorf("without replace.yml: %v, %v", m, err)
+	}
+
+	o, err := LoadOptions(CmdStatus, []string{"-replace=a.yml,b.yml", "-replace", "c.yml"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Join(o.replacePaths(), " "); got != "a.yml b.yml c.yml" {
+		t.Errorf("replace flags: %s", got)
+	}
+
+	// the CLI takes replace.yml of the working directory, and the library
+	// does not.
+	if o, err = LoadOptions(CmdStatus, nil); err != nil || o.ReplacePath != "" {
+		t.Errorf("without replace.yml: %q, %v", o.ReplacePath, err)
+	}
+	if err := ioutil.WriteFile("replace.yml", []byte("user:\n  - wrong: alice\n    right: alice-dst\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if o, err = LoadOptions(CmdStatus, nil); err != nil || o.ReplacePath != "replace.yml" {
+		t.Errorf("with replace.yml: %q, %v", o.ReplacePath, err)
+	}
+	o = DefaultOptions()
+	o.Src, o.Dst = testSrc, testDst
+	o.StatePath = filepath.Join(empty, "state.json")
+	tr, err := NewWith(o, &SRC{Owner: "foo", Name: "src"}, &DST{Owner: "foo", Name: "dst"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tr.Replace.User) != 0 {
+		t.Errorf("replace.yml of the library: %v", tr.Replace.User)
+	}
+}
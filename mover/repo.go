@@ -0,0 +1,156 @@
+package mover
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/google/go-github/v32/github"
+)
+
+// ensureDstRepo creates the DST repository with the settings of the SRC
+// one when it does not exist, and returns whether it was created.
+// With noDefaultLabels, the labels GitHub adds to a new repository are
+// deleted, so that only the labels of SRC are created.
+// The default branch is left to setDefaultBranch, as the one of an empty
+// repository cannot be changed until it is pushed.
+func ensureDstRepo(ctx context.Context, w io.Writer, client *github.Client, owner, name string, src *github.Repository, noDefaultLabels bool) (bool, error) {
+	_, resp, err := client.Repositories.Get(ctx, owner, name)
+	if err == nil {
+		return false, nil
+	}
+	if resp == nil || resp.StatusCode != http.StatusNotFound {
+		return false, err
+	}
+
+	visibility := src.GetVisibility()
+	if visibility == "" {
+		visibility = "public"
+		if src.GetPrivate() {
+			visibility = "private"
+		}
+	}
+	private := visibility != "public"
+	input := &github.Repository{
+		Name:        &name,
+		Description: src.Description,
+		Homepage:    src.Homepage,
+		Private:     &private,
+		Visibility:  &visibility,
+		HasIssues:   src.HasIssues,
+		HasWiki:     src.HasWiki,
+	}
+	// an empty org creates the repository of the authenticated user.
+	org := owner
+	user, err := isUserOwner(ctx, client, owner)
+	if err != nil {
+		return false, err
+	}
+	if user {
+		org = ""
+	}
+	if _, _, err := client.Repositories.Create(ctx, org, input); err != nil {
+		return false, err
+	}
+	logf(w, "created repository: %s/%s\n", owner, name)
+
+	if len(src.Topics) > 0 {
+		if _, _, err := client.Repositories.ReplaceAllTopics(ctx, owner, name, src.Topics); err != nil {
+			return true, err
+		}
+	}
+
+	if noDefaultLabels {
+		if err := deleteLabels(ctx, w, client, owner, name); err != nil {
+			return true, err
+		}
+	}
+
+	return true, nil
+}
+
+func deleteLabels(ctx context.Context, w io.Writer, client *github.Client, owner, name string) error {
+	var labels []*github.Label
+	opt := &github.ListOptions{PerPage: 100}
+	for {
+		got, resp, err := client.Issues.ListLabels(ctx, owner, name, opt)
+		if err != nil {
+			return err
+		}
+		labels = append(labels, got...)
+		if resp.NextPage == 0 {
+			break
+		}
+		opt.Page = resp.NextPage
+	}
+	for _, v := range labels {
+		if _, err := client.Issues.DeleteLabel(ctx, owner, name, v.GetName()); err != nil {
+			return err
+		}
+		logf(w, "deleted default label: %s\n", v.GetName())
+	}
+	return nil
+}
+
+// setDefaultBranch makes the branch the default one of the repository.
+// The branch not pushed yet is skipped, as the code is not moved.
+func setDefaultBranch(ctx context.Context, w io.Writer, client *github.Client, owner, name, branch string) error {
+	if _, resp, err := client.Repositories.GetBranch(ctx, owner, name, branch); err != nil {
+		if resp != nil && resp.StatusCode == http.StatusNotFound {
+			logf(w, "skipped default branch: %s - push it to %s/%s and set it\n", branch, owner, name)
+			return nil
+		}
+		return err
+	}
+	if _, _, err := client.Repositories.Edit(ctx, owner, name, &github.Repository{DefaultBranch: &branch}); err != nil {
+		return err
+	}
+	logf(w, "set default branch: %s\n", branch)
+	return nil
+}
+
+// isUserOwner reports whether the owner is a user, not an organization.
+// The repositories and projects of a user are created by the endpoints of
+// the authenticated user, so it is an error when the token is of another one.
+func isUserOwner(ctx context.Context, client *github.Client, owner string) (bool, error) {
+	user, _, err := client.Users.Get(ctx, owner)
+	if err != nil {
+		return false, err
+	}
+	if user.GetType() != "User" {
+		return false, nil
+	}
+	me, _, err := client.Users.Get(ctx, "")
+	if err != nil {
+		return true, err
+	}
+	if !strings.EqualFold(me.GetLogin(), owner) {
+		return true, fmt.Errorf("%s is a user, whose repositories and projects cannot be created by the token of %s", owner, me.GetLogin())
+	}
+	return true, nil
+}
+
+// CreateDstRepo creates DST with the settings of SRC when it does not exist.
+// The default branch is set by SetDstDefaultBranch after the transfer.
+func (t *Transfer) CreateDstRepo(ctx context.Context) error {
+	src, _, err := t.SRC.REST.Repositories.Get(ctx, t.SRC.Owner, t.SRC.Name)
+	if err != nil {
+		return err
+	}
+	created, err := ensureDstRepo(ctx, t.Log, t.DST.Client, t.DST.Owner, t.DST.Name, src, t.NoDefaultLabels)
+	if created {
+		t.dstDefaultBranch = src.GetDefaultBranch()
+	}
+	return err
+}
+
+// SetDstDefaultBranch sets the default branch of SRC to DST created by
+// CreateDstRepo, once it is pushed.
+func (t *Transfer) SetDstDefaultBranch(ctx context.Context) error {
+	if t.dstDefaultBranch == "" {
+		return nil
+	}
+	return setDefaultBranch(ctx, t.Log, t.DST.Client, t.DST.Owner, t.DST.Name, t.dstDefaultBranch)
+}
@@ -0,0 +1,149 @@
+package mover
+
+import (
+	"context"
+	"os"
+	"strings"
+	"testing"
+)
+
+// seedSrcRepo gives testSrc the settings copied by -create-dst, and makes
+// bar/dst missing.
+func seedSrcRepo(f *fakeGitHub) {
+	src := f.Repo(testSrc)
+	src.Description = "the source"
+	src.Homepage = "https://src.example.com"
+	src.Visibility = "internal"
+	src.Topics = []string{"go", "tools"}
+	src.DefaultBranch = "main"
+	src.Branches = []string{"main"}
+	f.Repo("bar/dst").Missing = true
+}
+
+func TestExecCreateDst(t *testing.T) {
+	f := newFakeGitHub()
+	defer f.Close()
+	seedSrc(f)
+	seedSrcRepo(f)
+	f.AddOrg("bar")
+	dir := testDir(t)
+	defer os.RemoveAll(dir)
+
+	o := testOptions(f, dir)
+	o.Dst = "bar/dst"
+	o.CreateDst = true
+	tr, err := New(context.Background(), o, testShared())
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the default branch of the empty repository is skipped.
+	if err := tr.Exec(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	dst := f.Repo("bar/dst")
+	if dst.Missing || dst.Owner != "bar" {
+		t.Fatalf("repository is not created for bar: %+v", dst)
+	}
+	if dst.Description != "the source" || dst.Homepage != "https://src.example.com" || dst.Visibility != "internal" {
+		t.Errorf("settings: %q %q %q", dst.Description, dst.Homepage, dst.Visibility)
+	}
+	if got := strings.Join(dst.Topics, ","); got != "go,tools" {
+		t.Errorf("topics: %s", got)
+	}
+	if v, ok := dst.Issues[1]; !ok || v.Title != "first" {
+		t.Errorf("issues are not moved: %v", dst.Issues)
+	}
+	if dst.DefaultBranch != "" {
+		t.Errorf("default branch of the empty repository: %q", dst.DefaultBranch)
+	}
+
+	dst.Branches = []string{"main"}
+	if err := tr.SetDstDefaultBranch(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if dst.DefaultBranch != "main" {
+		t.Errorf("default branch: %q", dst.DefaultBranch)
+	}
+}
+
+func TestExecCreateDstNoDefaultLabels(t *testing.T) {
+	for _, noDefault := range []bool{true, false} {
+		f := newFakeGitHub()
+		seedSrc(f)
+		seedSrcRepo(f)
+		f.AddOrg("bar")
+		f.DefaultLabels = []Label{{Name: "bug", Color: "d73a4a"}, {Name: "wontfix", Color: "ffffff"}}
+		dir := testDir(t)
+
+		o := testOptions(f, dir)
+		o.Dst = "bar/dst"
+		o.CreateDst = true
+		o.NoDefaultLabels = noDefault
+		tr, err := New(context.Background(), o, testShared())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := tr.Exec(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+
+		colors := map[string]string{}
+		for _, l := range f.Repo("bar/dst").Labels {
+			colors[l.Name] = l.Color
+		}
+		_, wontfix := colors["wontfix"]
+		if wontfix == noDefault {
+			t.Errorf("no default labels %v: wontfix is kept %v", noDefault, wontfix)
+		}
+		if noDefault && colors["bug"] != "ff0000" {
+			t.Errorf("bug of SRC is not created: %v", colors)
+		}
+		if _, ok := colors["help wanted"]; !ok {
+			t.Errorf("help wanted of SRC is not created: %v", colors)
+		}
+
+		f.Close()
+		os.RemoveAll(dir)
+	}
+}
+
+func TestExecCreateDstOfUser(t *testing.T) {
+	for _, login := range []string{"bar", "baz"} {
+		f := newFakeGitHub()
+		seedSrc(f)
+		seedSrcRepo(f)
+		f.Repo(testSrc).DefaultBranch = ""
+		f.AddUser("bar")
+		f.AuthLogin = login
+		dir := testDir(t)
+
+		o := testOptions(f, dir)
+		o.Dst = "bar/dst"
+		o.CreateDst = true
+		tr, err := New(context.Background(), o, testShared())
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = tr.Exec(context.Background())
+		dst := f.Repo("bar/dst")
+		if login == "bar" {
+			if err != nil {
+				t.Error(err)
+			}
+			if dst.Missing || dst.Owner != "bar" {
+				t.Errorf("repository is not created for bar: %+v", dst)
+			}
+		} else {
+			if err == nil || !strings.Contains(err.Error(), "token of baz") {
+				t.Errorf("repository of bar by the token of baz: %v", err)
+			}
+			if !dst.Missing {
+				t.Errorf("repository is created for %s", dst.Owner)
+			}
+		}
+
+		f.Close()
+		os.RemoveAll(dir)
+	}
+}
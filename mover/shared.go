@@ -0,0 +1,177 @@
+package mover
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"golang.org/x/oauth2"
+)
+
+const (
+	defaultRequestInterval = 100 * time.Millisecond
+)
+
+// Shared is what the transfers of a batch share: the existence of users
+// and the rate limit of the api hosts.
+type Shared struct {
+	Users   *UserCache
+	Limiter *RateLimiter
+}
+
+func NewShared() *Shared {
+	return &Shared{
+		Users:   &UserCache{users: map[string]bool{}},
+		Limiter: NewRateLimiter(http.DefaultTransport, defaultRequestInterval),
+	}
+}
+
+// Context returns the context that makes oauth2 clients use the rate limiter.
+func (s *Shared) Context(ctx context.Context) context.Context {
+	return context.WithValue(ctx, oauth2.HTTPClient, &http.Client{Transport: s.Limiter})
+}
+
+type UserCache struct {
+	mu    sync.Mutex
+	users map[string]bool
+}
+
+func (c *UserCache) Get(key string) (bool, bool) {
+	if c == nil {
+		return false, false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	exist, ok := c.users[key]
+	return exist, ok
+}
+
+func (c *UserCache) Set(key string, exist bool) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.users[key] = exist
+}
+
+// RateLimiter is a http.RoundTripper that spaces out requests, and waits
+// for the reset of the rate limit of a host and a token when it is exhausted.
+type RateLimiter struct {
+	Base     http.RoundTripper
+	Interval time.Duration
+	// Log is where the retries are printed, the standard output when nil.
+	Log io.Writer
+
+	mu    sync.Mutex
+	next  time.Time
+	reset map[string]time.Time
+}
+
+func NewRateLimiter(base http.RoundTripper, interval time.Duration) *RateLimiter {
+	return &RateLimiter{
+		Base:     base,
+		Interval: interval,
+		reset:    map[string]time.Time{},
+	}
+}
+
+// RoundTrip sends the request, and sends it again once after Retry-After
+// when it is refused by a secondary rate limit.
+func (l *RateLimiter) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := l.roundTrip(req)
+	if err != nil || !secondaryLimited(resp) {
+		return resp, err
+	}
+	retry := req.Clone(req.Context())
+	if req.Body != nil && req.Body != http.NoBody {
+		if req.GetBody == nil {
+			return resp, nil
+		}
+		body, err := req.GetBody()
+		if err != nil {
+			return resp, nil
+		}
+		retry.Body = body
+	}
+	resp.Body.Close()
+	logf(l.Log, "secondary rate limit: %s %s, retrying after %ss\n", req.Method, req.URL.Path, resp.Header.Get("Retry-After"))
+	return l.roundTrip(retry)
+}
+
+func (l *RateLimiter) roundTrip(req *http.Request) (*http.Response, error) {
+	if err := l.wait(req); err != nil {
+		return nil, err
+	}
+	resp, err := l.Base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	l.update(limitKey(req), resp)
+	return resp, nil
+}
+
+// secondaryLimited reports whether the request was refused by a secondary
+// rate limit, which tells how long to wait by Retry-After.
+func secondaryLimited(resp *http.Response) bool {
+	if resp.StatusCode != http.StatusForbidden && resp.StatusCode != http.StatusTooManyRequests {
+		return false
+	}
+	_, err := strconv.Atoi(resp.Header.Get("Retry-After"))
+	return err == nil
+}
+
+func (l *RateLimiter) wait(req *http.Request) error {
+	l.mu.Lock()
+	now := time.Now()
+	at := l.next
+	if reset := l.reset[limitKey(req)]; reset.After(at) {
+		at = reset
+	}
+	if at.Before(now) {
+		at = now
+	}
+	l.next = at.Add(l.Interval)
+	l.mu.Unlock()
+
+	d := time.Until(at)
+	if d <= 0 {
+		return nil
+	}
+	select {
+	case <-req.Context().Done():
+		return req.Context().Err()
+	case <-time.After(d):
+		return nil
+	}
+}
+
+// limitKey is the host and the credentials of the request, since the rate
+// limits are of each user, such as the tokens of a TokenPool.
+func limitKey(req *http.Request) string {
+	return req.URL.Host + " " + req.Header.Get("Authorization")
+}
+
+func (l *RateLimiter) update(key string, resp *http.Response) {
+	var reset time.Time
+	if resp.Header.Get("X-RateLimit-Remaining") == "0" {
+		if sec, err := strconv.ParseInt(resp.Header.Get("X-RateLimit-Reset"), 10, 64); err == nil {
+			reset = time.Unix(sec, 0)
+		}
+	}
+	// secondary rate limits tell how long to wait by Retry-After.
+	if sec, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil {
+		reset = time.Now().Add(time.Duration(sec) * time.Second)
+	}
+	if reset.IsZero() {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if reset.After(l.reset[key]) {
+		l.reset[key] = reset
+	}
+}
@@ -0,0 +1,176 @@
+package mover
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sort"
+)
+
+const (
+	defaultStatePath = "mover-state.json"
+)
+
+// State is what a run has done to the destination so far.
+// It is written after every created issue, so that an interrupted
+// run can be resumed and later phases know the number mapping.
+type State struct {
+	// Src and Dst are the repositories of the state, so that the state of
+	// another move is not resumed by mistake.
+	Src string `json:"src,omitempty"`
+	Dst string `json:"dst,omitempty"`
+	// Numbers maps source issue numbers to destination issue numbers.
+	Numbers map[int]int `json:"numbers"`
+	// Dummies are the destination numbers of the created dummies.
+	Dummies []int `json:"dummies"`
+	// Removed are the dummies deleted or transferred by the cleanup.
+	Removed []int `json:"removed,omitempty"`
+	// Milestones maps source milestone numbers to destination ones.
+	Milestones map[int]int `json:"milestones,omitempty"`
+	// Discussions maps source discussion numbers to destination ones.
+	Discussions map[int]int `json:"discussions,omitempty"`
+	// CommentedDiscussions are the source discussion numbers whose
+	// comments are all added.
+	CommentedDiscussions []int `json:"commented_discussions,omitempty"`
+	// Projects maps source project ids to destination ones.
+	Projects map[string]string `json:"projects,omitempty"`
+	// ProjectItems are the source project item ids added to the
+	// destination projects.
+	ProjectItems []string `json:"project_items,omitempty"`
+	// Noticed are the source numbers commented with the moved notice, and
+	// Backlinked are the ones also closed and locked if configured.
+	Noticed    []int `json:"noticed,omitempty"`
+	Backlinked []int `json:"backlinked,omitempty"`
+	// ClosedSrc are the source numbers open until closed by the back-link.
+	ClosedSrc []int `json:"closed_src,omitempty"`
+}
+
+func LoadState(path string) (*State, error) {
+	s := &State{Numbers: map[int]int{}, Milestones: map[int]int{}, Discussions: map[int]int{}, Projects: map[string]string{}}
+	buf, err := ioutil.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return s, nil
+		}
+		return nil, err
+	}
+	if err := json.Unmarshal(buf, s); err != nil {
+		return nil, err
+	}
+	if s.Numbers == nil {
+		s.Numbers = map[int]int{}
+	}
+	if s.Milestones == nil {
+		s.Milestones = map[int]int{}
+	}
+	if s.Discussions == nil {
+		s.Discussions = map[int]int{}
+	}
+	if s.Projects == nil {
+		s.Projects = map[string]string{}
+	}
+	return s, nil
+}
+
+func (s *State) Save(path string) error {
+	sort.Ints(s.Dummies)
+	sort.Ints(s.Removed)
+	sort.Ints(s.Noticed)
+	sort.Ints(s.Backlinked)
+	sort.Ints(s.ClosedSrc)
+	sort.Ints(s.CommentedDiscussions)
+	sort.Strings(s.ProjectItems)
+	buf, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, buf, 0644)
+}
+
+// Bind ties the state to the repositories, or returns the error when it is
+// the state of other repositories.
+func (s *State) Bind(src, dst string) error {
+	if s.Src != "" && (s.Src != src || s.Dst != dst) {
+		return fmt.Errorf("state file is of %s to %s, not of %s to %s: give another -state-file", s.Src, s.Dst, src, dst)
+	}
+	s.Src = src
+	s.Dst = dst
+	return nil
+}
+
+func (s *State) IsDummy(n int) bool {
+	for _, v := range s.Dummies {
+		if v == n {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *State) IsRemoved(n int) bool {
+	for _, v := range s.Removed {
+		if v == n {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *State) IsBacklinked(n int) bool {
+	for _, v := range s.Backlinked {
+		if v == n {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *State) IsNoticed(n int) bool {
+	for _, v := range s.Noticed {
+		if v == n {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *State) IsClosedSrc(n int) bool {
+	for _, v := range s.ClosedSrc {
+		if v == n {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *State) IsCommentedDiscussion(n int) bool {
+	for _, v := range s.CommentedDiscussions {
+		if v == n {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *State) IsAddedProjectItem(id string) bool {
+	for _, v := range s.ProjectItems {
+		if v == id {
+			return true
+		}
+	}
+	return false
+}
+
+// HasDst reports whether the destination number was created by a run.
+func (s *State) HasDst(n int) bool {
+	if s.IsDummy(n) {
+		return true
+	}
+	for _, v := range s.Numbers {
+		if v == n {
+			return true
+		}
+	}
+	return false
+}
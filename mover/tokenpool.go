@@ -0,0 +1,152 @@
+package mover
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+
+	"golang.org/x/oauth2"
+	"gopkg.in/yaml.v2"
+)
+
+type authorKey struct{}
+
+// withAuthor returns the context of the requests made on behalf of the DST
+// login, which the TokenPool authenticates with the token of the login.
+func withAuthor(ctx context.Context, login string) context.Context {
+	if login == "" {
+		return ctx
+	}
+	return context.WithValue(ctx, authorKey{}, login)
+}
+
+func authorOf(ctx context.Context) string {
+	login, _ := ctx.Value(authorKey{}).(string)
+	return login
+}
+
+// TokenPool is a http.RoundTripper that authenticates a request with the
+// token of its author, so that the issues and comments are authored by the
+// real users, or with the bot tokens in turn, so that the rate limits of
+// the bots are added up.
+type TokenPool struct {
+	Base  http.RoundTripper
+	Bots  []oauth2.TokenSource
+	Users map[string]string
+	// Log is where the dropped tokens are printed, the standard output when nil.
+	Log io.Writer
+
+	mu   sync.Mutex
+	next int
+}
+
+func (p *TokenPool) RoundTrip(req *http.Request) (*http.Response, error) {
+	if login := authorOf(req.Context()); login != "" {
+		if token, ok := p.user(login); ok {
+			resp, err := p.roundTrip(req, &oauth2.Token{AccessToken: token})
+			if err != nil || !denied(resp) || req.Body != nil && req.GetBody == nil {
+				return resp, err
+			}
+			// the user may not have access to DST, or the token may be
+			// revoked, so the bots make the request instead.
+			resp.Body.Close()
+			logf(p.Log, "dropped user token: %s - %s\n", login, resp.Status)
+			p.drop(login)
+			if req.GetBody != nil {
+				body, err := req.GetBody()
+				if err != nil {
+					return nil, err
+				}
+				req = req.Clone(req.Context())
+				req.Body = body
+			}
+		}
+	}
+	token, err := p.bot()
+	if err != nil {
+		return nil, err
+	}
+	return p.roundTrip(req, token)
+}
+
+// denied reports whether the response refuses the token, except by the rate limits.
+func denied(resp *http.Response) bool {
+	switch resp.StatusCode {
+	case http.StatusUnauthorized, http.StatusForbidden, http.StatusNotFound:
+		return resp.Header.Get("X-RateLimit-Remaining") != "0" && resp.Header.Get("Retry-After") == ""
+	}
+	return false
+}
+
+func (p *TokenPool) roundTrip(req *http.Request, token *oauth2.Token) (*http.Response, error) {
+	r := req.Clone(req.Context())
+	token.SetAuthHeader(r)
+	base := p.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return base.RoundTrip(r)
+}
+
+func (p *TokenPool) user(login string) (string, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	token, ok := p.Users[login]
+	return token, ok
+}
+
+func (p *TokenPool) drop(login string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	delete(p.Users, login)
+}
+
+// bot returns the token of the next bot.
+func (p *TokenPool) bot() (*oauth2.Token, error) {
+	p.mu.Lock()
+	if len(p.Bots) == 0 {
+		p.mu.Unlock()
+		return nil, fmt.Errorf("no dst token")
+	}
+	ts := p.Bots[p.next%len(p.Bots)]
+	p.next++
+	p.mu.Unlock()
+	return ts.Token()
+}
+
+// LoadUserTokens loads the tokens of the DST logins from the yaml file
+// of the "login: token" lines.
+func LoadUserTokens(path string) (map[string]string, error) {
+	buf, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	tokens := map[string]string{}
+	if err := yaml.UnmarshalStrict(buf, &tokens); err != nil {
+		return nil, fmt.Errorf("%s: %s", path, err)
+	}
+	return tokens, nil
+}
+
+// dstTokenPool returns the pool of the DST token or app, the additional
+// bot tokens and the user tokens of the options.
+func (o *Options) dstTokenPool(ctx context.Context, base http.RoundTripper, ts oauth2.TokenSource) (*TokenPool, error) {
+	p := &TokenPool{Base: base, Bots: []oauth2.TokenSource{ts}, Users: map[string]string{}, Log: o.Log}
+	for _, v := range strings.Split(o.DstTokens, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			p.Bots = append(p.Bots, tokenSource(ctx, o.DstEndpoint, v, nil))
+		}
+	}
+	if o.DstUserTokens != "" {
+		users, err := LoadUserTokens(o.DstUserTokens)
+		if err != nil {
+			return nil, err
+		}
+		p.Users = users
+	}
+	return p, nil
+}
@@ -0,0 +1,102 @@
+package mover
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"golang.org/x/oauth2"
+)
+
+func TestTokenPool(t *testing.T) {
+	var got []string
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth := r.Header.Get("Authorization")
+		got = append(got, strings.TrimPrefix(auth, "Bearer "))
+		if auth == "Bearer revoked" {
+			w.WriteHeader(http.StatusUnauthorized)
+		}
+	}))
+	defer s.Close()
+
+	p := &TokenPool{
+		Bots: []oauth2.TokenSource{
+			oauth2.StaticTokenSource(&oauth2.Token{AccessToken: "bot1"}),
+			oauth2.StaticTokenSource(&oauth2.Token{AccessToken: "bot2"}),
+		},
+		Users: map[string]string{"alice": "alice-token", "bob": "revoked"},
+	}
+	client := &http.Client{Transport: p}
+	ctx := context.Background()
+	for _, login := range []string{"", "", "alice", "carol", "bob", "bob"} {
+		req, _ := http.NewRequest("POST", s.URL, strings.NewReader("body"))
+		resp, err := client.Do(req.WithContext(withAuthor(ctx, login)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			t.Errorf("%s: %s", login, resp.Status)
+		}
+	}
+	want := []string{"bot1", "bot2", "alice-token", "bot1", "revoked", "bot2", "bot1"}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("tokens: %v, want %v", got, want)
+	}
+}
+
+func TestExecTokenPool(t *testing.T) {
+	f := newFakeGitHub()
+	defer f.Close()
+	seedSrc(f)
+	dir := testDir(t)
+	defer os.RemoveAll(dir)
+	f.AddToken("src-token", "src-bot")
+	f.AddToken("dst-token", "dst-bot")
+	f.AddToken("dst-bot-2-token", "dst-bot-2")
+	f.AddToken("dst-admin-token", "dst-admin")
+	f.AddToken("alice-token", "alice-dst")
+	users := filepath.Join(dir, "users.yml")
+	if err := ioutil.WriteFile(users, []byte("alice-dst: alice-token\nbob: revoked\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	o := testOptions(f, dir)
+	o.IsImport = false
+	o.DstTokens = "dst-bot-2-token"
+	o.DstUserTokens = users
+	tr, err := New(context.Background(), o, testShared())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := tr.Exec(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	dst := f.Repo(testDst)
+	if got := dst.Numbers(); len(got) != 5 {
+		t.Fatalf("dst numbers: %v", got)
+	}
+	first := dst.Issues[1]
+	if first.Author.Login != "alice-dst" {
+		t.Errorf("#1 author: %s, want the user of the token", first.Author.Login)
+	}
+	if len(first.Comments.Nodes) != 1 || !strings.HasPrefix(first.Comments.Nodes[0].Author.Login, "dst-bot") {
+		t.Errorf("#1 comments: %#v, want by a bot for the revoked token", first.Comments.Nodes)
+	}
+	bots := map[string]bool{}
+	for _, n := range dst.Numbers() {
+		if a := dst.Issues[n].Author.Login; a != "alice-dst" {
+			bots[a] = true
+		}
+	}
+	bots[first.Comments.Nodes[0].Author.Login] = true
+	if len(bots) != 2 {
+		t.Errorf("bot authors: %v, want the bots in turn", bots)
+	}
+}
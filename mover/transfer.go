@@ -0,0 +1,914 @@
+package mover
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/google/go-github/v32/github"
+)
+
+const (
+	defaultEndpoint = "https://api.github.com"
+)
+
+// importWaitInterval is the interval of checking the status of an import.
+var importWaitInterval = time.Second
+
+// Transfer moves Source to Destination. SRC and DST are set when they
+// are of GitHub, and nil otherwise.
+type Transfer struct {
+	Source      Source
+	Destination Destination
+	*SRC
+	*DST
+	Labels             []Label
+	Milestones         []Milestone
+	Issues             []Issue
+	Pulls              []Issue
+	Discussions        []Discussion
+	ImportRequested    []int
+	Replace            *Map
+	Templates          *Templates
+	Filter             *Filter
+	State              *State
+	StatePath          string
+	Align              string
+	DummyTitle         string
+	DummyBody          string
+	DummyLabel         string
+	DummyLock          bool
+	Cleanup            string
+	TrashRepo          string
+	DiscussionsMode    string
+	DiscussionCategory string
+	ProjectsMode       string
+	Wiki               bool
+	SrcWikiURL         string
+	DstWikiURL         string
+	Git                GitTransport
+	CreateDst          bool
+	NoDefaultLabels    bool
+	IsImport           bool
+	SkipLabels         bool
+	SkipMilestones     bool
+	Backlink           bool
+	MovedNotice        *template.Template
+	CloseSrc           bool
+	LockSrc            bool
+	DisableIssues      bool
+	ArchiveSrc         bool
+	MovedDescription   *template.Template
+	Markdown           bool
+	RewriteMentions    bool
+	RewriteLinks       bool
+	// Log is where the progress is printed, the standard output when nil.
+	Log io.Writer
+
+	// dstDefaultBranch is the default branch to set to DST created by CreateDstRepo.
+	dstDefaultBranch string
+	// failedImports are the SRC numbers of the imports failed, 0 of the dummies.
+	failedImports []int
+}
+
+type IssueAndCommentsRequest struct {
+	Issue    *github.IssueRequest
+	Comments []*github.IssueComment
+	// Author and CommentAuthors are the DST logins to create the issue
+	// and the comments as when the token pool has their tokens.
+	Author         string
+	CommentAuthors []string
+}
+
+// New returns the Transfer from the source of the type to the GitHub or
+// Gitea repository of the options.
+func New(ctx context.Context, o *Options, shared *Shared) (*Transfer, error) {
+	if !validSrcType(o.SrcType) {
+		return nil, fmt.Errorf("invalid source type: %s", o.SrcType)
+	}
+	if err := validSrc(o.SrcType, o.Src); err != nil {
+		return nil, err
+	}
+	if !validDstType(o.DstType) {
+		return nil, fmt.Errorf("invalid destination type: %s", o.DstType)
+	}
+	if !validRepo(o.Dst) {
+		return nil, fmt.Errorf("invalid destination repository: %q, expected owner/name", o.Dst)
+	}
+	if shared == nil {
+		shared = NewShared()
+	}
+	ctx = shared.Context(ctx)
+
+	src, err := newSource(ctx, o, shared)
+	if err != nil {
+		return nil, err
+	}
+
+	if o.DstType == DstGitea {
+		if o.DstEndpoint == defaultEndpoint {
+			return nil, fmt.Errorf("gitea needs the dst endpoint: https://gitea.example.com")
+		}
+		d := strings.Split(o.Dst, "/")
+		dst := &Gitea{
+			Owner:    d[0],
+			Name:     d[1],
+			Endpoint: o.DstEndpoint,
+			Token:    o.DstToken,
+			Client:   &http.Client{Transport: shared.Limiter},
+			Users:    shared.Users,
+			Log:      o.Log,
+		}
+		t, err := NewWith(o, src, dst)
+		if err != nil {
+			return nil, err
+		}
+		// Gitea has no import api to keep the creation times.
+		t.IsImport = false
+		return t, nil
+	}
+
+	dstTS, err := o.dstTokenSource(ctx)
+	if err != nil {
+		return nil, err
+	}
+	adminTS := dstTS
+	if o.DstAdminToken != "" && o.DstAdminToken != o.DstToken {
+		adminTS = tokenSource(ctx, o.DstEndpoint, o.DstAdminToken, nil)
+	}
+	pool, err := o.dstTokenPool(ctx, shared.Limiter, dstTS)
+	if err != nil {
+		return nil, err
+	}
+	dstClient, err := newRESTClientWith(o.DstEndpoint, &http.Client{Transport: pool})
+	if err != nil {
+		return nil, err
+	}
+	d := strings.Split(o.Dst, "/")
+	dst := &DST{
+		Owner:        d[0],
+		Name:         d[1],
+		Endpoint:     o.DstEndpoint,
+		Client:       dstClient,
+		GraphQL:      newGraphQLClient(ctx, o.DstEndpoint, dstTS),
+		AdminGraphQL: newGraphQLClient(ctx, o.DstEndpoint, adminTS),
+		TokenSource:  dstTS,
+		Users:        shared.Users,
+		Log:          o.Log,
+	}
+
+	return NewWith(o, src, dst)
+}
+
+func newSource(ctx context.Context, o *Options, shared *Shared) (Source, error) {
+	switch o.SrcType {
+	case SrcGitLab:
+		endpoint := o.SrcEndpoint
+		if endpoint == defaultEndpoint {
+			endpoint = defaultGitLabEndpoint
+		}
+		return &GitLab{
+			Project:  o.Src,
+			Endpoint: endpoint,
+			Token:    o.SrcToken,
+			MROffset: o.MROffset,
+			Client:   &http.Client{Transport: shared.Limiter},
+			Log:      o.Log,
+		}, nil
+	case SrcJira:
+		if o.SrcEndpoint == defaultEndpoint {
+			return nil, fmt.Errorf("jira needs the src endpoint: https://example.atlassian.net")
+		}
+		return &Jira{
+			Project:  o.Src,
+			Endpoint: o.SrcEndpoint,
+			User:     o.JiraUser,
+			Token:    o.SrcToken,
+			API:      o.JiraAPI,
+			JQL:      o.JiraJQL,
+			Client:   &http.Client{Transport: shared.Limiter},
+		}, nil
+	}
+
+	srcTS, err := o.srcTokenSource(ctx)
+	if err != nil {
+		return nil, err
+	}
+	srcREST, err := newRESTClient(ctx, o.SrcEndpoint, srcTS)
+	if err != nil {
+		return nil, err
+	}
+	s := strings.Split(o.Src, "/")
+	return &SRC{
+		Owner:       s[0],
+		Name:        s[1],
+		Endpoint:    o.SrcEndpoint,
+		Client:      newGraphQLClient(ctx, o.SrcEndpoint, srcTS),
+		REST:        srcREST,
+		TokenSource: srcTS,
+	}, nil
+}
+
+// NewWith returns the Transfer from src to dst with the options, except
+// the repositories, endpoints and tokens of them. The stages only for
+// GitHub cannot be enabled unless src and dst are SRC and DST.
+func NewWith(o *Options, src Source, dst Destination) (*Transfer, error) {
+	// the files are only the given ones, not the ones of the working
+	// directory, so that the library can be embedded.
+	if o.StatePath == "" {
+		return nil, fmt.Errorf("state file is required")
+	}
+	replace, err := loadReplacementMapFiles(o.replacePaths(), o.Log)
+	if err != nil {
+		return nil, err
+	}
+
+	filter, err := NewFilter(o.State, o.Labels, o.Milestone, o.Author, o.Since, o.Until, o.Numbers)
+	if err != nil {
+		return nil, err
+	}
+
+	templates, err := NewTemplates(o.IssueHeader, o.CommentHeader, o.Footer, o.TimeZone, o.TimeFormat)
+	if err != nil {
+		return nil, err
+	}
+	templates.Log = o.Log
+	if o.OriginFooter {
+		templates.Origin = template.Must(template.New("origin_footer").Parse(defaultOriginFooter))
+	}
+	movedNotice, err := parseMovedNotice(o.MovedNotice)
+	if err != nil {
+		return nil, err
+	}
+	var movedDescription *template.Template
+	if o.MovedDescription != "" {
+		if movedDescription, err = parseMovedDescription(o.MovedDescription); err != nil {
+			return nil, err
+		}
+	}
+
+	if !validAlign(o.Align) {
+		return nil, fmt.Errorf("invalid align mode: %s", o.Align)
+	}
+
+	if !validCleanup(o.Cleanup) {
+		return nil, fmt.Errorf("invalid cleanup mode: %s", o.Cleanup)
+	}
+	if o.Cleanup == CleanupTransfer && !validRepo(o.TrashRepo) {
+		return nil, fmt.Errorf("invalid trash repository: %q, expected owner/name", o.TrashRepo)
+	}
+
+	if !validDiscussions(o.Discussions) {
+		return nil, fmt.Errorf("invalid discussions mode: %s", o.Discussions)
+	}
+
+	if !validProjects(o.Projects) {
+		return nil, fmt.Errorf("invalid projects mode: %s", o.Projects)
+	}
+
+	ghSRC, _ := src.(*SRC)
+	ghDST, _ := dst.(*DST)
+	ghOnly := o.Discussions != DiscussionsNone || o.Projects != ProjectsNone || o.Wiki || o.CreateDst
+	if ghOnly && (ghSRC == nil || ghDST == nil) {
+		return nil, fmt.Errorf("discussions, projects, wiki and create-dst are only for GitHub repositories")
+	}
+	if o.Backlink && ghSRC == nil {
+		return nil, fmt.Errorf("backlink is only for GitHub sources")
+	}
+	if (o.CloseSrc || o.LockSrc) && !o.Backlink {
+		return nil, fmt.Errorf("close-src and lock-src need backlink")
+	}
+	if o.Cleanup != CleanupNone && ghDST == nil {
+		return nil, fmt.Errorf("cleanup is only for GitHub repositories")
+	}
+
+	st, err := LoadState(o.StatePath)
+	if err != nil {
+		return nil, err
+	}
+	if err := st.Bind(src.Repo(), dst.Repo()); err != nil {
+		return nil, fmt.Errorf("%s: %s", o.StatePath, err)
+	}
+
+	return &Transfer{
+		Source:             src,
+		Destination:        dst,
+		SRC:                ghSRC,
+		DST:                ghDST,
+		Labels:             nil,
+		Milestones:         nil,
+		Issues:             nil,
+		Pulls:              nil,
+		ImportRequested:    nil,
+		Replace:            replace,
+		Templates:          templates,
+		MovedNotice:        movedNotice,
+		Filter:             filter,
+		State:              st,
+		StatePath:          o.StatePath,
+		Align:              o.Align,
+		DummyTitle:         o.DummyTitle,
+		DummyBody:          o.DummyBody,
+		DummyLabel:         o.DummyLabel,
+		DummyLock:          o.DummyLock,
+		Cleanup:            o.Cleanup,
+		TrashRepo:          o.TrashRepo,
+		DiscussionsMode:    o.Discussions,
+		DiscussionCategory: o.DiscussionCategory,
+		ProjectsMode:       o.Projects,
+		Wiki:               o.Wiki,
+		SrcWikiURL:         o.SrcWikiURL,
+		DstWikiURL:         o.DstWikiURL,
+		Git:                &ExecGitTransport{},
+		CreateDst:          o.CreateDst,
+		NoDefaultLabels:    o.NoDefaultLabels,
+		IsImport:           o.IsImport,
+		SkipLabels:         o.SkipLabels,
+		SkipMilestones:     o.SkipMilestones,
+		Backlink:           o.Backlink,
+		CloseSrc:           o.CloseSrc,
+		LockSrc:            o.LockSrc,
+		DisableIssues:      o.DisableIssues,
+		ArchiveSrc:         o.ArchiveSrc,
+		MovedDescription:   movedDescription,
+		Markdown:           o.Markdown,
+		RewriteMentions:    o.RewriteMentions,
+		RewriteLinks:       o.RewriteLinks,
+		Log:                o.Log,
+	}, nil
+}
+
+func (t *Transfer) Exec(ctx context.Context) error {
+	if err := t.Fetch(ctx); err != nil {
+		return err
+	}
+	return t.Apply(ctx)
+}
+
+// Apply creates DST from what is fetched or imported.
+func (t *Transfer) Apply(ctx context.Context) error {
+	if t.CreateDst {
+		if err := t.CreateDstRepo(ctx); err != nil {
+			printError(t.Log, "repository create", err)
+			return err
+		}
+	}
+	if err := t.Do(ctx); err != nil {
+		return err
+	}
+	if t.Cleanup != CleanupNone {
+		if err := t.CleanupDummies(ctx); err != nil {
+			printError(t.Log, "dummy cleanup", err)
+			return err
+		}
+	}
+	if t.Backlink {
+		if err := t.DoBacklinks(ctx); err != nil {
+			printError(t.Log, "backlink", err)
+			return err
+		}
+	}
+	if err := t.SetDstDefaultBranch(ctx); err != nil {
+		printError(t.Log, "default branch set", err)
+		return err
+	}
+	if len(t.failedImports) > 0 {
+		return fmt.Errorf("issue imports failed: %v", t.failedImports)
+	}
+	//t.ImportIssueStatus(ctx)
+
+	return nil
+}
+
+func (t *Transfer) Fetch(ctx context.Context) error {
+	if !t.SkipLabels {
+		if err := t.FetchLabels(ctx); err != nil {
+			return err
+		}
+	}
+
+	if !t.SkipMilestones {
+		if err := t.FetchMilestones(ctx); err != nil {
+			return err
+		}
+	}
+
+	if err := t.FetchIssues(ctx); err != nil {
+		return err
+	}
+
+	if err := t.FetchPulls(ctx); err != nil {
+		return err
+	}
+
+	if t.DiscussionsMode != DiscussionsNone {
+		if err := t.FetchDiscussions(ctx); err != nil {
+			return err
+		}
+		if t.DiscussionsMode == DiscussionsIssues {
+			t.Issues = append(t.Issues, t.discussionIssues()...)
+		}
+	}
+
+	return nil
+}
+
+func (t *Transfer) FetchLabels(ctx context.Context) error {
+	labels, err := t.Source.Labels(ctx)
+	if err != nil {
+		return err
+	}
+	t.Labels = labels
+	return nil
+}
+
+func (t *Transfer) FetchMilestones(ctx context.Context) error {
+	milestones, err := t.Source.Milestones(ctx)
+	if err != nil {
+		return err
+	}
+	t.Milestones = milestones
+	return nil
+}
+
+func (t *Transfer) FetchIssues(ctx context.Context) error {
+	issues, err := t.Source.Issues(ctx, t.Filter)
+	if err != nil {
+		return err
+	}
+	t.Issues = issues
+	return nil
+}
+
+func (t *Transfer) FetchPulls(ctx context.Context) error {
+	pulls, err := t.Source.Pulls(ctx, t.Filter)
+	if err != nil {
+		return err
+	}
+	t.Pulls = pulls
+	return nil
+}
+
+func (t *Transfer) Do(ctx context.Context) error {
+	if !t.SkipLabels {
+		if err := t.DoLabels(ctx); err != nil {
+			printError(t.Log, "label create", err)
+			return err
+		}
+	}
+
+	if !t.SkipMilestones {
+		if err := t.DoMilestones(ctx); err != nil {
+			printError(t.Log, "milestone create", err)
+			return err
+		}
+	}
+
+	if err := t.DoIssues(ctx); err != nil {
+		printError(t.Log, "issue create", err)
+		return err
+	}
+	if err := t.waitImports(ctx); err != nil {
+		printError(t.Log, "issue import", err)
+		return err
+	}
+
+	if t.DiscussionsMode == DiscussionsMigrate {
+		if err := t.DoDiscussions(ctx); err != nil {
+			printError(t.Log, "discussion create", err)
+			return err
+		}
+	}
+
+	if t.ProjectsMode != ProjectsNone {
+		if err := t.DoProjects(ctx); err != nil {
+			printError(t.Log, "project create", err)
+			return err
+		}
+	}
+
+	if t.Wiki {
+		if err := t.DoWiki(ctx); err != nil {
+			printError(t.Log, "wiki move", err)
+			return err
+		}
+	}
+
+	return nil
+}
+
+// waitImports waits for the imports not waited for by DoIssues, so that the
+// later stages find the DST issues. The numbers in the state are corrected
+// by the imported ones, and the failed ones are removed from it, so that
+// they are neither linked by the projects nor back-linked.
+func (t *Transfer) waitImports(ctx context.Context) error {
+	if t.DST == nil {
+		return nil
+	}
+	imported, failed, err := t.DST.WaitImports(ctx)
+	for src, n := range imported {
+		if dn, ok := t.State.Numbers[src]; ok && dn != n {
+			logf(t.Log, "number mismatch: src #%d was imported as dst #%d\n", src, n)
+			t.State.Numbers[src] = n
+		}
+	}
+	for _, src := range failed {
+		delete(t.State.Numbers, src)
+	}
+	t.failedImports = append(t.failedImports, failed...)
+	if len(imported) > 0 || len(failed) > 0 {
+		if err := t.State.Save(t.StatePath); err != nil {
+			return err
+		}
+	}
+	return err
+}
+
+func (t *Transfer) DoLabels(ctx context.Context) error {
+	created := map[string]bool{}
+	for _, v := range t.Labels {
+		v, ok := t.replaceLabel(v)
+		if !ok {
+			logf(t.Log, "dropped label: %s\n", v.Name)
+			continue
+		}
+		// the rules may replace several labels with the same one.
+		if created[strings.ToLower(v.Name)] {
+			continue
+		}
+		created[strings.ToLower(v.Name)] = true
+		// the labels of a former run, or of the new repository, are kept.
+		ok, err := t.Destination.EnsureLabel(ctx, v)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			logf(t.Log, "skipped label: %s - already exists\n", v.Name)
+			continue
+		}
+		logf(t.Log, "created label: %s\n", v.Name)
+	}
+	return nil
+}
+
+func (t *Transfer) DoMilestones(ctx context.Context) error {
+	for _, v := range t.Milestones {
+		if n, ok := t.State.Milestones[v.Number]; ok {
+			logf(t.Log, "skipped milestone: %s - already moved to %d\n", v.Title, n)
+			continue
+		}
+		v.Title = t.replaceText(ScopeMilestones, v.Title)
+		v.Description = t.replaceText(ScopeMilestones, v.Description)
+		n, err := t.Destination.CreateMilestone(ctx, v)
+		if err != nil {
+			return err
+		}
+		logf(t.Log, "created milestone: %s\n", v.Title)
+		t.State.Milestones[v.Number] = n
+		if err := t.State.Save(t.StatePath); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// dstMilestone returns the DST number of the SRC milestone. The numbers of
+// SRC are kept with -skip-milestones, whose milestones DST is to have.
+func (t *Transfer) dstMilestone(n int) (int, bool) {
+	if n == 0 {
+		return 0, false
+	}
+	if m, ok := t.State.Milestones[n]; ok {
+		return m, true
+	}
+	return n, t.SkipMilestones
+}
+
+func (t *Transfer) DoIssues(ctx context.Context) error {
+	if t.Align != AlignNone {
+		if err := t.ensureDummyLabel(ctx); err != nil {
+			return err
+		}
+		return t.doIssuesAligned(ctx)
+	}
+	return t.doIssuesSequential(ctx)
+}
+
+func (t *Transfer) buildImportDummyIssueRequest(tt *time.Time, gap *Gap) *IssueImportRequest {
+	closed := true
+	var labels []string
+	if t.DummyLabel != "" {
+		labels = []string{t.DummyLabel}
+	}
+	return &IssueImportRequest{
+		IssueImport: IssueImport{
+			Title:     t.DummyTitle,
+			Body:      t.dummyBody(gap),
+			CreatedAt: tt,
+			ClosedAt:  tt,
+			UpdatedAt: tt,
+			Closed:    &closed,
+			Labels:    labels,
+		},
+		Comments: nil,
+	}
+}
+
+func (t *Transfer) buildImportIssueRequest(ctx context.Context, v *Issue) *IssueImportRequest {
+	if v == nil {
+		now := time.Now()
+		return t.buildImportDummyIssueRequest(&now, nil)
+	}
+
+	labels := t.issueLabels(v)
+	a := t.attribution(v.Author.Login, v.Author.AvatarURL, v.URL, v.CreatedAt, v.Number, true)
+	body := t.Templates.Issue(a, t.replaceBody(v.Body))
+	var comments []*IssueImportComment
+	for _, vv := range v.Comments.Nodes {
+		ca := t.attribution(vv.Author.Login, vv.Author.AvatarURL, vv.URL, vv.CreatedAt, v.Number, true)
+		comments = append(comments, &IssueImportComment{
+			CreatedAt: &vv.CreatedAt,
+			Body:      t.Templates.Comment(ca, t.replaceText(ScopeComments, vv.Body)),
+		})
+	}
+
+	input := &IssueImportRequest{
+		IssueImport: IssueImport{
+			Title:     t.replaceText(ScopeTitle, v.Title),
+			Body:      body,
+			CreatedAt: &v.CreatedAt,
+			ClosedAt:  &v.ClosedAt,
+			UpdatedAt: &v.UpdatedAt,
+			Closed:    &v.Closed,
+			Labels:    labels,
+		},
+		Comments: comments,
+		Author:   t.replaceUser(v.Author.Login),
+		Src:      v.Number,
+	}
+
+	var assigneeName string
+	if v.Assignees.TotalCount > 0 {
+		assigneeName = t.replaceUser(v.Assignees.Nodes[0].Login)
+		if t.existUser(ctx, assigneeName) {
+			input.IssueImport.Assignee = &assigneeName
+		}
+	}
+	if n, ok := t.dstMilestone(v.Milestone.Number); ok {
+		input.IssueImport.Milestone = &n
+	}
+
+	return input
+}
+
+func (t *Transfer) buildCreateDummyIssueRequest(tt *time.Time, gap *Gap) *IssueAndCommentsRequest {
+	st := "closed"
+	ti := t.DummyTitle
+	bo := t.dummyBody(gap)
+	labels := []string{}
+	if t.DummyLabel != "" {
+		labels = append(labels, t.DummyLabel)
+	}
+	return &IssueAndCommentsRequest{
+		Issue: &github.IssueRequest{
+			Title:  &ti,
+			Body:   &bo,
+			State:  &st,
+			Labels: &labels,
+		},
+		Comments: nil,
+	}
+}
+
+func (t *Transfer) dummyBody(gap *Gap) string {
+	if gap == nil {
+		return t.DummyBody
+	}
+	return t.DummyBody + "\n\n" + gap.String()
+}
+
+func (t *Transfer) buildCreateIssueRequest(ctx context.Context, v *Issue) *IssueAndCommentsRequest {
+	if v == nil {
+		now := time.Now()
+		return t.buildCreateDummyIssueRequest(&now, nil)
+	}
+
+	state := strings.ToLower(v.State)
+	labels := t.issueLabels(v)
+	a := t.attribution(v.Author.Login, v.Author.AvatarURL, v.URL, v.CreatedAt, v.Number, false)
+	body := t.Templates.Issue(a, t.replaceBody(v.Body))
+	var comments []*github.IssueComment
+	var commentAuthors []string
+	for _, vv := range v.Comments.Nodes {
+		ca := t.attribution(vv.Author.Login, vv.Author.AvatarURL, vv.URL, vv.CreatedAt, v.Number, false)
+		cBody := t.Templates.Comment(ca, t.replaceText(ScopeComments, vv.Body))
+		comments = append(comments, &github.IssueComment{
+			CreatedAt: &vv.CreatedAt,
+			Body:      &cBody,
+		})
+		commentAuthors = append(commentAuthors, t.replaceUser(vv.Author.Login))
+	}
+
+	title := t.replaceText(ScopeTitle, v.Title)
+	input := &IssueAndCommentsRequest{
+		Issue: &github.IssueRequest{
+			Title:  &title,
+			Body:   &body,
+			State:  &state,
+			Labels: &labels,
+		},
+		Comments:       comments,
+		Author:         t.replaceUser(v.Author.Login),
+		CommentAuthors: commentAuthors,
+	}
+
+	var assigneeName string
+	if v.Assignees.TotalCount > 0 {
+		assigneeName = t.replaceUser(v.Assignees.Nodes[0].Login)
+		if t.existUser(ctx, assigneeName) {
+			input.Issue.Assignee = &assigneeName
+		}
+	}
+	if n, ok := t.dstMilestone(v.Milestone.Number); ok {
+		input.Issue.Milestone = &n
+	}
+
+	return input
+}
+
+func (t *Transfer) existUser(ctx context.Context, name string) bool {
+	return t.Destination.UserExists(ctx, name)
+}
+
+func (t *Transfer) replaceUser(n string) string {
+	if t.Replace != nil && len(t.Replace.User) > 0 {
+		for _, v := range t.Replace.User {
+			if v.Wrong == n {
+				return v.Right
+			}
+		}
+	}
+	return n
+}
+
+func (t *Transfer) replaceBody(b string) string {
+	return t.replaceText(ScopeBody, b)
+}
+
+// replaceLabel applies the label rules and then the replacement rules of
+// the labels, and returns false when the label is dropped.
+func (t *Transfer) replaceLabel(v Label) (Label, bool) {
+	if t.Replace == nil {
+		return v, true
+	}
+	v, ok := t.Replace.Label(v)
+	v.Name = t.replaceText(ScopeLabels, v.Name)
+	return v, ok
+}
+
+// issueLabels returns the names of the labels of the issue in DST, which
+// has each of the merged labels once.
+func (t *Transfer) issueLabels(v *Issue) []string {
+	labels := []string{}
+	seen := map[string]bool{}
+	for _, vv := range v.Labels.Nodes {
+		l, ok := t.replaceLabel(Label{Name: vv.Name})
+		if !ok || seen[strings.ToLower(l.Name)] {
+			continue
+		}
+		seen[strings.ToLower(l.Name)] = true
+		labels = append(labels, l.Name)
+	}
+	return labels
+}
+
+// replaceText applies the replacement rules of the scope. The bodies and
+// comments are rewritten as Markdown when configured, so that only the
+// code rules replace their code, and the mentions and links are rewritten
+// when configured.
+func (t *Transfer) replaceText(scope, s string) string {
+	if scope != ScopeBody && scope != ScopeComments {
+		return t.replaceProse(scope, s)
+	}
+	if t.Markdown {
+		prose := func(p string) string { return t.replaceProse(scope, p) }
+		return rewriteMarkdown(s, prose, func(c string) string {
+			if t.Replace == nil {
+				return c
+			}
+			return t.Replace.ReplaceCode(scope, c)
+		})
+	}
+	return t.replaceProse(scope, s)
+}
+
+func (t *Transfer) replaceProse(scope, s string) string {
+	if t.Replace != nil {
+		s = t.Replace.Replace(scope, s)
+	}
+	if scope != ScopeBody && scope != ScopeComments {
+		return s
+	}
+	if t.RewriteMentions {
+		s = rewriteMentions(s, t.replaceUser)
+	}
+	if t.RewriteLinks {
+		s = t.rewriteLinks(s)
+	}
+	return s
+}
+
+func (t *Transfer) ImportIssueStatus(ctx context.Context) {
+	for _, v := range t.ImportRequested {
+		got, _, err := CheckImportIssueStatus(t.DST.Client, ctx, t.DST.Owner, t.DST.Name, int64(v))
+		if err != nil {
+			logf(t.Log, "issue import status error (%s): %#v\n",
+				err.(*github.ErrorResponse).Response.Status,
+				err.(*github.ErrorResponse).Message)
+			continue
+		}
+		if *got.Status == "imported" {
+			continue
+		}
+		logf(t.Log, "%s: %s\n", *got.Status, *got.URL)
+		for _, v := range got.Errors {
+			logf(t.Log, "%s [%s]: %s\n", *v.Field, *v.Code, *v.Value)
+		}
+	}
+}
+
+func (t *Transfer) ShowAssignees(ctx context.Context) error {
+	if err := t.Fetch(ctx); err != nil {
+		return err
+	}
+	for _, v := range t.Issues {
+		var assigneeName string
+		if v.Assignees.TotalCount > 0 {
+			assigneeName = t.replaceUser(v.Assignees.Nodes[0].Login)
+			if t.existUser(ctx, assigneeName) {
+				logf(t.Log, "%d %s\n", v.Number, assigneeName)
+			}
+		}
+	}
+	for _, v := range t.Pulls {
+		var assigneeName string
+		if v.Assignees.TotalCount > 0 {
+			assigneeName = t.replaceUser(v.Assignees.Nodes[0].Login)
+			if t.existUser(ctx, assigneeName) {
+				logf(t.Log, "%d %s\n", v.Number, assigneeName)
+			}
+		}
+	}
+	return nil
+}
+
+// attribution returns the data of the templates of the SRC author.
+func (t *Transfer) attribution(login, avatarURL, url string, createdAt time.Time, number int, imported bool) Attribution {
+	dstLogin := t.replaceUser(login)
+	return Attribution{
+		Author:       login,
+		AvatarURL:    avatarURL,
+		DstAuthor:    dstLogin,
+		DstAvatarURL: t.dstAvatarURL(dstLogin),
+		CreatedAt:    createdAt,
+		URL:          url,
+		Number:       number,
+		Src:          t.Source.Repo(),
+		Imported:     imported,
+	}
+}
+
+func (t *Transfer) dstAvatarURL(login string) string {
+	if login == "" {
+		return ""
+	}
+	switch d := t.Destination.(type) {
+	case *DST:
+		return webURL(d.Endpoint) + "/" + login + ".png"
+	case *Gitea:
+		return strings.TrimSuffix(d.Endpoint, "/") + "/user/avatar/" + login + "/-1"
+	}
+	return ""
+}
+
+func printError(w io.Writer, what string, err error) {
+	if e, ok := err.(*github.ErrorResponse); ok {
+		logf(w, "%s error (%s): %#v\n", what, e.Response.Status, e.Message)
+		return
+	}
+	logf(w, "%s error: %s\n", what, err)
+}
+
+// logf prints the progress to w, or to the standard output when w is nil.
+func logf(w io.Writer, format string, a ...interface{}) {
+	if w == nil {
+		w = os.Stdout
+	}
+	fmt.Fprintf(w, format, a...)
+}
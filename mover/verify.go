@@ -0,0 +1,121 @@
+package mover
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/google/go-github/v32/github"
+)
+
+// Diff is a difference between a SRC issue and the DST issue it was moved to.
+type Diff struct {
+	Src    int
+	Dst    int
+	Reason string
+}
+
+func (d Diff) String() string {
+	return fmt.Sprintf("#%d -> #%d: %s", d.Src, d.Dst, d.Reason)
+}
+
+// dstIssues returns the issues and pull requests of DST by number.
+func (t *Transfer) dstIssues(ctx context.Context) (map[int]*github.Issue, error) {
+	issues := map[int]*github.Issue{}
+	opt := &github.IssueListByRepoOptions{
+		State:       "all",
+		ListOptions: github.ListOptions{PerPage: 100},
+	}
+	for {
+		got, resp, err := t.DST.Client.Issues.ListByRepo(ctx, t.DST.Owner, t.DST.Name, opt)
+		if err != nil {
+			return nil, err
+		}
+		for _, v := range got {
+			issues[v.GetNumber()] = v
+		}
+		if resp.NextPage == 0 {
+			break
+		}
+		opt.Page = resp.NextPage
+	}
+	return issues, nil
+}
+
+// Verify compares the fetched SRC issues and pull requests with the DST
+// issues they were moved to, and returns an error when any of them differ.
+func (t *Transfer) Verify(ctx context.Context) error {
+	if t.DST == nil {
+		return fmt.Errorf("verify is only for GitHub repositories")
+	}
+	dst, err := t.dstIssues(ctx)
+	if err != nil {
+		return err
+	}
+
+	var diffs []Diff
+	for _, v := range t.items() {
+		n, ok := t.State.Numbers[v.Number]
+		if !ok {
+			n = v.Number
+		}
+		d, ok := dst[n]
+		// the noticed SRC issues have the moved notice, and the ones closed
+		// by the back-link were open when moved.
+		comments, closed := int(v.Comments.TotalCount), v.Closed
+		if t.State.IsNoticed(v.Number) && comments > 0 {
+			comments--
+		}
+		if t.State.IsClosedSrc(v.Number) {
+			closed = false
+		}
+		switch {
+		case !ok:
+			diffs = append(diffs, Diff{v.Number, n, "missing"})
+		case d.GetTitle() != t.replaceText(ScopeTitle, v.Title):
+			diffs = append(diffs, Diff{v.Number, n, fmt.Sprintf("title %q != %q", d.GetTitle(), t.replaceText(ScopeTitle, v.Title))})
+		case (d.GetState() == "closed") != closed:
+			diffs = append(diffs, Diff{v.Number, n, fmt.Sprintf("state %s != %s", d.GetState(), v.State)})
+		case d.GetComments() != comments:
+			diffs = append(diffs, Diff{v.Number, n, fmt.Sprintf("%d comments != %d", d.GetComments(), comments)})
+		}
+	}
+
+	sort.Slice(diffs, func(i, j int) bool { return diffs[i].Src < diffs[j].Src })
+	for _, d := range diffs {
+		logf(t.Log, "differs: %s\n", d)
+	}
+	logf(t.Log, "verified: %d issues and pull requests, %d differ\n", len(t.items()), len(diffs))
+	if len(diffs) > 0 {
+		return fmt.Errorf("%d issues and pull requests differ", len(diffs))
+	}
+	return nil
+}
+
+// ShowUsers prints the users of the fetched issues, their names in DST
+// after the replacement and whether they exist in DST.
+func (t *Transfer) ShowUsers(ctx context.Context) {
+	count := map[string]int{}
+	for _, v := range t.items() {
+		count[v.Author.Login]++
+		for _, a := range v.Assignees.Nodes {
+			count[a.Login]++
+		}
+		for _, c := range v.Comments.Nodes {
+			count[c.Author.Login]++
+		}
+	}
+	delete(count, "")
+
+	var users []string
+	for k := range count {
+		users = append(users, k)
+	}
+	sort.Strings(users)
+
+	logf(t.Log, "%-30s %-30s %-6s %s\n", "SRC", "DST", "EXISTS", "COUNT")
+	for _, u := range users {
+		name := t.replaceUser(u)
+		logf(t.Log, "%-30s %-30s %-6t %d\n", u, name, t.existUser(ctx, name), count[u])
+	}
+}
@@ -0,0 +1,43 @@
+package mover
+
+import (
+	"context"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestVerifyComments(t *testing.T) {
+	f := newFakeGitHub()
+	defer f.Close()
+	f.AddUser("alice-dst")
+	seedSrc(f)
+	dir := testDir(t)
+	defer os.RemoveAll(dir)
+
+	tr, err := New(context.Background(), testOptions(f, dir), testShared())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := tr.Exec(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if err := tr.Verify(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	// the comments beyond the first 100 are counted.
+	src := f.Repo(testSrc).Issues[2]
+	for i := 0; i < 101; i++ {
+		src.Comments.Nodes = append(src.Comments.Nodes, IssueComment{Body: "a comment", CreatedAt: time.Date(2019, 1, 2, 0, 0, 0, 0, time.UTC)})
+	}
+	dst := f.Repo(testDst).Issues[2]
+	dst.Comments.Nodes = append(dst.Comments.Nodes, src.Comments.Nodes[:100]...)
+	if err := tr.Fetch(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if err := tr.Verify(context.Background()); err == nil || !strings.Contains(err.Error(), "1 issues") {
+		t.Errorf("verify of 100 of 101 comments: %v", err)
+	}
+}
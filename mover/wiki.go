@@ -0,0 +1,198 @@
+package mover
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	defaultWebURL   = "https://github.com"
+	wikiCommitName  = "github-issues-mover"
+	wikiCommitEmail = "github-issues-mover@users.noreply.github.com"
+)
+
+// wikiExts are the extensions of the wiki pages rewritten by the mover.
+var wikiExts = map[string]bool{
+	".md":       true,
+	".markdown": true,
+	".mdown":    true,
+	".mkdn":     true,
+}
+
+// GitTransport clones, commits and pushes the git repositories of wikis.
+// token is empty for repositories that need no authentication, such as
+// local bare repositories.
+type GitTransport interface {
+	Clone(ctx context.Context, url, token, dir string) error
+	Commit(ctx context.Context, dir, message string) (bool, error)
+	Push(ctx context.Context, dir, url, token string) error
+}
+
+// ExecGitTransport is the GitTransport with the git command.
+type ExecGitTransport struct {
+	Command string
+}
+
+func (g *ExecGitTransport) Clone(ctx context.Context, url, token, dir string) error {
+	_, err := g.run(ctx, "", token, "clone", "--quiet", url, dir)
+	return err
+}
+
+func (g *ExecGitTransport) Commit(ctx context.Context, dir, message string) (bool, error) {
+	if _, err := g.run(ctx, dir, "", "add", "--all"); err != nil {
+		return false, err
+	}
+	out, err := g.run(ctx, dir, "", "status", "--porcelain")
+	if err != nil {
+		return false, err
+	}
+	if len(bytes.TrimSpace(out)) == 0 {
+		return false, nil
+	}
+	_, err = g.run(ctx, dir, "",
+		"-c", "user.name="+wikiCommitName, "-c", "user.email="+wikiCommitEmail,
+		"commit", "--quiet", "-m", message)
+	return err == nil, err
+}
+
+func (g *ExecGitTransport) Push(ctx context.Context, dir, url, token string) error {
+	_, err := g.run(ctx, dir, token, "push", "--quiet", "--force", "--all", url)
+	return err
+}
+
+func (g *ExecGitTransport) run(ctx context.Context, dir, token string, args ...string) ([]byte, error) {
+	command := g.Command
+	if command == "" {
+		command = "git"
+	}
+	cmd := exec.CommandContext(ctx, command, args...)
+	cmd.Dir = dir
+	if token != "" {
+		// the token is given by a header of the config in the environment,
+		// so that it is neither saved in the remote url nor seen in the
+		// arguments by ps. GIT_CONFIG_COUNT needs git 2.31 or later.
+		auth := base64.StdEncoding.EncodeToString([]byte("x-access-token:" + token))
+		cmd.Env = append(os.Environ(),
+			"GIT_CONFIG_COUNT=1",
+			"GIT_CONFIG_KEY_0=http.extraHeader",
+			"GIT_CONFIG_VALUE_0=Authorization: Basic "+auth,
+		)
+	}
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("git: %s: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	return out, nil
+}
+
+// webURL returns the web url of GitHub or GitHub Enterprise from its api endpoint.
+func webURL(endpoint string) string {
+	if endpoint == "" || endpoint == defaultEndpoint {
+		return defaultWebURL
+	}
+	u, err := url.Parse(endpoint)
+	if err != nil || u.Host == "" {
+		return strings.TrimSuffix(endpoint, "/")
+	}
+	return u.Scheme + "://" + u.Host
+}
+
+func (t *Transfer) srcWikiURL() string {
+	if t.SrcWikiURL != "" {
+		return t.SrcWikiURL
+	}
+	return fmt.Sprintf("%s/%s/%s.wiki.git", webURL(t.SRC.Endpoint), t.SRC.Owner, t.SRC.Name)
+}
+
+func (t *Transfer) dstWikiURL() string {
+	if t.DstWikiURL != "" {
+		return t.DstWikiURL
+	}
+	return fmt.Sprintf("%s/%s/%s.wiki.git", webURL(t.DST.Endpoint), t.DST.Owner, t.DST.Name)
+}
+
+// DoWiki clones the SRC wiki, rewrites its pages and pushes it to the DST wiki.
+// The DST wiki has to be created by adding the first page on the web beforehand.
+func (t *Transfer) DoWiki(ctx context.Context) error {
+	tmp, err := ioutil.TempDir("", "github-issues-mover-wiki")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tmp)
+	dir := filepath.Join(tmp, "wiki")
+
+	if err := t.Git.Clone(ctx, t.srcWikiURL(), accessToken(t.Log, t.SRC.TokenSource), dir); err != nil {
+		return err
+	}
+	logf(t.Log, "cloned wiki: %s\n", t.srcWikiURL())
+
+	err = filepath.Walk(dir, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			if info.Name() == ".git" {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !wikiExts[strings.ToLower(filepath.Ext(p))] {
+			return nil
+		}
+		buf, err := ioutil.ReadFile(p)
+		if err != nil {
+			return err
+		}
+		page := t.replaceBody(string(buf))
+		// the links of the wiki are rewritten even without -rewrite-links.
+		if !t.RewriteLinks {
+			page = t.rewriteLinks(page)
+		}
+		if page == string(buf) {
+			return nil
+		}
+		logf(t.Log, "rewrote wiki page: %s\n", strings.TrimPrefix(p, dir+string(filepath.Separator)))
+		return ioutil.WriteFile(p, []byte(page), info.Mode())
+	})
+	if err != nil {
+		return err
+	}
+
+	committed, err := t.Git.Commit(ctx, dir, fmt.Sprintf("Move wiki from %s/%s", t.SRC.Owner, t.SRC.Name))
+	if err != nil {
+		return err
+	}
+	if committed {
+		logf(t.Log, "committed wiki changes\n")
+	}
+
+	if err := t.Git.Push(ctx, dir, t.dstWikiURL(), accessToken(t.Log, t.DST.TokenSource)); err != nil {
+		return err
+	}
+	logf(t.Log, "pushed wiki: %s\n", t.dstWikiURL())
+
+	return nil
+}
+
+// rewriteLinks points the links to the SRC repository at the DST repository.
+func (t *Transfer) rewriteLinks(b string) string {
+	if t.SRC == nil {
+		return b
+	}
+	src := fmt.Sprintf("%s/%s/%s", webURL(t.SRC.Endpoint), t.SRC.Owner, t.SRC.Name)
+	dst := t.dstRepoURL()
+	if dst == "" {
+		return b
+	}
+	return strings.ReplaceAll(b, src, dst)
+}